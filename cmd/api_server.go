@@ -0,0 +1,549 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+	"github.com/tingchima/gogolook/configs"
+	"github.com/tingchima/gogolook/infra"
+	"github.com/tingchima/gogolook/infra/health"
+	"github.com/tingchima/gogolook/infra/idempotency"
+	"github.com/tingchima/gogolook/infra/metrics"
+	"github.com/tingchima/gogolook/infra/notifier"
+	"github.com/tingchima/gogolook/infra/ratelimit"
+	"github.com/tingchima/gogolook/infra/tracing"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/reminder"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	handler_graphql "github.com/tingchima/gogolook/internal/handler/graphql"
+	handler_grpc "github.com/tingchima/gogolook/internal/handler/grpc"
+	handler_http "github.com/tingchima/gogolook/internal/handler/http"
+	"github.com/tingchima/gogolook/internal/repository/cache"
+	"google.golang.org/grpc"
+)
+
+// newAPIServerCmd .
+func newAPIServerCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "api-server",
+		Short: "Run the http api server until SIGINT or SIGTERM",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			// graceful shutdown process
+			rootCtx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			return RunServer(rootCtx, cfg)
+		},
+	}
+}
+
+// RunServer runs until ctx is done, or the http server fails to serve.
+func RunServer(ctx context.Context, cfg *configs.AppConfig) error {
+
+	dbCfg := cfg.Database()
+
+	// the tracer provider should be set before opening the database, and shut down after it is closed
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing(), Version)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server().ShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("shutdown tracing fail", "err", err)
+		}
+	}()
+
+	features := cfg.Features()
+
+	// new relative infra
+	postgresConn, err := newPostgresConn(ctx, dbCfg)
+	if err != nil {
+		return err
+	}
+	defer postgresConn.Close()
+
+	postgresReplicas, err := infra.NewPostgresReplicaConns(dbCfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range postgresReplicas {
+			_ = postgresReplicas[i].Close()
+		}
+	}()
+
+	err = prepareSchema(cfg)
+	if err != nil {
+		return err
+	}
+
+	// the task events are only delivered in-process without the listener
+	var postgresListener *infra.PostgresListener
+	if features.EventPropagation {
+		postgresListener = infra.NewPostgresListener(dbCfg)
+	}
+
+	webhookCfg := cfg.Webhook()
+
+	reminderNotifiers := map[domain.ReminderChannel]reminder.Notifier{
+		domain.ReminderChannelLog: notifier.NewLogNotifier(),
+		domain.ReminderChannelWebhook: notifier.NewWebhookNotifier(notifier.WebhookConfig{
+			Timeout:             webhookCfg.Timeout,
+			AllowedHosts:        webhookCfg.AllowedHosts,
+			AllowPrivateNetwork: webhookCfg.AllowPrivateNetwork,
+		}),
+	}
+
+	if smtpCfg := cfg.SMTP(); smtpCfg.Host != "" {
+		reminderNotifiers[domain.ReminderChannelSMTP] = notifier.NewSMTPNotifier(notifier.SMTPConfig{
+			Host:     smtpCfg.Host,
+			Port:     smtpCfg.Port,
+			Username: smtpCfg.Username,
+			Password: smtpCfg.Password,
+			From:     smtpCfg.From,
+			StartTLS: smtpCfg.StartTLS,
+		})
+	}
+
+	var appMetrics *metrics.Metrics
+	if features.Metrics {
+		appMetrics = metrics.New()
+
+		err = appMetrics.RegisterDBStats(postgresConn.DB, dbCfg.DBName)
+		if err != nil {
+			return fmt.Errorf("register db metrics: %w", err)
+		}
+	}
+
+	var taskCache *cache.TaskRepositoryParam
+	if taskCacheCfg := cfg.TaskCache(); taskCacheCfg.Enabled {
+		taskCache = &cache.TaskRepositoryParam{Size: taskCacheCfg.Size, TTL: taskCacheCfg.TTL}
+
+		// the misses are read from the replicas, which may lag behind the writes
+		if len(dbCfg.ReplicaDSNs) > 0 {
+			taskCache.ReplicaLag = dbCfg.ReplicaLag
+		}
+	}
+
+	// new app
+	app, err := application.NewApplication(application.ApplicationParam{
+		PostgresConn:      postgresConn,
+		PostgresReplicas:  postgresReplicas,
+		PostgresListener:  postgresListener,
+		ReminderNotifiers: reminderNotifiers,
+		Metrics:           appMetrics,
+		TaskCache:         taskCache,
+	})
+	if err != nil {
+		return fmt.Errorf("new application: %w", err)
+	}
+
+	if appMetrics != nil {
+		err = appMetrics.RegisterTaskCounter(app.TaskService)
+		if err != nil {
+			return fmt.Errorf("register task metrics: %w", err)
+		}
+	}
+
+	serverCfg := cfg.Server()
+
+	checker, err := newHealthChecker(serverCfg, postgresConn, postgresListener, app, features)
+	if err != nil {
+		return err
+	}
+
+	gin.SetMode(serverCfg.Mode)
+
+	// new handler
+	handler := gin.New()
+
+	// use middleware
+	handler.Use(
+		handler_http.RequestID(),
+		handler_http.Tracing(),
+		handler_http.AccessLog(slog.Default()),
+		handler_http.Recovery(),
+	)
+
+	handler.HandleMethodNotAllowed = true
+	handler.NoMethod(handler_http.MethodNotAllowed())
+
+	if appMetrics != nil {
+		handler.Use(handler_http.Metrics(appMetrics))
+	}
+
+	// the probes and the api document are registered before the auth middleware, they are not authenticated
+	handler_http.RegisterHealthHandlers(handler, checker)
+
+	handler_http.RegisterOpenAPIHandlers(handler)
+
+	if authCfg := cfg.Auth(); authCfg.Enabled {
+		handler.Use(handler_http.APIKeyAuth(authCfg.APIKeys))
+	}
+
+	// after the auth, so that the authenticated clients are limited by their API keys
+	var (
+		limiter        *ratelimit.Limiter
+		rateLimitStore *ratelimit.PostgresStore
+	)
+	if rateLimitCfg := cfg.RateLimit(); rateLimitCfg.Enabled {
+		limiter, rateLimitStore, err = newRateLimiter(rateLimitCfg, postgresConn)
+		if err != nil {
+			return err
+		}
+
+		handler.Use(handler_http.RateLimit(limiter))
+	}
+
+	// after the auth, so that the windows are tracked by the principal, the reads follow
+	// the writes of the same client without the replicas
+	var stickyPrimary *common.StickyPrimary
+	if len(dbCfg.ReplicaDSNs) > 0 {
+		stickyPrimary = common.NewStickyPrimary(dbCfg.ReplicaLag)
+
+		handler.Use(handler_http.StickyPrimaryRead(stickyPrimary))
+	}
+
+	// after the auth, so that the keys are scoped by the principal
+	var (
+		idempotencyStore *idempotency.PostgresStore
+		grpcIdempotency  handler_grpc.IdempotencyParam
+	)
+	if idempotencyCfg := cfg.Idempotency(); idempotencyCfg.Enabled {
+		var store idempotency.Store = idempotency.NewMemoryStore()
+
+		if idempotencyCfg.Store == "postgres" {
+			idempotencyStore = idempotency.NewPostgresStore(postgresConn)
+			store = idempotencyStore
+		}
+
+		handler.Use(handler_http.Idempotency(handler_http.IdempotencyParam{
+			Store:       store,
+			TTL:         idempotencyCfg.TTL,
+			LockTimeout: idempotencyCfg.LockTimeout,
+		}))
+
+		// the keys are shared by the HTTP requests and the gRPC calls
+		grpcIdempotency = handler_grpc.IdempotencyParam{
+			Store:       store,
+			TTL:         idempotencyCfg.TTL,
+			LockTimeout: idempotencyCfg.LockTimeout,
+		}
+	}
+
+	// register http handlers
+	handler_http.RegisterHandlers(handler, app)
+
+	if features.CalDAV {
+		handler_http.RegisterCalDAVHandlers(handler, app)
+	}
+
+	if graphqlCfg := cfg.GraphQL(); graphqlCfg.Enabled {
+		graphqlHandler := gin.WrapH(handler_graphql.NewHandler(handler_graphql.HandlerParam{
+			App:             app,
+			ComplexityLimit: graphqlCfg.ComplexityLimit,
+			Introspection:   graphqlCfg.Introspection,
+		}))
+
+		handler.GET(handler_graphql.Path, graphqlHandler)
+		handler.POST(handler_graphql.Path, graphqlHandler)
+	}
+
+	// the scraper sends the API key as bearer token when auth is enabled
+	if appMetrics != nil {
+		handler.GET(handler_http.MetricsPath, gin.WrapH(appMetrics.Handler()))
+	}
+
+	// new server
+	server := http.Server{
+		Addr:              fmt.Sprintf(":%s", serverCfg.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: serverCfg.ReadHeaderTimeout,
+		IdleTimeout:       serverCfg.IdleTimeout,
+	}
+
+	// new grpc server, it listens before serving so that a port in use fails the startup
+	var (
+		grpcServer   *grpc.Server
+		grpcListener net.Listener
+	)
+
+	if grpcCfg := cfg.GRPC(); grpcCfg.Enabled {
+		grpcParam := handler_grpc.ServerParam{
+			App:           app,
+			Reflection:    grpcCfg.Reflection,
+			Limiter:       limiter,
+			Idempotency:   grpcIdempotency,
+			StickyPrimary: stickyPrimary,
+		}
+		if authCfg := cfg.Auth(); authCfg.Enabled {
+			grpcParam.APIKeys = authCfg.APIKeys
+		}
+
+		grpcServer = handler_grpc.NewServer(grpcParam)
+
+		grpcListener, err = net.Listen("tcp", fmt.Sprintf(":%s", grpcCfg.Port))
+		if err != nil {
+			return fmt.Errorf("listen grpc: %w", err)
+		}
+	}
+
+	// the background processes are stopped when any server fails to serve
+	rootCtx, rootCtxCancel := context.WithCancel(ctx)
+	defer rootCtxCancel()
+
+	wg := &sync.WaitGroup{}
+
+	var serveErr, grpcServeErr error
+
+	// run server
+	{
+		wg.Add(2)
+
+		// run http server in background
+		go func() {
+			defer wg.Done()
+
+			err := server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serveErr = err
+				rootCtxCancel()
+			}
+		}()
+
+		// init shutdown http server process in background
+		go func() {
+			defer wg.Done()
+
+			<-rootCtx.Done()
+
+			// report not ready first, and keep serving until the load balancers stop routing
+			checker.SetShuttingDown()
+			if ctx.Err() != nil && serverCfg.DrainDelay > 0 {
+				slog.Info("draining before shutdown", "delay", serverCfg.DrainDelay)
+				time.Sleep(serverCfg.DrainDelay)
+			}
+
+			// close the event streams first, otherwise they keep the servers from shutting down
+			app.Close()
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serverCfg.ShutdownTimeout)
+			defer cancel()
+
+			if grpcServer != nil {
+				go func() {
+					<-shutdownCtx.Done()
+					grpcServer.Stop()
+				}()
+			}
+
+			err := server.Shutdown(shutdownCtx)
+			if err != nil {
+				slog.Error("shutdown http server fail", "err", err)
+			}
+
+			// the remaining calls are cancelled by Stop after the shutdown timeout
+			if grpcServer != nil {
+				grpcServer.GracefulStop()
+			}
+		}()
+
+		// run grpc server in background
+		if grpcServer != nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := grpcServer.Serve(grpcListener)
+				if err != nil {
+					grpcServeErr = err
+					rootCtxCancel()
+				}
+			}()
+		}
+
+		// propagate task events across instances in background
+		if postgresListener != nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := postgresListener.Run(rootCtx)
+				if err != nil {
+					slog.Error("run pg listener fail", "err", err)
+				}
+			}()
+		}
+
+		// purge the idle rate limit buckets in background
+		if rateLimitStore != nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				rateLimitStore.Run(rootCtx, rateLimitIdle)
+			}()
+		}
+
+		// purge the expired idempotency keys in background
+		if idempotencyStore != nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				idempotencyStore.Run(rootCtx)
+			}()
+		}
+
+		// bring the recovered replicas back in background
+		if len(postgresReplicas) > 0 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				app.RunReplicaCheck(rootCtx)
+			}()
+		}
+
+		// deliver the due reminders in background
+		if features.Reminder {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				app.ReminderScheduler.Run(rootCtx)
+			}()
+		}
+	}
+
+	// wait all process be done
+	wg.Wait()
+
+	if serveErr != nil {
+		return fmt.Errorf("serve http: %w", serveErr)
+	}
+
+	if grpcServeErr != nil {
+		return fmt.Errorf("serve grpc: %w", grpcServeErr)
+	}
+
+	return nil
+}
+
+// rateLimitIdle is long enough for the buckets to be full again.
+const rateLimitIdle = time.Hour
+
+// newRateLimiter returns the postgres store if it is used, its idle buckets should be purged.
+func newRateLimiter(cfg *configs.RateLimit, postgresConn *sqlx.DB) (*ratelimit.Limiter, *ratelimit.PostgresStore, error) {
+
+	rules := make([]ratelimit.Rule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		rules[i] = ratelimit.Rule{
+			Route:     rule.Route,
+			Principal: rule.Principal,
+			Limit: ratelimit.Limit{
+				Requests: rule.Requests,
+				Period:   rule.Period,
+				Burst:    rule.Burst,
+			},
+		}
+	}
+
+	var (
+		store         ratelimit.Store = ratelimit.NewMemoryStore()
+		postgresStore *ratelimit.PostgresStore
+	)
+
+	if cfg.Store == "postgres" {
+		postgresStore = ratelimit.NewPostgresStore(postgresConn)
+		store = postgresStore
+	}
+
+	limiter, err := ratelimit.NewLimiter(store, rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("new rate limiter: %w", err)
+	}
+
+	return limiter, postgresStore, nil
+}
+
+// newHealthChecker checks the database, the schema version and the background workers which are enabled.
+func newHealthChecker(
+	serverCfg *configs.Server,
+	postgresConn *sqlx.DB,
+	postgresListener *infra.PostgresListener,
+	app *application.Application,
+	features *configs.Features,
+) (*health.Checker, error) {
+
+	latestVersion, err := infra.EmbeddedLatestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	checker := health.NewChecker(serverCfg.ReadinessTimeout)
+
+	checker.Add("database", func(ctx context.Context) error {
+		return postgresConn.PingContext(ctx)
+	})
+
+	checker.Add("migration", func(ctx context.Context) error {
+		return infra.CheckSchemaVersion(ctx, postgresConn, latestVersion)
+	})
+
+	if postgresListener != nil {
+		checker.Add("pg_listener", postgresListener.Check)
+	}
+
+	if features.Reminder {
+		checker.Add("reminder_scheduler", app.ReminderScheduler.Check)
+	}
+
+	return checker, nil
+}
+
+// newPostgresConn opens the connection pool and checks that the database is reachable.
+func newPostgresConn(ctx context.Context, cfg *configs.Database) (*sqlx.DB, error) {
+
+	conn, err := infra.NewPostgresConn(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect postgres: %w", err)
+	}
+
+	err = conn.PingContext(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("connect postgres %s:%s: %w", cfg.Host, cfg.Port, err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,59 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newConfigCmd .
+func newConfigCmd(opts *rootOptions) *cobra.Command {
+	return newGroupCmd("config", "Inspect the application config",
+		newConfigPrintCmd(opts),
+	)
+}
+
+// newConfigPrintCmd .
+func newConfigPrintCmd(opts *rootOptions) *cobra.Command {
+
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective config of profile and environment variables, the secrets are redacted",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+
+			if output != "yaml" && output != "json" {
+				return usageError{err: errors.New("the output should be yaml or json")}
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			settings := cfg.Redacted()
+
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(settings)
+			}
+
+			enc := yaml.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent(2)
+			if err = enc.Encode(settings); err != nil {
+				return err
+			}
+			return enc.Close()
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "yaml or json")
+
+	return cmd
+}
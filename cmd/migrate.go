@@ -0,0 +1,246 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/tingchima/gogolook/configs"
+	"github.com/tingchima/gogolook/infra"
+)
+
+// migrateOptions .
+type migrateOptions struct {
+	*rootOptions
+
+	dir string
+}
+
+// newMigrator uses the embedded migrations unless --dir is given.
+func (o *migrateOptions) newMigrator() (*infra.Migrator, error) {
+
+	cfg, err := o.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.dir == "" {
+		return infra.NewEmbeddedMigrator(cfg.Database())
+	}
+
+	dir, err := filepath.Abs(o.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return infra.NewMigrator(cfg.Database(), "file://"+filepath.ToSlash(dir))
+}
+
+// newMigrateCmd .
+func newMigrateCmd(root *rootOptions) *cobra.Command {
+
+	opts := &migrateOptions{rootOptions: root}
+
+	cmd := newGroupCmd("migrate", "Migrate the database schema",
+		newMigrateUpCmd(opts),
+		newMigrateDownCmd(opts),
+		newMigrateStatusCmd(opts),
+		newMigrateForceCmd(opts),
+	)
+
+	cmd.PersistentFlags().StringVar(&opts.dir, "dir", "", "directory of the migration files, the embedded migrations are used if it is empty")
+
+	return cmd
+}
+
+// parseSteps parses the optional number of migrations, 0 means all.
+func parseSteps(args []string) (int, error) {
+
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, usageError{err: fmt.Errorf("the number of migrations should be a positive integer, got %q", args[0])}
+	}
+
+	return n, nil
+}
+
+// runMigration reports no change as success.
+func runMigration(cmd *cobra.Command, opts *migrateOptions, run func(m *infra.Migrator) error) error {
+
+	m, err := opts.newMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	err = run(m)
+	if errors.Is(err, infra.ErrMigrationNoChange) {
+		fmt.Fprintln(cmd.OutOrStdout(), "no change")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "migrated to version %d\n", status.Version)
+
+	return nil
+}
+
+// newMigrateUpCmd .
+func newMigrateUpCmd(opts *migrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "up [N]",
+		Short: "Apply all or N pending migrations",
+		Args:  usageArgs(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			n, err := parseSteps(args)
+			if err != nil {
+				return err
+			}
+
+			return runMigration(cmd, opts, func(m *infra.Migrator) error {
+				return m.Up(n)
+			})
+		},
+	}
+}
+
+// newMigrateDownCmd .
+func newMigrateDownCmd(opts *migrateOptions) *cobra.Command {
+
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "down [N | --all]",
+		Short: "Roll back N migrations, or all of them with --all",
+		Args:  usageArgs(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			// rolling back everything drops all of the data, it should be explicit
+			if all == (len(args) == 1) {
+				return usageError{err: errors.New("either the number of migrations or --all is required")}
+			}
+
+			n, err := parseSteps(args)
+			if err != nil {
+				return err
+			}
+
+			return runMigration(cmd, opts, func(m *infra.Migrator) error {
+				return m.Down(n)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "roll back all migrations")
+
+	return cmd
+}
+
+// newMigrateStatusCmd .
+func newMigrateStatusCmd(opts *migrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Print the current and the latest migration version",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+
+			m, err := opts.newMigrator()
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			status, err := m.Status()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "version: %d\n", status.Version)
+			fmt.Fprintf(cmd.OutOrStdout(), "dirty: %t\n", status.Dirty)
+			fmt.Fprintf(cmd.OutOrStdout(), "latest: %d\n", status.Latest)
+			fmt.Fprintf(cmd.OutOrStdout(), "pending: %d\n", status.Pending)
+
+			if status.Dirty {
+				return fmt.Errorf("the version %d is dirty, fix the schema and run migrate force", status.Version)
+			}
+
+			return nil
+		},
+	}
+}
+
+// newMigrateForceCmd .
+func newMigrateForceCmd(opts *migrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "force VERSION",
+		Short: "Set the version without running migrations and clear the dirty state, use \"force -- -1\" for no migration",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < -1 {
+				return usageError{err: fmt.Errorf("the version should be an integer not less than -1, got %q", args[0])}
+			}
+
+			m, err := opts.newMigrator()
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			err = m.Force(version)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "forced to version %d\n", version)
+
+			return nil
+		},
+	}
+}
+
+// prepareSchema applies the embedded migrations if migration.auto is set, the instances
+// wait for each other by the advisory lock. The server should not run on the older schema.
+func prepareSchema(cfg *configs.AppConfig) error {
+
+	m, err := infra.NewEmbeddedMigrator(cfg.Database())
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if migrationCfg := cfg.Migration(); migrationCfg.Auto {
+		m.SetLockTimeout(migrationCfg.LockTimeout)
+
+		err = m.Up(0)
+		if err != nil && !errors.Is(err, infra.ErrMigrationNoChange) {
+			return fmt.Errorf("migrate up: %w", err)
+		}
+	}
+
+	status, err := m.CheckSchema()
+	if err != nil {
+		return fmt.Errorf("check schema: %w", err)
+	}
+
+	slog.Info("database schema is up to date", "version", status.Version, "latest", status.Latest)
+
+	return nil
+}
@@ -0,0 +1,154 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tingchima/gogolook/configs"
+	"github.com/tingchima/gogolook/infra/logger"
+)
+
+// exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1
+	// ExitUsage is the wrong command, arguments or flags
+	ExitUsage = 2
+)
+
+// DefaultProfile is used when neither --profile nor APP_PROFILE is given.
+const DefaultProfile = configs.ProfileDev
+
+// usageError is exited with ExitUsage.
+type usageError struct {
+	err error
+}
+
+// Error .
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap .
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+// usageArgs reports the errors of args as usageError.
+func usageArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, a []string) error {
+		if err := args(cmd, a); err != nil {
+			return usageError{err: err}
+		}
+		return nil
+	}
+}
+
+// rootOptions are shared by the subcommands.
+type rootOptions struct {
+	profile string
+
+	// logOutput of the default logger
+	logOutput io.Writer
+}
+
+// loadConfig also sets up the default logger by the log config.
+func (o *rootOptions) loadConfig() (*configs.AppConfig, error) {
+
+	cfg, err := configs.LoadConfig(o.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = logger.Setup(cfg.Log(), o.logOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// profileFromEnv .
+func profileFromEnv() string {
+	if profile := os.Getenv(configs.EnvPrefix + "_PROFILE"); profile != "" {
+		return profile
+	}
+	return DefaultProfile
+}
+
+// newGroupCmd is a command which only has subcommands, it prints the help without subcommand.
+func newGroupCmd(use string, short string, subcommands ...*cobra.Command) *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(subcommands...)
+
+	return cmd
+}
+
+// newRootCmd .
+func newRootCmd(logOutput io.Writer) *cobra.Command {
+
+	opts := &rootOptions{logOutput: logOutput}
+
+	root := newGroupCmd("gogolook", "gogolook task api server",
+		newAPIServerCmd(opts),
+		newMigrateCmd(opts),
+		newSeedCmd(opts),
+		newConfigCmd(opts),
+		newVersionCmd(),
+	)
+
+	root.SilenceUsage = true
+	root.SilenceErrors = true
+
+	root.PersistentFlags().StringVar(&opts.profile, "profile", profileFromEnv(),
+		"config profile, e.g. app-dev, app-prod, the env APP_PROFILE is used if it is not given")
+
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err: err}
+	})
+
+	return root
+}
+
+// Execute runs the command of os.Args and returns the exit code.
+func Execute() int {
+	return execute(os.Args[1:], os.Stdout, os.Stderr)
+}
+
+// execute .
+func execute(args []string, stdout io.Writer, stderr io.Writer) int {
+
+	root := newRootCmd(stderr)
+	root.SetArgs(args)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return ExitOK
+	}
+
+	// the errors of database may contain the DSN
+	fmt.Fprintf(stderr, "Error: %s\n", logger.Redact(err.Error()))
+
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		return ExitUsage
+	}
+
+	return ExitError
+}
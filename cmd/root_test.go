@@ -0,0 +1,104 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tingchima/gogolook/configs"
+)
+
+// TestExecute only covers the commands which do not need a database.
+func TestExecute(t *testing.T) {
+
+	t.Setenv("APP_DATABASE_PASSWORD", "secret-password")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  []string
+		wantErr  []string
+	}{
+		{
+			name:     "version",
+			args:     []string{"version"},
+			wantCode: ExitOK,
+			wantOut:  []string{"gogolook dev"},
+		},
+		{
+			name:     "help",
+			args:     []string{},
+			wantCode: ExitOK,
+			wantOut:  []string{"api-server", "migrate", "seed"},
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"serve"},
+			wantCode: ExitUsage,
+			wantErr:  []string{"Run 'gogolook --help' for usage."},
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"version", "--verbose"},
+			wantCode: ExitUsage,
+		},
+		{
+			name:     "migrate down without steps",
+			args:     []string{"migrate", "down"},
+			wantCode: ExitUsage,
+			wantErr:  []string{"either the number of migrations or --all is required", "gogolook migrate down --help"},
+		},
+		{
+			name:     "migrate up with invalid steps",
+			args:     []string{"migrate", "up", "abc"},
+			wantCode: ExitUsage,
+		},
+		{
+			name:     "migrate force without version",
+			args:     []string{"migrate", "force"},
+			wantCode: ExitUsage,
+		},
+		{
+			name:     "seed with unknown format",
+			args:     []string{"seed", "--format", "xml"},
+			wantCode: ExitUsage,
+		},
+		{
+			name:     "config print",
+			args:     []string{"config", "print", "--profile", configs.ProfileDev},
+			wantCode: ExitOK,
+			wantOut:  []string{"password: '" + configs.RedactedValue + "'"},
+		},
+		{
+			name:     "config print json",
+			args:     []string{"config", "print", "-o", "json", "--profile", configs.ProfileDev},
+			wantCode: ExitOK,
+			wantOut:  []string{`"password": "` + configs.RedactedValue + `"`},
+		},
+		{
+			name:     "config print with missing profile",
+			args:     []string{"config", "print", "--profile", "app-missing"},
+			wantCode: ExitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var stdout, stderr bytes.Buffer
+
+			code := execute(tt.args, &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code, stderr.String())
+
+			for _, s := range tt.wantOut {
+				assert.Contains(t, stdout.String(), s)
+			}
+			for _, s := range tt.wantErr {
+				assert.Contains(t, stderr.String(), s)
+			}
+			assert.NotContains(t, stdout.String(), "secret-password")
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/taskio"
+)
+
+// sampleTasks are seeded without --file, they have external IDs so that seeding again updates them.
+//
+//go:embed seed_tasks.md
+var sampleTasks []byte
+
+// newSeedCmd .
+func newSeedCmd(opts *rootOptions) *cobra.Command {
+
+	var (
+		file   string
+		format string
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Import the sample tasks, or the tasks of --file in any import format",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+
+			var (
+				r          io.Reader = bytes.NewReader(sampleTasks)
+				taskFormat           = taskio.FormatMarkdown
+				err        error
+			)
+
+			if file != "" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				r = f
+
+				if format == "" {
+					format = strings.TrimPrefix(filepath.Ext(file), ".")
+				}
+			}
+
+			if format != "" {
+				taskFormat, err = taskio.ParseFormat(format)
+				if err != nil {
+					return usageError{err: err}
+				}
+			}
+
+			rows, rowErrs, err := taskio.Decode(taskFormat, r, task.MaxImportRows)
+			if err != nil {
+				return err
+			}
+
+			if len(rowErrs) > 0 {
+				msgs := make([]string, len(rowErrs))
+				for i := range rowErrs {
+					msgs[i] = rowErrs[i].Error()
+				}
+				return errors.New("some rows are invalid:\n" + strings.Join(msgs, "\n"))
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			postgresConn, err := newPostgresConn(cmd.Context(), cfg.Database())
+			if err != nil {
+				return err
+			}
+			defer postgresConn.Close()
+
+			app, err := application.NewApplication(application.ApplicationParam{PostgresConn: postgresConn})
+			if err != nil {
+				return err
+			}
+			defer app.Close()
+
+			results, err := app.TaskService.ImportTasks(cmd.Context(), rows, dryRun)
+			if err != nil {
+				return err
+			}
+
+			return printSeedResults(cmd.OutOrStdout(), results, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "file of the tasks, the sample tasks are seeded if it is empty")
+	cmd.Flags().StringVar(&format, "format", "", "csv, json, ndjson, todotxt or markdown, default by the file extension")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only validate the tasks")
+
+	return cmd
+}
+
+// printSeedResults .
+func printSeedResults(w io.Writer, results []domain.TaskImportResult, dryRun bool) error {
+
+	var created, updated int
+	for i := range results {
+		if results[i].Action == domain.TaskImportCreate {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	prefix := ""
+	if dryRun {
+		prefix = "(dry run) "
+	}
+
+	_, err := fmt.Fprintf(w, "%screated: %d, updated: %d\n", prefix, created, updated)
+	return err
+}
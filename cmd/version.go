@@ -0,0 +1,56 @@
+// Package cmd provides the command line interface, e.g. api-server, migrate and seed.
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// build information, set by -ldflags "-X github.com/tingchima/gogolook/cmd.Version=..."
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// newVersionCmd .
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, err := fmt.Fprintln(cmd.OutOrStdout(), versionString())
+			return err
+		},
+	}
+}
+
+// versionString falls back to the vcs information of go build if the ldflags are not set.
+func versionString() string {
+
+	commit, buildTime := Commit, BuildTime
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && buildTime == "":
+				buildTime = setting.Value
+			}
+		}
+	}
+
+	if commit == "" {
+		commit = "unknown"
+	}
+	if buildTime == "" {
+		buildTime = "unknown"
+	}
+
+	return fmt.Sprintf("gogolook %s (commit %s, built %s, %s)", Version, commit, buildTime, runtime.Version())
+}
@@ -2,9 +2,14 @@
 package configs
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,27 +21,210 @@ func init() {
 	base = filepath.Dir(currentFile)
 }
 
+// profiles
+const (
+	ProfileDev  = "app-dev"
+	ProfileProd = "app-prod"
+	ProfileTest = "app-test"
+)
+
+// EnvPrefix is the prefix of environment variables which override the config,
+// e.g. APP_DATABASE_PASSWORD overrides database.password.
+const EnvPrefix = "APP"
+
 type AppConfig struct {
 	Viper *viper.Viper
+
+	sections appSections
+}
+
+// appSections .
+type appSections struct {
+	Server      Server      `mapstructure:"server"`
+	GRPC        GRPC        `mapstructure:"grpc"`
+	GraphQL     GraphQL     `mapstructure:"graphql"`
+	Database    Database    `mapstructure:"database"`
+	Migration   Migration   `mapstructure:"migration"`
+	Log         Log         `mapstructure:"log"`
+	Tracing     Tracing     `mapstructure:"tracing"`
+	Auth        Auth        `mapstructure:"auth"`
+	RateLimit   RateLimit   `mapstructure:"rate_limit"`
+	Idempotency Idempotency `mapstructure:"idempotency"`
+	TaskCache   TaskCache   `mapstructure:"task_cache"`
+	Features    Features    `mapstructure:"features"`
+	SMTP        SMTP        `mapstructure:"smtp"`
+	Webhook     Webhook     `mapstructure:"webhook"`
 }
 
+// NewConfig reads the config file of name, the missing file is only logged.
 func NewConfig(name string) *AppConfig {
 
-	configPaths := []string{base}
+	cfg, err := newConfig(name)
+	if err != nil {
+		slog.Warn("read config file fail", "name", name, "err", err)
+	}
+
+	return cfg
+}
+
+// LoadConfig reads the config file of profile and validates it, the environment
+// variables override the values of file.
+func LoadConfig(profile string) (*AppConfig, error) {
+
+	cfg, err := newConfig(profile)
+	if err != nil {
+		return nil, fmt.Errorf("load config %s: %w", profile, err)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s:\n%w", profile, err)
+	}
+
+	return cfg, nil
+}
+
+// newConfig returns the config with defaults even if the file can not be read.
+func newConfig(name string) (*AppConfig, error) {
+
+	configPaths := []string{base, "./configs", "."}
 
 	v := viper.New()
 
+	setDefaults(v)
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
 	v.SetConfigName(name)   // name of config file (without extension)
 	v.SetConfigType("yaml") // REQUIRED if the config file does not have the extension in the name
 	for i := range configPaths {
 		v.AddConfigPath(configPaths[i])
 	}
-	err := v.ReadInConfig() // Find and read the config file
-	if err != nil {         // Handle errors reading the config file
-		log.Printf("config file: %s", err)
+
+	cfg := &AppConfig{Viper: v}
+
+	readErr := v.ReadInConfig() // Find and read the config file
+
+	// the defaults make every key known by viper, so that all of them can be overridden by env
+	err := v.Unmarshal(&cfg.sections)
+	if err != nil {
+		return cfg, err
 	}
 
-	return &AppConfig{Viper: v}
+	return cfg, readErr
+}
+
+// setDefaults .
+func setDefaults(v *viper.Viper) {
+
+	v.SetDefault("server.port", "8080")
+	v.SetDefault("server.mode", "release")
+	v.SetDefault("server.read_header_timeout", 10*time.Second)
+	v.SetDefault("server.idle_timeout", 2*time.Minute)
+	v.SetDefault("server.shutdown_timeout", 5*time.Second)
+	v.SetDefault("server.drain_delay", 0)
+	v.SetDefault("server.readiness_timeout", 2*time.Second)
+
+	v.SetDefault("grpc.enabled", false)
+	v.SetDefault("grpc.port", "9090")
+	v.SetDefault("grpc.reflection", false)
+
+	v.SetDefault("graphql.enabled", false)
+	v.SetDefault("graphql.complexity_limit", 1000)
+	v.SetDefault("graphql.introspection", false)
+
+	v.SetDefault("database.host", "localhost")
+	v.SetDefault("database.port", "5432")
+	v.SetDefault("database.username", "")
+	v.SetDefault("database.password", "")
+	v.SetDefault("database.db_name", "")
+	v.SetDefault("database.ssl_mode", "disable")
+	v.SetDefault("database.max_open_conns", 20)
+	v.SetDefault("database.max_idle_conns", 10)
+	v.SetDefault("database.conn_max_lifetime", 30*time.Minute)
+	v.SetDefault("database.conn_max_idle_time", 5*time.Minute)
+	v.SetDefault("database.replica_dsns", []string{})
+	v.SetDefault("database.replica_lag", 2*time.Second)
+
+	v.SetDefault("migration.auto", false)
+	v.SetDefault("migration.lock_timeout", time.Minute)
+
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "text")
+
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.service_name", "gogolook")
+	v.SetDefault("tracing.exporter", "stdout")
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", false)
+	v.SetDefault("tracing.file", "")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	v.SetDefault("auth.enabled", false)
+	v.SetDefault("auth.api_keys", []string{})
+
+	v.SetDefault("rate_limit.enabled", false)
+	v.SetDefault("rate_limit.store", "memory")
+	v.SetDefault("rate_limit.rules", []map[string]any{})
+
+	v.SetDefault("idempotency.enabled", false)
+	v.SetDefault("idempotency.store", "memory")
+	v.SetDefault("idempotency.ttl", 24*time.Hour)
+	v.SetDefault("idempotency.lock_timeout", time.Minute)
+
+	v.SetDefault("task_cache.enabled", false)
+	v.SetDefault("task_cache.size", 1024)
+	v.SetDefault("task_cache.ttl", 30*time.Second)
+
+	v.SetDefault("features.caldav", true)
+	v.SetDefault("features.reminder", true)
+	v.SetDefault("features.event_propagation", true)
+	v.SetDefault("features.metrics", true)
+
+	v.SetDefault("smtp.host", "")
+	v.SetDefault("smtp.port", "25")
+	v.SetDefault("smtp.username", "")
+	v.SetDefault("smtp.password", "")
+	v.SetDefault("smtp.from", "noreply@gogolook.local")
+	v.SetDefault("smtp.start_tls", true)
+
+	v.SetDefault("webhook.timeout", 10*time.Second)
+	v.SetDefault("webhook.allowed_hosts", []string{})
+	v.SetDefault("webhook.allow_private_network", false)
+}
+
+// Server .
+type Server struct {
+	Port string `mapstructure:"port"`
+	// gin mode: debug, release, test
+	Mode              string        `mapstructure:"mode"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// 關閉伺服器時等待進行中請求的時間
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// 開始關閉後先回報未就緒, 等待負載平衡器停止導入流量的時間
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	// 就緒檢查各元件的逾時
+	ReadinessTimeout time.Duration `mapstructure:"readiness_timeout"`
+}
+
+// GRPC server of TaskService, it is shut down with the http server.
+type GRPC struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Port    string `mapstructure:"port"`
+	// 註冊 reflection service, 供 grpcurl 等工具列出服務
+	Reflection bool `mapstructure:"reflection"`
+}
+
+// GraphQL endpoint /graphql, it is behind the auth and rate limit of http server.
+type GraphQL struct {
+	Enabled bool `mapstructure:"enabled"`
+	// 查詢複雜度上限, 列表欄位的複雜度會乘上預估筆數
+	ComplexityLimit int `mapstructure:"complexity_limit"`
+	// 開放 introspection, 供 GraphiQL 等工具取得 schema
+	Introspection bool `mapstructure:"introspection"`
 }
 
 // Database .
@@ -46,14 +234,404 @@ type Database struct {
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"db_name"`
+	// disable, require, verify-ca, verify-full
+	SSLMode string `mapstructure:"ssl_mode"`
+
+	// connection pool
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+
+	// 唯讀副本的連線字串, 列表與查詢優先讀取副本, 副本異常時改讀主資料庫
+	ReplicaDSNs []string `mapstructure:"replica_dsns"`
+	// 副本的延遲上限, 呼叫端寫入後此時間內改讀主資料庫, 且任務快取不快取副本的結果, 僅於設定副本時使用
+	ReplicaLag time.Duration `mapstructure:"replica_lag"`
+}
+
+// Migration of the embedded schema migrations at startup.
+type Migration struct {
+	// 啟動時執行 migration, 否則資料庫版本落後時拒絕啟動
+	Auto bool `mapstructure:"auto"`
+	// 等待其他 instance 執行 migration 的時間
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
+}
+
+// Log .
+type Log struct {
+	// debug, info, warn, error
+	Level string `mapstructure:"level"`
+	// text, json
+	Format string `mapstructure:"format"`
+}
+
+// Tracing of OpenTelemetry.
+type Tracing struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	ServiceName string `mapstructure:"service_name"`
+	// otlp, stdout, file
+	Exporter string `mapstructure:"exporter"`
+	// OTLP/HTTP endpoint host:port, e.g. localhost:4318
+	Endpoint string `mapstructure:"endpoint"`
+	// 不使用 TLS 連線 OTLP endpoint
+	Insecure bool `mapstructure:"insecure"`
+	// file exporter 的檔案路徑
+	File string `mapstructure:"file"`
+	// 取樣比例 0 ~ 1, 有上游 traceparent 時依上游決定
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// Auth .
+type Auth struct {
+	// 啟用時所有 API 都需要 API key, 日曆訂閱網址仍以其 token 驗證
+	Enabled bool `mapstructure:"enabled"`
+	// APIKeys are comma separated in the environment variable, e.g. APP_AUTH_API_KEYS=key1,key2
+	APIKeys []string `mapstructure:"api_keys"`
+}
+
+// RateLimit of the token buckets by route and principal.
+type RateLimit struct {
+	Enabled bool `mapstructure:"enabled"`
+	// memory, postgres, postgres is shared by the replicas
+	Store string          `mapstructure:"store"`
+	Rules []RateLimitRule `mapstructure:"rules"`
+}
+
+// RateLimitRule allows Requests per Period on average and Burst at once, the most specific rule
+// of request is used, the route is preferred to the principal.
+type RateLimitRule struct {
+	// 路由, 例如 "POST /task", gRPC 為完整方法名稱例如 "/gogolook.task.v1.TaskService/CreateTask", "*" 為所有路由共用
+	Route string `mapstructure:"route"`
+	// 空值為所有請求者, 或類型 key, ip, 或指定請求者例如 ip:10.0.0.1
+	// 沒有 user 類型, 驗證只有共用的 API key 而沒有使用者帳號, 需分別限制時請發給各使用者不同的 key
+	Principal string        `mapstructure:"principal"`
+	Requests  int           `mapstructure:"requests"`
+	Period    time.Duration `mapstructure:"period"`
+	Burst     int           `mapstructure:"burst"`
+}
+
+// Idempotency of the POST requests with Idempotency-Key header.
+type Idempotency struct {
+	Enabled bool `mapstructure:"enabled"`
+	// memory, postgres, postgres is shared by the replicas
+	Store string `mapstructure:"store"`
+	// 保留回應的時間, 到期後可重新使用此 key
+	TTL time.Duration `mapstructure:"ttl"`
+	// 處理中的 key 於此時間後視為中斷, 應大於請求的處理時間
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
+}
+
+// TaskCache is the in-process cache of task reads, the writes of other instances are
+// only seen after the ttl unless the event propagation is enabled.
+type TaskCache struct {
+	Enabled bool `mapstructure:"enabled"`
+	// 最多快取的查詢數量
+	Size int `mapstructure:"size"`
+	// 快取的有效時間
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// Features .
+type Features struct {
+	// CalDAV 端點
+	CalDAV bool `mapstructure:"caldav"`
+	// 提醒排程
+	Reminder bool `mapstructure:"reminder"`
+	// 以 LISTEN/NOTIFY 在多個 instance 之間傳遞任務事件
+	EventPropagation bool `mapstructure:"event_propagation"`
+	// prometheus /metrics 端點
+	Metrics bool `mapstructure:"metrics"`
+}
+
+// SMTP of the reminder notifier, it is disabled when the host is empty.
+type SMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	StartTLS bool   `mapstructure:"start_tls"`
+}
+
+// Webhook of the reminder notifier, the internal addresses are refused.
+type Webhook struct {
+	Timeout time.Duration `mapstructure:"timeout"`
+	// 允許的 webhook 主機, 例如 hooks.example.com 或 *.example.com, 空白表示不限制
+	AllowedHosts []string `mapstructure:"allowed_hosts"`
+	// 允許 loopback 與內部網路位址, 僅供開發使用
+	AllowPrivateNetwork bool `mapstructure:"allow_private_network"`
+}
+
+func (c *AppConfig) Server() *Server {
+	return &c.sections.Server
+}
+
+func (c *AppConfig) GRPC() *GRPC {
+	return &c.sections.GRPC
+}
+
+func (c *AppConfig) GraphQL() *GraphQL {
+	return &c.sections.GraphQL
 }
 
 func (c *AppConfig) Database() *Database {
-	return &Database{
-		Host:     c.Viper.GetString("database.host"),
-		Port:     c.Viper.GetString("database.port"),
-		Username: c.Viper.GetString("database.username"),
-		Password: c.Viper.GetString("database.password"),
-		DBName:   c.Viper.GetString("database.db_name"),
+	return &c.sections.Database
+}
+
+func (c *AppConfig) Migration() *Migration {
+	return &c.sections.Migration
+}
+
+func (c *AppConfig) Log() *Log {
+	return &c.sections.Log
+}
+
+func (c *AppConfig) Tracing() *Tracing {
+	return &c.sections.Tracing
+}
+
+func (c *AppConfig) Auth() *Auth {
+	return &c.sections.Auth
+}
+
+func (c *AppConfig) RateLimit() *RateLimit {
+	return &c.sections.RateLimit
+}
+
+func (c *AppConfig) Idempotency() *Idempotency {
+	return &c.sections.Idempotency
+}
+
+func (c *AppConfig) TaskCache() *TaskCache {
+	return &c.sections.TaskCache
+}
+
+func (c *AppConfig) Features() *Features {
+	return &c.sections.Features
+}
+
+func (c *AppConfig) SMTP() *SMTP {
+	return &c.sections.SMTP
+}
+
+func (c *AppConfig) Webhook() *Webhook {
+	return &c.sections.Webhook
+}
+
+// Validate reports all invalid values at once.
+func (c *AppConfig) Validate() error {
+
+	var errs []error
+
+	invalid := func(key string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	oneOf := func(key string, value string, options ...string) {
+		for i := range options {
+			if value == options[i] {
+				return
+			}
+		}
+		invalid(key, "should be one of %s, got %q", strings.Join(options, ", "), value)
+	}
+
+	port := func(key string, value string) {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 65535 {
+			invalid(key, "should be a port number between 1 and 65535, got %q", value)
+		}
+	}
+
+	required := func(key string, value string) {
+		if strings.TrimSpace(value) == "" {
+			invalid(key, "is required")
+		}
+	}
+
+	server := c.Server()
+	port("server.port", server.Port)
+	oneOf("server.mode", server.Mode, "debug", "release", "test")
+	if server.ShutdownTimeout <= 0 {
+		invalid("server.shutdown_timeout", "should be positive, got %s", server.ShutdownTimeout)
+	}
+	if server.DrainDelay < 0 {
+		invalid("server.drain_delay", "should not be negative, got %s", server.DrainDelay)
+	}
+	if server.ReadinessTimeout <= 0 {
+		invalid("server.readiness_timeout", "should be positive, got %s", server.ReadinessTimeout)
+	}
+
+	if grpc := c.GRPC(); grpc.Enabled {
+		port("grpc.port", grpc.Port)
+		if grpc.Port == server.Port {
+			invalid("grpc.port", "should be different from server.port %s", server.Port)
+		}
+	}
+
+	if graphql := c.GraphQL(); graphql.Enabled && graphql.ComplexityLimit <= 0 {
+		invalid("graphql.complexity_limit", "should be positive, got %d", graphql.ComplexityLimit)
+	}
+
+	db := c.Database()
+	required("database.host", db.Host)
+	port("database.port", db.Port)
+	required("database.username", db.Username)
+	required("database.db_name", db.DBName)
+	oneOf("database.ssl_mode", db.SSLMode, "disable", "require", "verify-ca", "verify-full")
+	if db.MaxOpenConns < 0 {
+		invalid("database.max_open_conns", "should not be negative, got %d", db.MaxOpenConns)
+	}
+	if db.MaxIdleConns < 0 {
+		invalid("database.max_idle_conns", "should not be negative, got %d", db.MaxIdleConns)
+	}
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		invalid("database.max_idle_conns", "should not be greater than database.max_open_conns %d, got %d", db.MaxOpenConns, db.MaxIdleConns)
+	}
+	if db.ConnMaxLifetime < 0 {
+		invalid("database.conn_max_lifetime", "should not be negative, got %s", db.ConnMaxLifetime)
+	}
+	if db.ConnMaxIdleTime < 0 {
+		invalid("database.conn_max_idle_time", "should not be negative, got %s", db.ConnMaxIdleTime)
+	}
+	for i := range db.ReplicaDSNs {
+		required(fmt.Sprintf("database.replica_dsns[%d]", i), strings.TrimSpace(db.ReplicaDSNs[i]))
+	}
+	if db.ReplicaLag < 0 {
+		invalid("database.replica_lag", "should not be negative, got %s", db.ReplicaLag)
+	}
+
+	if migration := c.Migration(); migration.Auto && migration.LockTimeout <= 0 {
+		invalid("migration.lock_timeout", "should be positive, got %s", migration.LockTimeout)
+	}
+
+	logCfg := c.Log()
+	oneOf("log.level", logCfg.Level, "debug", "info", "warn", "error")
+	oneOf("log.format", logCfg.Format, "text", "json")
+
+	if tracing := c.Tracing(); tracing.Enabled {
+		required("tracing.service_name", tracing.ServiceName)
+		oneOf("tracing.exporter", tracing.Exporter, "otlp", "stdout", "file")
+		if tracing.Exporter == "otlp" {
+			required("tracing.endpoint", tracing.Endpoint)
+		}
+		if tracing.Exporter == "file" {
+			required("tracing.file", tracing.File)
+		}
+		if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+			invalid("tracing.sample_ratio", "should be between 0 and 1, got %g", tracing.SampleRatio)
+		}
+	}
+
+	auth := c.Auth()
+	if auth.Enabled {
+		if len(auth.APIKeys) == 0 {
+			invalid("auth.api_keys", "is required when auth is enabled")
+		}
+		for i := range auth.APIKeys {
+			if len(auth.APIKeys[i]) < 16 {
+				invalid(fmt.Sprintf("auth.api_keys[%d]", i), "should be at least 16 characters")
+			}
+		}
+	}
+
+	if rateLimit := c.RateLimit(); rateLimit.Enabled {
+		oneOf("rate_limit.store", rateLimit.Store, "memory", "postgres")
+		for i, rule := range rateLimit.Rules {
+			key := fmt.Sprintf("rate_limit.rules[%d]", i)
+			required(key+".route", rule.Route)
+			if rule.Requests <= 0 || rule.Period <= 0 || rule.Burst <= 0 {
+				invalid(key, "requests, period and burst should be positive")
+			}
+		}
+	}
+
+	if idempotency := c.Idempotency(); idempotency.Enabled {
+		oneOf("idempotency.store", idempotency.Store, "memory", "postgres")
+		if idempotency.TTL <= 0 {
+			invalid("idempotency.ttl", "should be positive")
+		}
+		if idempotency.LockTimeout <= 0 {
+			invalid("idempotency.lock_timeout", "should be positive")
+		}
+	}
+
+	if taskCache := c.TaskCache(); taskCache.Enabled {
+		if taskCache.Size <= 0 {
+			invalid("task_cache.size", "should be positive, got %d", taskCache.Size)
+		}
+		if taskCache.TTL <= 0 {
+			invalid("task_cache.ttl", "should be positive, got %s", taskCache.TTL)
+		}
+	}
+
+	smtp := c.SMTP()
+	if smtp.Host != "" {
+		port("smtp.port", smtp.Port)
+		required("smtp.from", smtp.From)
+	}
+
+	webhook := c.Webhook()
+	if webhook.Timeout <= 0 {
+		invalid("webhook.timeout", "should be positive, got %s", webhook.Timeout)
+	}
+	for i, host := range webhook.AllowedHosts {
+		if strings.TrimSpace(host) == "" {
+			invalid(fmt.Sprintf("webhook.allowed_hosts[%d]", i), "should not be empty")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RedactedValue replaces the secrets in Redacted.
+const RedactedValue = "[REDACTED]"
+
+// secretKeys .
+var secretKeys = []string{
+	"database.password",
+	"database.replica_dsns",
+	"auth.api_keys",
+	"smtp.password",
+}
+
+// Redacted returns all settings with the secrets replaced, the empty secrets are kept
+// so that the missing ones can still be noticed.
+func (c *AppConfig) Redacted() map[string]any {
+
+	settings := c.Viper.AllSettings()
+
+	for _, key := range secretKeys {
+		path := strings.Split(key, ".")
+
+		m := settings
+		for _, name := range path[:len(path)-1] {
+			m, _ = m[name].(map[string]any)
+		}
+		if m == nil {
+			continue
+		}
+
+		name := path[len(path)-1]
+		if value, ok := m[name]; ok && !isEmptySetting(value) {
+			m[name] = RedactedValue
+		}
+	}
+
+	return settings
+}
+
+// isEmptySetting .
+func isEmptySetting(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
 	}
+	return false
 }
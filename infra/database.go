@@ -2,12 +2,20 @@
 package infra
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/url"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/tingchima/gogolook/configs"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MustNewPostgresConn .
@@ -15,47 +23,110 @@ func MustNewPostgresConn(cfg *configs.Database) *sqlx.DB {
 
 	conn := sqlx.MustOpen("postgres", resolvePostgresDSN(cfg))
 
+	setPostgresPool(conn, cfg)
+
 	err := conn.Ping()
 	if err != nil {
 		log.Panicf("new pg fail, err: %s\n", err.Error())
 	}
 
-	log.Println("new pg successfully")
+	slog.Info("postgres connected", "host", cfg.Host, "port", cfg.Port, "db_name", cfg.DBName)
 	return conn
 }
 
 // NewPostgresConn .
 func NewPostgresConn(cfg *configs.Database) (*sqlx.DB, error) {
 
-	conn, err := sqlx.Open("postgres", resolvePostgresDSN(cfg))
+	conn, err := openPostgres(resolvePostgresDSN(cfg), cfg)
 	if err != nil {
-		log.Printf("new postgres connection fail, err: %s", err.Error())
+		slog.Error("new postgres connection fail", "host", cfg.Host, "port", cfg.Port, "err", err)
 		return nil, err
 	}
 
 	return conn, nil
 }
 
-// resolvePostgresDSN .
-func resolvePostgresDSN(cfg *configs.Database) string {
+// NewPostgresReplicaConns opens the replicas of cfg.ReplicaDSNs with the same pool config as the primary.
+// They are not pinged, a replica which is down is skipped by the repository until it is up.
+func NewPostgresReplicaConns(cfg *configs.Database) ([]*sqlx.DB, error) {
+
+	conns := make([]*sqlx.DB, 0, len(cfg.ReplicaDSNs))
+
+	for i := range cfg.ReplicaDSNs {
+		conn, err := openPostgres(cfg.ReplicaDSNs[i], cfg)
+		if err != nil {
+			for j := range conns {
+				_ = conns[j].Close()
+			}
+			// the dsn is not logged since it contains the password
+			return nil, fmt.Errorf("open postgres replica %d: %w", i, err)
+		}
+
+		conns = append(conns, conn)
+	}
+
+	return conns, nil
+}
 
-	log.Println(fmt.Sprintf(
-		"postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		// "postgresql://%s:%s/%s?sslmode=disable",
-		cfg.Username,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-	))
-
-	return fmt.Sprintf(
-		"postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		// "postgresql://%s:%s/%s?sslmode=disable",
-		cfg.Username,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
+// openPostgres .
+func openPostgres(dsn string, cfg *configs.Database) (*sqlx.DB, error) {
+
+	db, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			// only the queries of traced requests, the background jobs poll too often
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sql queries are traced as the children spans of context
+	conn := sqlx.NewDb(db, "postgres")
+
+	setPostgresPool(conn, cfg)
+
+	return conn, nil
+}
+
+// setPostgresPool applies the connection pool config, the zero value keeps the default of database/sql.
+func setPostgresPool(conn *sqlx.DB, cfg *configs.Database) {
+
+	if cfg.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		conn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		conn.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+// resolvePostgresDSN escapes the credentials, it should never be logged since it contains the password.
+func resolvePostgresDSN(cfg *configs.Database) string {
+
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.DBName,
+		RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+	}
+
+	return dsn.String()
 }
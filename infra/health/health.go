@@ -0,0 +1,177 @@
+// Package health provides the readiness checks of the components, e.g. database and background workers.
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout of every check.
+const DefaultTimeout = 2 * time.Second
+
+// component status
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// ready status
+const (
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// failure reasons, the detailed errors are only logged since the readiness endpoint is public
+const (
+	ReasonTimeout     = "timeout"
+	ReasonUnavailable = "unavailable"
+)
+
+// CheckFunc returns error if the component is not ready, it should respect the deadline of ctx.
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus .
+type ComponentStatus struct {
+	Status   string `json:"status"`          // up, down
+	Error    string `json:"error,omitempty"` // 失敗原因: timeout, unavailable
+	Duration string `json:"duration"`        // 檢查時間
+}
+
+// Report .
+type Report struct {
+	Status     string                     `json:"status"`     // ready, not_ready, shutting_down
+	Components map[string]ComponentStatus `json:"components"` // 各元件狀態
+}
+
+// Ready .
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// namedCheck .
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs the checks concurrently, it is not ready once the shutdown begins.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+
+	shuttingDown atomic.Bool
+}
+
+// NewChecker .
+func NewChecker(timeout time.Duration) *Checker {
+
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Checker{timeout: timeout}
+}
+
+// Add registers the check of component name.
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes the checker not ready, so that the load balancers stop sending the new requests.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs all checks with the timeout.
+func (c *Checker) Check(ctx context.Context) Report {
+
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	report := Report{
+		Status:     StatusReady,
+		Components: make(map[string]ComponentStatus, len(checks)),
+	}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for i := range checks {
+		wg.Add(1)
+
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			status := runCheck(ctx, nc.name, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[nc.name] = status
+			if status.Status != StatusUp {
+				report.Status = StatusNotReady
+			}
+		}(checks[i])
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+// runCheck returns down if the check does not finish before ctx is done,
+// the error is logged and only the reason is returned.
+func runCheck(ctx context.Context, name string, check CheckFunc) ComponentStatus {
+
+	start := time.Now()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := ComponentStatus{
+		Status:   StatusUp,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = ReasonUnavailable
+		if errors.Is(err, context.DeadlineExceeded) {
+			status.Error = ReasonTimeout
+		}
+
+		slog.WarnContext(ctx, "readiness check fail", "component", name, "err", err)
+	}
+
+	return status
+}
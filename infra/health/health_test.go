@@ -0,0 +1,92 @@
+// Package health provides the readiness checks of the components, e.g. database and background workers.
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChecker_Check .
+func TestChecker_Check(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		checks       map[string]CheckFunc
+		shuttingDown bool
+		wantStatus   string
+		wantDown     []string
+		wantReason   string
+	}{
+		{
+			name: "ready",
+			checks: map[string]CheckFunc{
+				"database": func(ctx context.Context) error { return nil },
+				"worker":   func(ctx context.Context) error { return nil },
+			},
+			wantStatus: StatusReady,
+		},
+		{
+			name: "component down",
+			checks: map[string]CheckFunc{
+				"database": func(ctx context.Context) error { return errors.New("mock db server error") },
+				"worker":   func(ctx context.Context) error { return nil },
+			},
+			wantStatus: StatusNotReady,
+			wantDown:   []string{"database"},
+			wantReason: ReasonUnavailable,
+		},
+		{
+			name: "timeout",
+			checks: map[string]CheckFunc{
+				// ignores the deadline of ctx
+				"database": func(ctx context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				},
+			},
+			wantStatus: StatusNotReady,
+			wantDown:   []string{"database"},
+			wantReason: ReasonTimeout,
+		},
+		{
+			name: "shutting down",
+			checks: map[string]CheckFunc{
+				"database": func(ctx context.Context) error { return nil },
+			},
+			shuttingDown: true,
+			wantStatus:   StatusShuttingDown,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewChecker(50 * time.Millisecond)
+			for name, check := range tt.checks {
+				c.Add(name, check)
+			}
+
+			if tt.shuttingDown {
+				c.SetShuttingDown()
+			}
+
+			report := c.Check(context.Background())
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Equal(t, tt.wantStatus == StatusReady, report.Ready())
+
+			for _, name := range tt.wantDown {
+				assert.Equal(t, StatusDown, report.Components[name].Status, name)
+				// the detailed error is not exposed
+				assert.Equal(t, tt.wantReason, report.Components[name].Error, name)
+			}
+		})
+	}
+}
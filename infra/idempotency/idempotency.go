@@ -0,0 +1,49 @@
+// Package idempotency provides the stores of idempotency keys, the response of the first request
+// is kept so that the retries get the same response.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInFlight is returned when the request of key is still being processed.
+	ErrInFlight = errors.New("a request with the same idempotency key is in progress")
+	// ErrFingerprintMismatch is returned when the key is reused for a different request.
+	ErrFingerprintMismatch = errors.New("the idempotency key has been used for a different request")
+	// ErrLockLost is returned by Complete when the lock has expired and the key has been locked again.
+	ErrLockLost = errors.New("the lock of idempotency key has been lost")
+)
+
+// Response of the first request.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Store .
+type Store interface {
+	// Begin locks the key for the request of fingerprint until lockTimeout, it returns
+	// the stored response if the request has been completed, or ErrInFlight and
+	// ErrFingerprintMismatch. If the key is locked, the response is nil and the lock token
+	// of this acquisition is returned.
+	Begin(ctx context.Context, key string, fingerprint string, lockTimeout time.Duration) (resp *Response, lockToken string, err error)
+	// Complete stores the response of key until ttl if the key is still locked by lockToken,
+	// otherwise ErrLockLost is returned.
+	Complete(ctx context.Context, key string, lockToken string, resp Response, ttl time.Duration) error
+	// Release unlocks the key without response if it is still locked by lockToken,
+	// so that the request can be retried.
+	Release(ctx context.Context, key string, lockToken string) error
+}
+
+// newLockToken .
+func newLockToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+// Package idempotency provides the stores of idempotency keys, the response of the first request
+// is kept so that the retries get the same response.
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryStore .
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2024, 2, 5, 9, 0, 0, 0, time.UTC)
+
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	// the first request locks the key
+	got, token, err := s.Begin(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.NotEmpty(t, token)
+
+	// the concurrent retry
+	_, _, err = s.Begin(ctx, "k", "fp", time.Minute)
+	assert.ErrorIs(t, err, ErrInFlight)
+
+	// the key is reused for a different request
+	_, _, err = s.Begin(ctx, "k", "other", time.Minute)
+	assert.ErrorIs(t, err, ErrFingerprintMismatch)
+
+	resp := Response{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"result":{"id":1}}`)}
+	require.NoError(t, s.Complete(ctx, "k", token, resp, time.Hour))
+
+	// the retry gets the stored response, it is not released
+	got, _, err = s.Begin(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, &resp, got)
+
+	require.NoError(t, s.Release(ctx, "k", token))
+
+	got, _, err = s.Begin(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, &resp, got)
+
+	// the key can be used again after ttl
+	now = now.Add(time.Hour)
+
+	got, token, err = s.Begin(ctx, "k", "other", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// the released key can be retried
+	require.NoError(t, s.Release(ctx, "k", token))
+
+	got, token, err = s.Begin(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// the lock of interrupted request expires
+	now = now.Add(time.Minute)
+
+	got, retryToken, err := s.Begin(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.NotEqual(t, token, retryToken)
+
+	// the interrupted request can neither release nor complete the key of retry
+	require.NoError(t, s.Release(ctx, "k", token))
+
+	_, _, err = s.Begin(ctx, "k", "fp", time.Minute)
+	assert.ErrorIs(t, err, ErrInFlight)
+
+	err = s.Complete(ctx, "k", token, Response{StatusCode: 500}, time.Hour)
+	assert.ErrorIs(t, err, ErrLockLost)
+
+	require.NoError(t, s.Complete(ctx, "k", retryToken, resp, time.Hour))
+
+	got, _, err = s.Begin(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, &resp, got)
+
+	// the expired keys are swept
+	now = now.Add(time.Hour)
+
+	_, _, err = s.Begin(ctx, "other", "fp", time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+
+	_, _, err = s.Begin(ctx, "new", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, s.records, 1)
+}
@@ -0,0 +1,112 @@
+// Package idempotency provides the stores of idempotency keys, the response of the first request
+// is kept so that the retries get the same response.
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval .
+const memorySweepInterval = time.Minute
+
+// record .
+type record struct {
+	fingerprint string
+	lockToken   string
+	response    *Response
+	expiresAt   time.Time
+}
+
+// MemoryStore keeps the keys of the current instance.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	records   map[string]*record
+	lastSweep time.Time
+}
+
+// NewMemoryStore .
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		records: make(map[string]*record),
+	}
+}
+
+// Begin .
+func (s *MemoryStore) Begin(_ context.Context, key string, fingerprint string, lockTimeout time.Duration) (*Response, string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.sweep(now)
+
+	r, ok := s.records[key]
+	if !ok || !now.Before(r.expiresAt) {
+		lockToken := newLockToken()
+		s.records[key] = &record{fingerprint: fingerprint, lockToken: lockToken, expiresAt: now.Add(lockTimeout)}
+		return nil, lockToken, nil
+	}
+
+	if r.fingerprint != fingerprint {
+		return nil, "", ErrFingerprintMismatch
+	}
+
+	if r.response == nil {
+		return nil, "", ErrInFlight
+	}
+
+	resp := *r.response
+
+	return &resp, "", nil
+}
+
+// Complete .
+func (s *MemoryStore) Complete(_ context.Context, key string, lockToken string, resp Response, ttl time.Duration) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok || r.lockToken != lockToken || r.response != nil {
+		return ErrLockLost
+	}
+
+	r.response = &resp
+	r.expiresAt = s.now().Add(ttl)
+
+	return nil
+}
+
+// Release .
+func (s *MemoryStore) Release(_ context.Context, key string, lockToken string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.records[key]; ok && r.lockToken == lockToken && r.response == nil {
+		delete(s.records, key)
+	}
+
+	return nil
+}
+
+// sweep .
+func (s *MemoryStore) sweep(now time.Time) {
+
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, r := range s.records {
+		if !now.Before(r.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package idempotency provides the stores of idempotency keys, the response of the first request
+// is kept so that the retries get the same response.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// postgresPurgeInterval .
+const postgresPurgeInterval = 10 * time.Minute
+
+// beginQuery locks the key if it is missing or expired, no row is returned if the key is held.
+const beginQuery = `
+INSERT INTO idempotency_keys AS k (key, fingerprint, lock_token, completed, expires_at)
+VALUES ($1, $2, $3, FALSE, now() + make_interval(secs => $4))
+ON CONFLICT (key) DO UPDATE SET
+	fingerprint = EXCLUDED.fingerprint,
+	lock_token = EXCLUDED.lock_token,
+	completed = FALSE,
+	response_status = NULL,
+	response_content_type = NULL,
+	response_body = NULL,
+	created_at = now(),
+	expires_at = EXCLUDED.expires_at
+WHERE k.expires_at <= now()
+RETURNING key`
+
+// repoIdempotencyKey .
+type repoIdempotencyKey struct {
+	Fingerprint         string         `db:"fingerprint"`
+	Completed           bool           `db:"completed"`
+	ResponseStatus      sql.NullInt32  `db:"response_status"`
+	ResponseContentType sql.NullString `db:"response_content_type"`
+	ResponseBody        []byte         `db:"response_body"`
+}
+
+// PostgresStore keeps the keys in the idempotency_keys table, they are shared by the replicas.
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresStore .
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Begin .
+func (s *PostgresStore) Begin(ctx context.Context, key string, fingerprint string, lockTimeout time.Duration) (*Response, string, error) {
+
+	var locked string
+
+	lockToken := newLockToken()
+
+	err := s.db.GetContext(ctx, &locked, beginQuery, key, fingerprint, lockToken, lockTimeout.Seconds())
+	if err == nil {
+		return nil, lockToken, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, "", err
+	}
+
+	var row repoIdempotencyKey
+
+	err = s.db.GetContext(ctx, &row, `
+SELECT fingerprint, completed, response_status, response_content_type, response_body
+FROM idempotency_keys WHERE key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// purged or released meanwhile
+		return s.Begin(ctx, key, fingerprint, lockTimeout)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if row.Fingerprint != fingerprint {
+		return nil, "", ErrFingerprintMismatch
+	}
+
+	if !row.Completed {
+		return nil, "", ErrInFlight
+	}
+
+	return &Response{
+		StatusCode:  int(row.ResponseStatus.Int32),
+		ContentType: row.ResponseContentType.String,
+		Body:        row.ResponseBody,
+	}, "", nil
+}
+
+// Complete .
+func (s *PostgresStore) Complete(ctx context.Context, key string, lockToken string, resp Response, ttl time.Duration) error {
+
+	result, err := s.db.ExecContext(ctx, `
+UPDATE idempotency_keys SET
+	completed = TRUE,
+	response_status = $3,
+	response_content_type = $4,
+	response_body = $5,
+	expires_at = now() + make_interval(secs => $6)
+WHERE key = $1 AND lock_token = $2 AND NOT completed`, key, lockToken, resp.StatusCode, resp.ContentType, resp.Body, ttl.Seconds())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+// Release .
+func (s *PostgresStore) Release(ctx context.Context, key string, lockToken string) error {
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1 AND lock_token = $2 AND NOT completed", key, lockToken)
+
+	return err
+}
+
+// Purge deletes the expired keys.
+func (s *PostgresStore) Purge(ctx context.Context) (int64, error) {
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= now()")
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Run purges the expired keys periodically until ctx is done.
+func (s *PostgresStore) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(postgresPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := s.Purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "purge idempotency keys fail", "err", err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package metrics provides the prometheus metrics of http requests, errors, database and tasks.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// Namespace of the metrics.
+const Namespace = "gogolook"
+
+// UnmatchedRoute labels the requests which match no route, so that the paths do not explode the series.
+const UnmatchedRoute = "unmatched"
+
+// taskCountTimeout bounds the query of task gauges while scraping.
+const taskCountTimeout = 3 * time.Second
+
+// TaskCounter .
+type TaskCounter interface {
+	// 統計未完成與已完成的任務數量
+	CountTasks(ctx context.Context) (domain.TaskCount, error)
+}
+
+// Metrics .
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration *prometheus.HistogramVec
+	httpErrors          *prometheus.CounterVec
+	queryDuration       *prometheus.HistogramVec
+}
+
+// New registers the metrics and the go runtime and process collectors to a new registry.
+func New() *Metrics {
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of http requests by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		httpErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "errors_total",
+			Help:      "Number of error responses by error code name.",
+		}, []string{"name", "status"}),
+
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "repository",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of repository methods.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"repository", "method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestDuration,
+		m.httpErrors,
+		m.queryDuration,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the prometheus text exposition format, the failed collectors
+// are skipped rather than failing the whole scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// Registry .
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// ObserveHTTPRequest .
+func (m *Metrics) ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+
+	if route == "" {
+		route = UnmatchedRoute
+	}
+
+	m.httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// IncError counts the error response by the name of ErrCode.
+func (m *Metrics) IncError(name string, status int) {
+	m.httpErrors.WithLabelValues(name, strconv.Itoa(status)).Inc()
+}
+
+// QueryObserver returns the observer of repository methods.
+func (m *Metrics) QueryObserver(repository string) func(method string, duration time.Duration) {
+	return func(method string, duration time.Duration) {
+		m.queryDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+	}
+}
+
+// RegisterDBStats exposes the connection pool stats of db, e.g. open and in-use connections.
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterTaskCounter exposes the number of open and completed tasks, they are counted on every scrape.
+func (m *Metrics) RegisterTaskCounter(counter TaskCounter) error {
+	return m.registry.Register(&taskCollector{
+		counter: counter,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "tasks"),
+			"Number of tasks by status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+// taskCollector .
+type taskCollector struct {
+	counter TaskCounter
+	desc    *prometheus.Desc
+}
+
+// Describe .
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect .
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), taskCountTimeout)
+	defer cancel()
+
+	count, err := c.counter.CountTasks(ctx)
+	if err != nil {
+		slog.Warn("count tasks for metrics fail", "err", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count.Open), "open")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count.Completed), "completed")
+}
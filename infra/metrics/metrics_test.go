@@ -0,0 +1,81 @@
+// Package metrics provides the prometheus metrics of http requests, errors, database and tasks.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// taskCounterFunc .
+type taskCounterFunc func(ctx context.Context) (domain.TaskCount, error)
+
+// CountTasks .
+func (f taskCounterFunc) CountTasks(ctx context.Context) (domain.TaskCount, error) {
+	return f(ctx)
+}
+
+// scrape .
+func scrape(t *testing.T, m *Metrics) string {
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	return rec.Body.String()
+}
+
+// TestMetrics .
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+
+	m.ObserveHTTPRequest(http.MethodGet, "/task/:id", http.StatusOK, 20*time.Millisecond)
+	m.ObserveHTTPRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+	m.IncError("RESOURCE_NOT_FOUND", http.StatusNotFound)
+	m.QueryObserver("postgres")("GetTaskByID", 5*time.Millisecond)
+
+	err := m.RegisterTaskCounter(taskCounterFunc(func(ctx context.Context) (domain.TaskCount, error) {
+		return domain.TaskCount{Open: 3, Completed: 5}, nil
+	}))
+	require.NoError(t, err)
+
+	body := scrape(t, m)
+
+	for _, s := range []string{
+		`gogolook_http_request_duration_seconds_count{method="GET",route="/task/:id",status="200"} 1`,
+		`gogolook_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		`gogolook_http_errors_total{name="RESOURCE_NOT_FOUND",status="404"} 1`,
+		`gogolook_repository_query_duration_seconds_count{method="GetTaskByID",repository="postgres"} 1`,
+		`gogolook_tasks{status="open"} 3`,
+		`gogolook_tasks{status="completed"} 5`,
+		"go_goroutines",
+	} {
+		assert.Contains(t, body, s)
+	}
+}
+
+// TestMetrics_TaskCounterError .
+func TestMetrics_TaskCounterError(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+
+	err := m.RegisterTaskCounter(taskCounterFunc(func(ctx context.Context) (domain.TaskCount, error) {
+		return domain.TaskCount{}, errors.New("mock db server error")
+	}))
+	require.NoError(t, err)
+
+	// the other metrics are still served
+	body := scrape(t, m)
+	assert.NotContains(t, body, "gogolook_tasks{")
+	assert.Contains(t, body, "go_goroutines")
+}
@@ -0,0 +1,215 @@
+// Package infra provides
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+	"github.com/tingchima/gogolook/configs"
+	"github.com/tingchima/gogolook/migrations"
+)
+
+// ErrMigrationNoChange .
+var ErrMigrationNoChange = migrate.ErrNoChange
+
+// ErrSchemaBehind is returned by CheckSchema when the database misses some migrations of the binary.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// ErrSchemaDirty is returned by CheckSchema when the last migration failed.
+var ErrSchemaDirty = errors.New("database schema is dirty")
+
+// MigrationStatus .
+type MigrationStatus struct {
+	// 目前版本, 0 為尚未執行任何 migration
+	Version uint
+	// 上次 migration 失敗, 需要 force 修正版本
+	Dirty bool
+	// 最新可用版本
+	Latest uint
+	// 尚未執行的 migration 數量
+	Pending int
+}
+
+// Migrator runs the schema migrations, golang-migrate holds an advisory lock of postgres
+// while migrating, so that only one instance migrates at a time.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// NewMigrator opens the migrations of sourceURL, e.g. file://migrations.
+func NewMigrator(cfg *configs.Database, sourceURL string) (*Migrator, error) {
+
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("open migration source %s: %w", sourceURL, err)
+	}
+
+	return newMigrator(cfg, src)
+}
+
+// NewEmbeddedMigrator opens the migrations embedded in the binary.
+func NewEmbeddedMigrator(cfg *configs.Database) (*Migrator, error) {
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("open embedded migrations: %w", err)
+	}
+
+	return newMigrator(cfg, src)
+}
+
+// newMigrator .
+func newMigrator(cfg *configs.Database, src source.Driver) (*Migrator, error) {
+
+	m, err := migrate.NewWithSourceInstance("source", src, resolvePostgresDSN(cfg))
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("new migrate: %w", err)
+	}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+// SetLockTimeout is the max time to wait for the advisory lock, the other instance may be migrating.
+func (mg *Migrator) SetLockTimeout(timeout time.Duration) {
+	mg.m.LockTimeout = timeout
+}
+
+// Up applies n migrations, or all of the pending migrations if n <= 0.
+func (mg *Migrator) Up(n int) error {
+	if n <= 0 {
+		return mg.m.Up()
+	}
+	return mg.m.Steps(n)
+}
+
+// Down rolls back n migrations, or all of the migrations if n <= 0.
+func (mg *Migrator) Down(n int) error {
+	if n <= 0 {
+		return mg.m.Down()
+	}
+	return mg.m.Steps(-n)
+}
+
+// Force sets the version without running migrations and clears the dirty state,
+// version -1 means no migration has been applied.
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Status .
+func (mg *Migrator) Status() (MigrationStatus, error) {
+
+	var status MigrationStatus
+
+	version, dirty, err := mg.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	v, err := mg.source.First()
+	for err == nil {
+		status.Latest = v
+		if v > status.Version {
+			status.Pending++
+		}
+		v, err = mg.source.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// CheckSchema returns ErrSchemaBehind if there are pending migrations, the newer schema is allowed
+// so that the previous version can still run during a rolling update.
+func (mg *Migrator) CheckSchema() (MigrationStatus, error) {
+
+	status, err := mg.Status()
+	if err != nil {
+		return status, err
+	}
+
+	if status.Dirty {
+		return status, fmt.Errorf("%w: version %d, fix the schema and run migrate force", ErrSchemaDirty, status.Version)
+	}
+
+	if status.Pending > 0 {
+		return status, fmt.Errorf("%w: version %d, latest %d, %d pending migrations", ErrSchemaBehind, status.Version, status.Latest, status.Pending)
+	}
+
+	return status, nil
+}
+
+// EmbeddedLatestVersion returns the latest version of the embedded migrations.
+func EmbeddedLatestVersion() (uint, error) {
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("open embedded migrations: %w", err)
+	}
+	defer src.Close()
+
+	var latest uint
+
+	v, err := src.First()
+	for err == nil {
+		latest = v
+		v, err = src.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	return latest, nil
+}
+
+// migrationsTable of golang-migrate, it has only one row of the current version.
+const migrationsTable = "schema_migrations"
+
+// CheckSchemaVersion is the lighter CheckSchema by the connection pool, for the readiness probe.
+func CheckSchemaVersion(ctx context.Context, db *sqlx.DB, latest uint) error {
+
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+
+	err := db.GetContext(ctx, &row, "SELECT version, dirty FROM "+migrationsTable+" LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: no migration, latest %d", ErrSchemaBehind, latest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if row.Dirty {
+		return fmt.Errorf("%w: version %d", ErrSchemaDirty, row.Version)
+	}
+
+	if row.Version < int64(latest) {
+		return fmt.Errorf("%w: version %d, latest %d", ErrSchemaBehind, row.Version, latest)
+	}
+
+	return nil
+}
+
+// Close .
+func (mg *Migrator) Close() error {
+	sourceErr, dbErr := mg.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
@@ -0,0 +1,43 @@
+// Package notifier provides
+package notifier
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// LogNotifier writes the reminder to the default logger, it is useful for local development.
+type LogNotifier struct{}
+
+// NewLogNotifier .
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify .
+func (n *LogNotifier) Notify(ctx context.Context, reminder domain.DueReminder) error {
+	slog.InfoContext(ctx, "reminder",
+		"reminder_id", reminder.Reminder.ID,
+		"task_id", reminder.Task.ID,
+		"task_name", reminder.Task.Name,
+		"due_at", formatDueAt(reminder.Task),
+	)
+	return nil
+}
+
+// formatDueAt formats the due time in the time zone of task.
+func formatDueAt(task domain.Task) string {
+	if !task.DueAt.Valid {
+		return "-"
+	}
+
+	dueAt := task.DueAt.Time
+	if loc, err := time.LoadLocation(task.Timezone); err == nil && task.Timezone != "" {
+		dueAt = dueAt.In(loc)
+	}
+
+	return dueAt.Format(time.RFC3339)
+}
@@ -0,0 +1,132 @@
+// Package notifier provides
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig .
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	// 寄件者
+	From string
+	// 伺服器支援時是否使用 STARTTLS
+	StartTLS bool
+}
+
+// SMTPNotifier sends the reminder by email to the recipient.
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier .
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+// Notify .
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder domain.DueReminder) error {
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultSMTPTimeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(n.cfg.Host, n.cfg.Port)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if n.cfg.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			err = client.StartTLS(&tls.Config{ServerName: n.cfg.Host})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if n.cfg.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(n.cfg.From); err != nil {
+		return err
+	}
+
+	recipient, err := mail.ParseAddress(reminder.Reminder.Recipient)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrReminderRecipientInvalid, err)
+	}
+
+	if err = client.Rcpt(recipient.Address); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrReminderRejected, err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(n.message(reminder)); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// message builds the RFC 5322 message of reminder.
+func (n *SMTPNotifier) message(reminder domain.DueReminder) []byte {
+
+	subject := fmt.Sprintf("提醒: %s", reminder.Task.Name)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", reminder.Reminder.Recipient)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&b, "\r\n")
+	fmt.Fprintf(&b, "任務: %s\r\n", reminder.Task.Name)
+	fmt.Fprintf(&b, "到期時間: %s\r\n", formatDueAt(reminder.Task))
+
+	return b.Bytes()
+}
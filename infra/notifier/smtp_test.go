@@ -0,0 +1,194 @@
+// Package notifier provides
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// fakeSMTPMail is the mail received by fakeSMTPServer.
+type fakeSMTPMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer speaks just enough SMTP to accept one mail per connection.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mails    chan fakeSMTPMail
+}
+
+// newFakeSMTPServer .
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{
+		listener: listener,
+		mails:    make(chan fakeSMTPMail, 1),
+	}
+
+	go s.serve()
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	return s
+}
+
+// addr .
+func (s *fakeSMTPServer) addr() (host, port string) {
+	host, port, _ = net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+// serve .
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle .
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	var mail fakeSMTPMail
+
+	reply("220 localhost fake smtp")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			mail.From = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			mail.To = append(mail.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+
+		case cmd == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			mail.Data = data.String()
+
+			reply("250 OK")
+			s.mails <- mail
+
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// TestSMTPNotifier_Notify .
+func TestSMTPNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeSMTPServer(t)
+
+	host, port := server.addr()
+
+	n := NewSMTPNotifier(SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "noreply@gogolook.test",
+	})
+
+	reminder := domain.DueReminder{
+		Reminder: domain.Reminder{
+			ID:        1,
+			TaskID:    2,
+			Channel:   domain.ReminderChannelSMTP,
+			Recipient: "User <user@gogolook.test>",
+		},
+		Task: domain.Task{
+			ID:       2,
+			Name:     "繳房租",
+			DueAt:    null.TimeFrom(time.Date(2024, 2, 1, 1, 0, 0, 0, time.UTC)),
+			Timezone: "Asia/Taipei",
+		},
+		FireAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := n.Notify(ctx, reminder)
+	require.NoError(t, err)
+
+	select {
+	case mail := <-server.mails:
+		assert.Equal(t, "noreply@gogolook.test", mail.From)
+		assert.Equal(t, []string{"user@gogolook.test"}, mail.To)
+		assert.Contains(t, mail.Data, "To: User <user@gogolook.test>\r\n")
+		assert.Contains(t, mail.Data, "Subject: =?UTF-8?q?")
+		assert.Contains(t, mail.Data, "任務: 繳房租\r\n")
+		assert.Contains(t, mail.Data, "到期時間: 2024-02-01T09:00:00+08:00\r\n")
+	case <-ctx.Done():
+		t.Fatal("fake smtp server did not receive the mail")
+	}
+}
+
+// TestSMTPNotifier_Notify_ServerUnavailable .
+func TestSMTPNotifier_Notify_ServerUnavailable(t *testing.T) {
+	t.Parallel()
+
+	// reserve a port and close it, so that nothing is listening on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	_ = listener.Close()
+
+	n := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "noreply@gogolook.test"})
+
+	err = n.Notify(context.Background(), domain.DueReminder{
+		Reminder: domain.Reminder{Recipient: "user@gogolook.test"},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,236 @@
+// Package notifier provides
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+const defaultWebhookTimeout = 10 * time.Second
+
+var (
+	// ErrWebhookForbiddenAddress is returned when the webhook resolves to an internal address.
+	ErrWebhookForbiddenAddress = fmt.Errorf("%w: the webhook address is internal", domain.ErrReminderRecipientNotAllowed)
+
+	// ErrWebhookHostNotAllowed is returned when the webhook host is not in the allowed hosts.
+	ErrWebhookHostNotAllowed = fmt.Errorf("%w: the webhook host is not in the allowed hosts", domain.ErrReminderRecipientNotAllowed)
+)
+
+// forbiddenPrefixes are the internal or reserved addresses besides the loopback,
+// private and link-local ones of net.IP.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// WebhookConfig .
+type WebhookConfig struct {
+	Timeout time.Duration
+	// AllowedHosts restricts the webhook hosts, e.g. hooks.example.com or *.example.com,
+	// any host is allowed if it is empty.
+	AllowedHosts []string
+	// AllowPrivateNetwork allows the loopback, private and link-local addresses, only for development.
+	AllowPrivateNetwork bool
+}
+
+// WebhookNotifier POSTs the reminder as JSON to the recipient url.
+//
+// The recipient is given by the clients, so the connections to the internal addresses
+// are refused after the DNS resolution, and the redirects are not followed.
+type WebhookNotifier struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookNotifier uses the default timeout if cfg.Timeout is zero.
+func NewWebhookNotifier(cfg WebhookConfig) *WebhookNotifier {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultWebhookTimeout
+	}
+
+	n := &WebhookNotifier{cfg: cfg}
+
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		Control: n.control,
+	}
+
+	n.client = &http.Client{
+		Timeout: cfg.Timeout,
+		// the proxy of environment would be dialed instead of the recipient
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.Timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// the redirect location is not validated, the 3xx response is regarded as failure
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return n
+}
+
+// ValidateRecipient checks the url and the allowed hosts when the reminder is created,
+// the resolved addresses are checked again on every delivery.
+func (n *WebhookNotifier) ValidateRecipient(recipient string) error {
+
+	u, err := url.Parse(recipient)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: the recipient of webhook reminder should be a http(s) url", domain.ErrReminderRecipientInvalid)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+
+	if !n.allowedHost(host) {
+		return ErrWebhookHostNotAllowed
+	}
+
+	if n.cfg.AllowPrivateNetwork {
+		return nil
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookForbiddenAddress
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil && forbiddenAddr(addr) {
+		return ErrWebhookForbiddenAddress
+	}
+
+	return nil
+}
+
+// allowedHost .
+func (n *WebhookNotifier) allowedHost(host string) bool {
+	if len(n.cfg.AllowedHosts) == 0 {
+		return true
+	}
+
+	for _, allowed := range n.cfg.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+
+		if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// control checks the resolved address right before connecting.
+func (n *WebhookNotifier) control(_, address string, _ syscall.RawConn) error {
+	if n.cfg.AllowPrivateNetwork {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookForbiddenAddress, address)
+	}
+
+	if forbiddenAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrWebhookForbiddenAddress, address)
+	}
+
+	return nil
+}
+
+// forbiddenAddr .
+func forbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webhookPayload .
+type webhookPayload struct {
+	ReminderID int64     `json:"reminder_id"`
+	FireAt     time.Time `json:"fire_at"`
+	Task       struct {
+		ID     int64     `json:"id"`
+		Name   string    `json:"name"`
+		Status bool      `json:"status"`
+		DueAt  null.Time `json:"due_at"`
+	} `json:"task"`
+}
+
+// Notify .
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder domain.DueReminder) error {
+
+	// the allowed hosts may have been changed after the reminder is created
+	if err := n.ValidateRecipient(reminder.Reminder.Recipient); err != nil {
+		return err
+	}
+
+	payload := webhookPayload{
+		ReminderID: reminder.Reminder.ID,
+		FireAt:     reminder.FireAt,
+	}
+	payload.Task.ID = reminder.Task.ID
+	payload.Task.Name = reminder.Task.Name
+	payload.Task.Status = reminder.Task.Status
+	payload.Task.DueAt = reminder.Task.DueAt
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reminder.Reminder.Recipient, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: webhook responds with status %d", domain.ErrReminderRejected, resp.StatusCode)
+	}
+
+	return nil
+}
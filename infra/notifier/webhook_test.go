@@ -0,0 +1,179 @@
+// Package notifier provides
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// webhookReminder .
+func webhookReminder(recipient string) domain.DueReminder {
+	return domain.DueReminder{
+		Reminder: domain.Reminder{ID: 1, Channel: domain.ReminderChannelWebhook, Recipient: recipient},
+		Task:     domain.Task{ID: 2, Name: "繳房租"},
+		FireAt:   time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// TestWebhookNotifier_Notify .
+func TestWebhookNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan webhookPayload, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(WebhookConfig{AllowPrivateNetwork: true})
+
+	err := n.Notify(context.Background(), webhookReminder(server.URL))
+	require.NoError(t, err)
+
+	payload := <-received
+	assert.Equal(t, int64(1), payload.ReminderID)
+	assert.Equal(t, int64(2), payload.Task.ID)
+}
+
+// TestWebhookNotifier_ForbiddenAddress .
+func TestWebhookNotifier_ForbiddenAddress(t *testing.T) {
+	t.Parallel()
+
+	var called atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(WebhookConfig{})
+
+	// the literal address is refused before connecting
+	err := n.Notify(context.Background(), webhookReminder(server.URL))
+	assert.True(t, errors.Is(err, ErrWebhookForbiddenAddress), err)
+
+	// the address resolved by DNS is refused when connecting
+	resolvedURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	resp, err := n.client.Post(resolvedURL, "application/json", nil)
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	assert.True(t, errors.Is(err, ErrWebhookForbiddenAddress), err)
+
+	assert.False(t, called.Load())
+}
+
+// TestWebhookNotifier_Redirect .
+func TestWebhookNotifier_Redirect(t *testing.T) {
+	t.Parallel()
+
+	var called atomic.Bool
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(WebhookConfig{AllowPrivateNetwork: true})
+
+	err := n.Notify(context.Background(), webhookReminder(server.URL))
+	assert.EqualError(t, err, "the recipient rejected the reminder: webhook responds with status 307")
+
+	assert.False(t, called.Load())
+}
+
+// TestWebhookNotifier_ValidateRecipient .
+func TestWebhookNotifier_ValidateRecipient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		cfg       WebhookConfig
+		recipient string
+		expected  error
+	}{
+		{
+			name:      "public host",
+			recipient: "https://hooks.example.com/reminder",
+		},
+		{
+			name:      "loopback address",
+			recipient: "http://127.0.0.1:8080/hook",
+			expected:  ErrWebhookForbiddenAddress,
+		},
+		{
+			name:      "ipv4 mapped loopback address",
+			recipient: "http://[::ffff:127.0.0.1]/hook",
+			expected:  ErrWebhookForbiddenAddress,
+		},
+		{
+			name:      "link local metadata address",
+			recipient: "http://169.254.169.254/latest/meta-data",
+			expected:  ErrWebhookForbiddenAddress,
+		},
+		{
+			name:      "private address",
+			recipient: "http://10.0.0.1/hook",
+			expected:  ErrWebhookForbiddenAddress,
+		},
+		{
+			name:      "localhost",
+			recipient: "http://LOCALHOST./hook",
+			expected:  ErrWebhookForbiddenAddress,
+		},
+		{
+			name:      "private address allowed",
+			cfg:       WebhookConfig{AllowPrivateNetwork: true},
+			recipient: "http://10.0.0.1/hook",
+		},
+		{
+			name:      "allowed host",
+			cfg:       WebhookConfig{AllowedHosts: []string{"hooks.example.com"}},
+			recipient: "https://hooks.example.com/reminder",
+		},
+		{
+			name:      "allowed wildcard host",
+			cfg:       WebhookConfig{AllowedHosts: []string{"*.example.com"}},
+			recipient: "https://a.hooks.example.com/reminder",
+		},
+		{
+			name:      "host not allowed",
+			cfg:       WebhookConfig{AllowedHosts: []string{"*.example.com"}},
+			recipient: "https://example.com.evil.io/reminder",
+			expected:  ErrWebhookHostNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewWebhookNotifier(tt.cfg).ValidateRecipient(tt.recipient)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.expected), err)
+			}
+		})
+	}
+
+	err := NewWebhookNotifier(WebhookConfig{}).ValidateRecipient("ftp://example.com")
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+// Package infra provides
+package infra
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tingchima/gogolook/configs"
+)
+
+const (
+	listenerMinReconnectInterval = time.Second
+	listenerMaxReconnectInterval = time.Minute
+
+	// listenerPingInterval detects the dead connection which has not been noticed by TCP.
+	listenerPingInterval = 90 * time.Second
+)
+
+// PostgresNotifier issues NOTIFY through the connection pool.
+type PostgresNotifier struct {
+	db *sqlx.DB
+}
+
+// NewPostgresNotifier .
+func NewPostgresNotifier(db *sqlx.DB) *PostgresNotifier {
+	return &PostgresNotifier{db: db}
+}
+
+// Notify sends the payload to the listeners of channel on every instance,
+// including the current one. The payload should be a JSON object less than 8000 bytes,
+// its "id" is set to the next value of notification_id_seq.
+//
+// The notifications of one channel are serialized by an advisory lock held until commit,
+// so that the ids are in the order of delivery on every instance. Without the lock, a transaction
+// taking a smaller id may commit later, and its notification is discarded as a reordered event
+// by the Broker. The lock is global across instances but the contention is bounded: it is held
+// only for nextval and pg_notify in a short transaction, and Postgres already serializes the
+// commits of notifying transactions by the lock of notification queue.
+func (n *PostgresNotifier) Notify(ctx context.Context, channel string, payload []byte) error {
+
+	tx, err := n.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the two keys form is not overlapped with the bigint lock of migrations
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('pg_notify'), hashtext($1))", channel)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`SELECT pg_notify($1, jsonb_set($2::jsonb, '{id}', to_jsonb(nextval('notification_id_seq')))::text)`,
+		channel, string(payload),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// NotificationHandler .
+type NotificationHandler func(payload []byte)
+
+// PostgresListener LISTENs on a dedicated connection and fans the notifications out
+// to in-process handlers, the connection is re-established automatically.
+type PostgresListener struct {
+	dsn string
+
+	mu                sync.RWMutex
+	handlers          map[string][]NotificationHandler
+	reconnectHandlers []func()
+
+	connected atomic.Bool
+}
+
+// ErrListenerNotConnected .
+var ErrListenerNotConnected = errors.New("pg listener is not connected")
+
+// Check returns ErrListenerNotConnected if the listener is not running or reconnecting,
+// the task events of the other instances are missed meanwhile.
+func (l *PostgresListener) Check(_ context.Context) error {
+	if !l.connected.Load() {
+		return ErrListenerNotConnected
+	}
+	return nil
+}
+
+// NewPostgresListener .
+func NewPostgresListener(cfg *configs.Database) *PostgresListener {
+	return &PostgresListener{
+		dsn:      resolvePostgresDSN(cfg),
+		handlers: make(map[string][]NotificationHandler),
+	}
+}
+
+// Handle registers the handler of channel, it should be called before Run.
+func (l *PostgresListener) Handle(channel string, handler NotificationHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.handlers[channel] = append(l.handlers[channel], handler)
+}
+
+// OnReconnect registers the handler called after the connection has been re-established,
+// the notifications sent during the disconnection are lost.
+func (l *PostgresListener) OnReconnect(handler func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reconnectHandlers = append(l.reconnectHandlers, handler)
+}
+
+// Run listens on the registered channels until ctx is done.
+func (l *PostgresListener) Run(ctx context.Context) error {
+
+	listener := pq.NewListener(l.dsn, listenerMinReconnectInterval, listenerMaxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventConnected:
+				l.connected.Store(true)
+				slog.Info("pg listener connected")
+			case pq.ListenerEventReconnected:
+				l.connected.Store(true)
+				slog.Info("pg listener reconnected")
+			case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+				l.connected.Store(false)
+				if err != nil {
+					slog.Warn("pg listener disconnected", "err", err)
+				}
+			}
+		},
+	)
+
+	// closing the listener also aborts the blocking Listen calls
+	go func() {
+		<-ctx.Done()
+		l.connected.Store(false)
+		_ = listener.Close()
+	}()
+
+	l.mu.RLock()
+	channels := make([]string, 0, len(l.handlers))
+	for channel := range l.handlers {
+		channels = append(channels, channel)
+	}
+	l.mu.RUnlock()
+
+	for i := range channels {
+		err := listener.Listen(channels[i])
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case notification, ok := <-listener.Notify:
+			if !ok {
+				return nil
+			}
+
+			// a nil notification is sent after the connection has been re-established
+			if notification == nil {
+				l.reconnected()
+				continue
+			}
+
+			l.dispatch(notification.Channel, []byte(notification.Extra))
+
+		case <-ticker.C:
+			go func() {
+				if err := listener.Ping(); err != nil {
+					slog.Warn("pg listener ping fail", "err", err)
+				}
+			}()
+		}
+	}
+}
+
+// dispatch .
+func (l *PostgresListener) dispatch(channel string, payload []byte) {
+	l.mu.RLock()
+	handlers := l.handlers[channel]
+	l.mu.RUnlock()
+
+	for i := range handlers {
+		handlers[i](payload)
+	}
+}
+
+// reconnected .
+func (l *PostgresListener) reconnected() {
+	l.mu.RLock()
+	handlers := l.reconnectHandlers
+	l.mu.RUnlock()
+
+	for i := range handlers {
+		handlers[i]()
+	}
+}
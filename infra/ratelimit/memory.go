@@ -0,0 +1,84 @@
+// Package ratelimit provides the token bucket rate limiter, the buckets are kept in memory
+// or in postgres so that the replicas share them.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval removes the full buckets, they are the same as the missing ones.
+const memorySweepInterval = time.Minute
+
+// bucket .
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// the bucket is full after fullAt
+	fullAt time.Time
+}
+
+// MemoryStore keeps the buckets of the current instance.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore .
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Take .
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := math.Max(0, now.Sub(b.updatedAt).Seconds())
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.rate())
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	result := newResult(limit, allowed, b.tokens)
+	b.fullAt = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep .
+func (s *MemoryStore) sweep(now time.Time) {
+
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
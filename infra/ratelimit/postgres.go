@@ -0,0 +1,87 @@
+// Package ratelimit provides the token bucket rate limiter, the buckets are kept in memory
+// or in postgres so that the replicas share them.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// postgresPurgeInterval .
+const postgresPurgeInterval = 10 * time.Minute
+
+// takeQuery refills and takes a token atomically by the clock of database, so that
+// the clocks of replicas do not matter. $1 key, $2 burst, $3 rate per second.
+const takeQuery = `
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, TRUE, now())
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE
+		WHEN LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::float8) >= 1
+		THEN LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::float8) - 1
+		ELSE LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::float8)
+	END,
+	allowed = LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::float8) >= 1,
+	updated_at = now()
+RETURNING tokens, allowed`
+
+// PostgresStore keeps the buckets in the rate_limit_buckets table, they are shared by the replicas.
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresStore .
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take .
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+
+	var row struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+
+	err := s.db.GetContext(ctx, &row, takeQuery, key, limit.Burst, limit.rate())
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(limit, row.Allowed, row.Tokens), nil
+}
+
+// Purge deletes the buckets which are not used for idle, they should be full already.
+func (s *PostgresStore) Purge(ctx context.Context, idle time.Duration) (int64, error) {
+
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)", idle.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Run purges the idle buckets periodically until ctx is done.
+func (s *PostgresStore) Run(ctx context.Context, idle time.Duration) {
+
+	ticker := time.NewTicker(postgresPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := s.Purge(ctx, idle)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "purge rate limit buckets fail", "err", err)
+		}
+	}
+}
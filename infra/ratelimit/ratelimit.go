@@ -0,0 +1,204 @@
+// Package ratelimit provides the token bucket rate limiter, the buckets are kept in memory
+// or in postgres so that the replicas share them.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// AnyRoute matches all routes, its bucket is shared by the routes of principal.
+const AnyRoute = "*"
+
+// principal kinds, there is no user kind since the requests are authenticated by the API keys
+// without user accounts, issue a key per user to limit the users separately.
+const (
+	PrincipalAPIKey = "key"
+	PrincipalIP     = "ip"
+)
+
+// maxKeyLength of the bucket keys, the longer keys are hashed, see rate_limit_buckets.key.
+const maxKeyLength = 512
+
+// APIKeyPrincipal identifies the client by the digest of API key, the key itself is never kept.
+// The principal is only derived from the authenticated key, so that a client can not get
+// the fresh buckets by changing the other parts of request.
+func APIKeyPrincipal(key string) string {
+
+	digest := sha256.Sum256([]byte(key))
+
+	return PrincipalAPIKey + ":" + hex.EncodeToString(digest[:6])
+}
+
+// ErrInvalidLimit .
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit allows Requests per Period on average, and Burst requests at once.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// rate is the number of tokens refilled per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// validate .
+func (l Limit) validate() error {
+	if l.Requests <= 0 || l.Period <= 0 || l.Burst <= 0 {
+		return fmt.Errorf("%w: requests, period and burst should be positive, got %d, %s, %d", ErrInvalidLimit, l.Requests, l.Period, l.Burst)
+	}
+	return nil
+}
+
+// Result of taking a token.
+type Result struct {
+	Allowed bool
+	// the capacity of bucket
+	Limit int
+	// the whole tokens left
+	Remaining int
+	// the bucket will be full after Reset
+	Reset time.Duration
+	// the next token will be available after RetryAfter, zero if allowed
+	RetryAfter time.Duration
+}
+
+// newResult calculates the result by the tokens left in the bucket.
+func newResult(limit Limit, allowed bool, tokens float64) Result {
+
+	rate := limit.rate()
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / rate),
+	}
+
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+// secondsToDuration .
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Store takes a token from the bucket of key, the bucket is created full.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Rule limits the requests of route by principal.
+type Rule struct {
+	// gin route with method, e.g. "POST /task", or AnyRoute
+	Route string
+	// empty for all principals, a kind like "ip", or a principal like "key:1a2b3c4d5e6f"
+	Principal string
+
+	Limit Limit
+}
+
+// matchPrincipal returns the specificity of match, 0 means not matched.
+func (r Rule) matchPrincipal(principal string) int {
+	switch {
+	case r.Principal == "":
+		return 1
+	case r.Principal == principal:
+		return 3
+	case strings.HasPrefix(principal, r.Principal+":"):
+		return 2
+	}
+	return 0
+}
+
+// Limiter picks the most specific rule of request, the route is preferred to the principal.
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+// NewLimiter .
+func NewLimiter(store Store, rules []Rule) (*Limiter, error) {
+
+	for i := range rules {
+		if rules[i].Route == "" {
+			return nil, fmt.Errorf("%w: rules[%d].route is required", ErrInvalidLimit, i)
+		}
+		if err := rules[i].Limit.validate(); err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+
+	return &Limiter{store: store, rules: rules}, nil
+}
+
+// Match returns the rule of route and principal.
+func (l *Limiter) Match(route string, principal string) (Rule, bool) {
+
+	var (
+		matched Rule
+		best    int
+	)
+
+	for _, rule := range l.rules {
+		var score int
+		switch rule.Route {
+		case route:
+			score = 10
+		case AnyRoute:
+			score = 0
+		default:
+			continue
+		}
+
+		p := rule.matchPrincipal(principal)
+		if p == 0 {
+			continue
+		}
+
+		if score += p; score > best {
+			matched, best = rule, score
+		}
+	}
+
+	return matched, best > 0
+}
+
+// Allow takes a token for the request of principal, it is always allowed if there is no rule.
+func (l *Limiter) Allow(ctx context.Context, route string, principal string) (Result, bool, error) {
+
+	rule, ok := l.Match(route, principal)
+	if !ok {
+		return Result{Allowed: true}, false, nil
+	}
+
+	// the buckets of rules are separated, so that changing a rule does not affect the others
+	key := rule.Route + "|" + rule.Principal + "|" + principal
+	if len(key) > maxKeyLength {
+		digest := sha256.Sum256([]byte(key))
+		key = rule.Route + "|" + hex.EncodeToString(digest[:])
+	}
+
+	result, err := l.store.Take(ctx, key, rule.Limit)
+	if err != nil {
+		return Result{Allowed: true}, false, err
+	}
+
+	return result, true, nil
+}
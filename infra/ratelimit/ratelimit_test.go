@@ -0,0 +1,136 @@
+// Package ratelimit provides the token bucket rate limiter, the buckets are kept in memory
+// or in postgres so that the replicas share them.
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryStore_Take .
+func TestMemoryStore_Take(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	// 1 request per second, 3 at once
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		got, err := s.Take(context.Background(), "k", limit)
+		require.NoError(t, err)
+		assert.True(t, got.Allowed)
+		assert.Equal(t, 3, got.Limit)
+		assert.Equal(t, i, got.Remaining)
+	}
+
+	got, err := s.Take(context.Background(), "k", limit)
+	require.NoError(t, err)
+	assert.False(t, got.Allowed)
+	assert.Equal(t, 0, got.Remaining)
+	assert.Equal(t, time.Second, got.RetryAfter)
+	assert.Equal(t, 3*time.Second, got.Reset)
+
+	// the other keys have their own buckets
+	got, err = s.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	assert.True(t, got.Allowed)
+
+	// refilled by the elapsed time
+	now = now.Add(1500 * time.Millisecond)
+
+	got, err = s.Take(context.Background(), "k", limit)
+	require.NoError(t, err)
+	assert.True(t, got.Allowed)
+	assert.Equal(t, 0, got.Remaining)
+
+	// the full buckets are swept
+	now = now.Add(time.Hour)
+
+	_, err = s.Take(context.Background(), "k", limit)
+	require.NoError(t, err)
+	assert.Len(t, s.buckets, 1)
+}
+
+// TestLimiter_Match .
+func TestLimiter_Match(t *testing.T) {
+	t.Parallel()
+
+	limit := Limit{Requests: 1, Period: time.Second, Burst: 1}
+
+	limiter, err := NewLimiter(NewMemoryStore(), []Rule{
+		{Route: AnyRoute, Limit: limit},
+		{Route: AnyRoute, Principal: PrincipalIP, Limit: limit},
+		{Route: "POST /task", Limit: limit},
+		{Route: "POST /task", Principal: "key:trusted", Limit: limit},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		route     string
+		principal string
+		want      Rule
+	}{
+		{route: "GET /tasks", principal: "key:abc", want: Rule{Route: AnyRoute}},
+		{route: "GET /tasks", principal: "ip:10.0.0.1", want: Rule{Route: AnyRoute, Principal: PrincipalIP}},
+		{route: "POST /task", principal: "ip:10.0.0.1", want: Rule{Route: "POST /task"}},
+		{route: "POST /task", principal: "key:trusted", want: Rule{Route: "POST /task", Principal: "key:trusted"}},
+	}
+
+	for _, tt := range tests {
+		got, ok := limiter.Match(tt.route, tt.principal)
+		require.True(t, ok)
+		assert.Equal(t, tt.want.Route, got.Route, tt.route, tt.principal)
+		assert.Equal(t, tt.want.Principal, got.Principal, tt.route, tt.principal)
+	}
+
+	// no rule
+	limiter, err = NewLimiter(NewMemoryStore(), []Rule{{Route: "POST /task", Limit: limit}})
+	require.NoError(t, err)
+
+	result, limited, err := limiter.Allow(context.Background(), "GET /tasks", "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	assert.True(t, result.Allowed)
+
+	_, err = NewLimiter(NewMemoryStore(), []Rule{{Route: "POST /task"}})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+// recordingStore records the bucket keys.
+type recordingStore struct {
+	keys []string
+}
+
+// Take .
+func (s *recordingStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.keys = append(s.keys, key)
+	return newResult(limit, true, float64(limit.Burst)), nil
+}
+
+// TestLimiter_KeyLength .
+func TestLimiter_KeyLength(t *testing.T) {
+	t.Parallel()
+
+	store := &recordingStore{}
+
+	limiter, err := NewLimiter(store, []Rule{
+		{Route: AnyRoute, Limit: Limit{Requests: 1, Period: time.Second, Burst: 1}},
+	})
+	require.NoError(t, err)
+
+	_, _, err = limiter.Allow(context.Background(), "GET /tasks", "ip:"+strings.Repeat("a", 1000))
+	require.NoError(t, err)
+
+	require.Len(t, store.keys, 1)
+	assert.LessOrEqual(t, len(store.keys[0]), maxKeyLength)
+	assert.Len(t, APIKeyPrincipal(strings.Repeat("k", 1000)), len(PrincipalAPIKey)+1+12)
+}
@@ -0,0 +1,119 @@
+// Package tracing provides the OpenTelemetry tracer provider with W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+
+	"github.com/tingchima/gogolook/configs"
+)
+
+// exporters
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Setup sets the global tracer provider and propagator, the returned shutdown flushes the
+// pending spans. Only the propagator is set if tracing is disabled, so that the trace context
+// of requests is still passed to the downstream.
+func Setup(ctx context.Context, cfg *configs.Tracing, version string) (shutdown func(ctx context.Context) error, err error) {
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeOutput, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// follow the sampling decision of upstream if there is a traceparent
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+// newExporter .
+func newExporter(ctx context.Context, cfg *configs.Tracing) (sdktrace.SpanExporter, func() error, error) {
+
+	noClose := func() error { return nil }
+
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("new otlp exporter: %w", err)
+		}
+		return exporter, noClose, nil
+
+	case ExporterStdout:
+		exporter, err := newWriterExporter(os.Stdout)
+		return exporter, noClose, err
+
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open trace file: %w", err)
+		}
+
+		exporter, err := newWriterExporter(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		return exporter, f.Close, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+}
+
+// newWriterExporter writes a span per line in json.
+func newWriterExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("new stdout exporter: %w", err)
+	}
+
+	return exporter, nil
+}
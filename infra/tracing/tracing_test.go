@@ -0,0 +1,67 @@
+// Package tracing provides the OpenTelemetry tracer provider with W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/configs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestSetup_File .
+func TestSetup_File(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err := Setup(context.Background(), &configs.Tracing{
+		Enabled:     true,
+		ServiceName: "gogolook-test",
+		Exporter:    ExporterFile,
+		File:        file,
+		SampleRatio: 1,
+	}, "test")
+	require.NoError(t, err)
+
+	// continue the trace of upstream
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	_, span := otel.Tracer("test").Start(ctx, "test span")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"Name":"test span"`)
+	assert.Contains(t, string(b), "gogolook-test")
+}
+
+// TestSetup_Disabled .
+func TestSetup_Disabled(t *testing.T) {
+
+	shutdown, err := Setup(context.Background(), &configs.Tracing{Enabled: false}, "test")
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// the trace context is still propagated
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+	assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+
+	_, err = Setup(context.Background(), &configs.Tracing{Enabled: true, Exporter: "zipkin"}, "test")
+	assert.Error(t, err)
+}
@@ -2,21 +2,47 @@
 package application
 
 import (
+	"context"
 	"log"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/tingchima/gogolook/infra"
+	"github.com/tingchima/gogolook/infra/metrics"
+	"github.com/tingchima/gogolook/internal/application/calendar"
+	"github.com/tingchima/gogolook/internal/application/reminder"
 	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/application/taskdetail"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/repository/cache"
 	"github.com/tingchima/gogolook/internal/repository/postgres"
 )
 
 // Application .
 type Application struct {
-	TaskService *task.Service
+	TaskService       *task.Service
+	TaskDetailService *taskdetail.Service
+	ReminderService   *reminder.Service
+	ReminderScheduler *reminder.Scheduler
+	CalendarService   *calendar.Service
+
+	taskBroker   *task.Broker
+	postgresRepo *postgres.Postgres
 }
 
 // ApplicationParam .
 type ApplicationParam struct {
 	PostgresConn *sqlx.DB
+	// PostgresReplicas serve the list and get queries, the primary serves them if it is empty
+	PostgresReplicas []*sqlx.DB
+	// PostgresListener propagates the task events across instances by LISTEN/NOTIFY,
+	// the events are only delivered in-process if it is nil.
+	PostgresListener *infra.PostgresListener
+	// ReminderNotifiers delivers the reminders by channel
+	ReminderNotifiers map[domain.ReminderChannel]reminder.Notifier
+	// Metrics observes the repository methods if it is not nil
+	Metrics *metrics.Metrics
+	// TaskCache caches the task reads in-process if it is not nil
+	TaskCache *cache.TaskRepositoryParam
 }
 
 // MustNewApplication .
@@ -34,11 +60,86 @@ func MustNewApplication(param ApplicationParam) *Application {
 // NewApplication .
 func NewApplication(param ApplicationParam) (*Application, error) {
 
-	postgresRepo := postgres.NewRepository(param.PostgresConn)
+	var repoOptions []postgres.Option
+	if param.Metrics != nil {
+		repoOptions = append(repoOptions, postgres.WithQueryObserver(param.Metrics.QueryObserver("postgres")))
+	}
+	if len(param.PostgresReplicas) > 0 {
+		repoOptions = append(repoOptions, postgres.WithReplicas(param.PostgresReplicas...))
+	}
+
+	postgresRepo := postgres.NewRepository(param.PostgresConn, repoOptions...)
+
+	var taskRepo task.Repository = postgresRepo
+
+	var taskCache *cache.TaskRepository
+	if param.TaskCache != nil {
+		taskCache = cache.NewTaskRepository(postgresRepo, *param.TaskCache)
+		taskRepo = taskCache
+	}
+
+	taskBroker := task.NewBroker(task.DefaultBrokerReplaySize)
+
+	var taskPublisher task.EventPublisher = taskBroker
+
+	if param.PostgresListener != nil {
+		// the events are still delivered in-process while NOTIFY is failing
+		taskPublisher = task.NewNotifyPublisher(infra.NewPostgresNotifier(param.PostgresConn), taskBroker)
+
+		param.PostgresListener.Handle(task.EventChannel, task.HandleNotification(taskBroker))
+		param.PostgresListener.OnReconnect(taskBroker.Reset)
+
+		// the writes of other instances purge the cache, the notifications missed during
+		// the disconnection are covered by the purge on reconnect
+		if taskCache != nil {
+			param.PostgresListener.Handle(task.EventChannel, func([]byte) { taskCache.Invalidate() })
+			param.PostgresListener.OnReconnect(taskCache.Invalidate)
+		}
+	}
 
 	taskService := task.NewService(task.ServiceParam{
+		PostgresRepo: taskRepo,
+		Broker:       taskBroker,
+		Publisher:    taskPublisher,
+	})
+
+	taskDetailService := taskdetail.NewService(taskdetail.ServiceParam{
+		PostgresRepo: postgresRepo,
+	})
+
+	reminderService := reminder.NewService(reminder.ServiceParam{
+		PostgresRepo: postgresRepo,
+		Notifiers:    param.ReminderNotifiers,
+	})
+
+	reminderScheduler := reminder.NewScheduler(reminder.SchedulerParam{
 		PostgresRepo: postgresRepo,
+		Notifiers:    param.ReminderNotifiers,
 	})
 
-	return &Application{TaskService: taskService}, nil
+	calendarService := calendar.NewService(calendar.ServiceParam{
+		PostgresRepo: postgresRepo,
+	})
+
+	return &Application{
+		TaskService:       taskService,
+		TaskDetailService: taskDetailService,
+		ReminderService:   reminderService,
+		ReminderScheduler: reminderScheduler,
+		CalendarService:   calendarService,
+		taskBroker:        taskBroker,
+		postgresRepo:      postgresRepo,
+	}, nil
+}
+
+// RunReplicaCheck checks the postgres replicas until ctx is done, the reads fail over to the primary
+// while a replica is down.
+func (app *Application) RunReplicaCheck(ctx context.Context) {
+	app.postgresRepo.RunReplicaCheck(ctx, postgres.ReplicaCheckInterval)
+}
+
+// Close releases the long-lived resources of application,
+// like closing the task event subscriptions so that streaming requests can finish.
+func (app *Application) Close() {
+	app.taskBroker.Close()
 }
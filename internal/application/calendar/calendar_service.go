@@ -0,0 +1,110 @@
+// Package calendar provides
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// tokenBytes is the entropy of the subscription token.
+const tokenBytes = 32
+
+// maxFieldLength is the length of the varchar columns of calendar feed.
+const maxFieldLength = 255
+
+// ErrInvalidToken .
+var ErrInvalidToken = errors.New("calendar feed not found")
+
+// 列出使用者的日曆訂閱
+func (s *Service) ListFeeds(ctx context.Context, user string) ([]domain.CalendarFeed, error) {
+
+	return s.postgresRepo.ListCalendarFeeds(ctx, user)
+}
+
+// 建立日曆訂閱, 回傳的 token 只會出現這一次
+func (s *Service) CreateFeed(ctx context.Context, param domain.CalendarFeed) (feed *domain.CalendarFeed, token string, err error) {
+
+	param.User = strings.TrimSpace(param.User)
+
+	if param.User == "" {
+		msg := "the user is required"
+		return nil, "", common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	if utf8.RuneCountInString(param.User) > maxFieldLength || utf8.RuneCountInString(param.Name) > maxFieldLength {
+		msg := fmt.Sprintf("the user and name should be at most %d characters", maxFieldLength)
+		return nil, "", common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	token, err = generateToken()
+	if err != nil {
+		return nil, "", common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	param.TokenHash = hashToken(token)
+
+	feed, err = s.postgresRepo.CreateCalendarFeed(ctx, param)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return feed, token, nil
+}
+
+// 刪除使用者的日曆訂閱
+func (s *Service) DeleteFeed(ctx context.Context, user string, id int64) error {
+
+	return s.postgresRepo.DeleteCalendarFeed(ctx, user, id)
+}
+
+// 透過訂閱token列出日曆的任務, 篩選條件與任務列表相同
+func (s *Service) ListFeedTasks(ctx context.Context, token string) (*domain.CalendarFeed, []domain.Task, error) {
+
+	// a malformed token can not match any feed, it is not looked up
+	if _, err := base64.RawURLEncoding.DecodeString(token); err != nil || len(token) == 0 {
+		err := ErrInvalidToken
+		return nil, nil, common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+	}
+
+	feed, err := s.postgresRepo.GetCalendarFeedByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tasks, err := s.postgresRepo.ListTasks(ctx, feed.Param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return feed, tasks, nil
+}
+
+// generateToken .
+func generateToken() (string, error) {
+
+	b := make([]byte, tokenBytes)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken .
+func hashToken(token string) string {
+
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
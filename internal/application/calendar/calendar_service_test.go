@@ -0,0 +1,155 @@
+// Package calendar provides
+package calendar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestCalendarService_CreateFeed .
+func TestCalendarService_CreateFeed(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name            string
+		param           domain.CalendarFeed
+		wantErr         bool
+		expectedErrCode common.ErrCode
+		setupService    func(t *testing.T) *Service
+	}{
+		{
+			name:  "success",
+			param: domain.CalendarFeed{User: " alice ", Name: "工作"},
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().CreateCalendarFeed(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, feed domain.CalendarFeed) (*domain.CalendarFeed, error) {
+						assert.Equal(t, "alice", feed.User)
+						assert.Len(t, feed.TokenHash, 64)
+						feed.ID = 1
+						return &feed, nil
+					})
+
+				return buildService(mock)
+			},
+		},
+		{
+			name:  "user is required",
+			param: domain.CalendarFeed{Name: "工作"},
+			setupService: func(t *testing.T) *Service {
+				return buildService(buildMockService(ctrl))
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setupService(t)
+
+			feed, token, err := s.CreateFeed(context.Background(), tt.param)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, common.IsErrCode(err, tt.expectedErrCode))
+
+			} else {
+				require.NoError(t, err)
+				assert.NotEmpty(t, token)
+				assert.Equal(t, hashToken(token), feed.TokenHash)
+			}
+		})
+	}
+}
+
+// TestCalendarService_ListFeedTasks .
+func TestCalendarService_ListFeedTasks(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	token, err := generateToken()
+	require.NoError(t, err)
+
+	feed := domain.CalendarFeed{
+		ID:        1,
+		User:      "alice",
+		TokenHash: hashToken(token),
+		Param:     domain.TaskParam{Status: null.BoolFrom(false)},
+	}
+
+	tests := []struct {
+		name            string
+		token           string
+		wantErr         bool
+		expectedErrCode common.ErrCode
+		setupService    func(t *testing.T) *Service
+	}{
+		{
+			name:  "success",
+			token: token,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().GetCalendarFeedByTokenHash(gomock.Any(), feed.TokenHash).Return(&feed, nil)
+				mock.postgresRepo.EXPECT().ListTasks(gomock.Any(), feed.Param).Return([]domain.Task{{ID: 1}}, nil)
+
+				return buildService(mock)
+			},
+		},
+		{
+			name:  "malformed token",
+			token: "not a token!",
+			setupService: func(t *testing.T) *Service {
+				return buildService(buildMockService(ctrl))
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeResourceNotFound,
+		},
+		{
+			name:  "revoked token",
+			token: token,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				err := common.NewError(common.ErrCodeResourceNotFound, errors.New("calendar feed not found"))
+
+				mock.postgresRepo.EXPECT().GetCalendarFeedByTokenHash(gomock.Any(), feed.TokenHash).Return(nil, err)
+
+				return buildService(mock)
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeResourceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setupService(t)
+
+			gotFeed, tasks, err := s.ListFeedTasks(context.Background(), tt.token)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, common.IsErrCode(err, tt.expectedErrCode))
+
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, feed.ID, gotFeed.ID)
+				assert.Len(t, tasks, 1)
+			}
+		})
+	}
+}
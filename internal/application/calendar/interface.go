@@ -0,0 +1,30 @@
+// Package calendar provides
+package calendar
+
+import (
+	"context"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// Repository
+//
+//go:generate mockgen -destination mocks/repository.go -package=mocks . Repository
+type Repository interface {
+	CalendarFeedRepository
+
+	// 列出任務
+	ListTasks(ctx context.Context, param domain.TaskParam) ([]domain.Task, error)
+}
+
+// CalendarFeedRepository .
+type CalendarFeedRepository interface {
+	// 列出使用者的日曆訂閱
+	ListCalendarFeeds(ctx context.Context, user string) ([]domain.CalendarFeed, error)
+	// 透過token hash取得日曆訂閱
+	GetCalendarFeedByTokenHash(ctx context.Context, tokenHash string) (*domain.CalendarFeed, error)
+	// 建立日曆訂閱
+	CreateCalendarFeed(ctx context.Context, param domain.CalendarFeed) (*domain.CalendarFeed, error)
+	// 刪除使用者的日曆訂閱
+	DeleteCalendarFeed(ctx context.Context, user string, id int64) error
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/tingchima/gogolook/internal/application/calendar (interfaces: Repository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/tingchima/gogolook/internal/domain"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateCalendarFeed mocks base method.
+func (m *MockRepository) CreateCalendarFeed(arg0 context.Context, arg1 domain.CalendarFeed) (*domain.CalendarFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCalendarFeed", arg0, arg1)
+	ret0, _ := ret[0].(*domain.CalendarFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCalendarFeed indicates an expected call of CreateCalendarFeed.
+func (mr *MockRepositoryMockRecorder) CreateCalendarFeed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCalendarFeed", reflect.TypeOf((*MockRepository)(nil).CreateCalendarFeed), arg0, arg1)
+}
+
+// DeleteCalendarFeed mocks base method.
+func (m *MockRepository) DeleteCalendarFeed(arg0 context.Context, arg1 string, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendarFeed", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendarFeed indicates an expected call of DeleteCalendarFeed.
+func (mr *MockRepositoryMockRecorder) DeleteCalendarFeed(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendarFeed", reflect.TypeOf((*MockRepository)(nil).DeleteCalendarFeed), arg0, arg1, arg2)
+}
+
+// GetCalendarFeedByTokenHash mocks base method.
+func (m *MockRepository) GetCalendarFeedByTokenHash(arg0 context.Context, arg1 string) (*domain.CalendarFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarFeedByTokenHash", arg0, arg1)
+	ret0, _ := ret[0].(*domain.CalendarFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarFeedByTokenHash indicates an expected call of GetCalendarFeedByTokenHash.
+func (mr *MockRepositoryMockRecorder) GetCalendarFeedByTokenHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarFeedByTokenHash", reflect.TypeOf((*MockRepository)(nil).GetCalendarFeedByTokenHash), arg0, arg1)
+}
+
+// ListCalendarFeeds mocks base method.
+func (m *MockRepository) ListCalendarFeeds(arg0 context.Context, arg1 string) ([]domain.CalendarFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalendarFeeds", arg0, arg1)
+	ret0, _ := ret[0].([]domain.CalendarFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalendarFeeds indicates an expected call of ListCalendarFeeds.
+func (mr *MockRepositoryMockRecorder) ListCalendarFeeds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalendarFeeds", reflect.TypeOf((*MockRepository)(nil).ListCalendarFeeds), arg0, arg1)
+}
+
+// ListTasks mocks base method.
+func (m *MockRepository) ListTasks(arg0 context.Context, arg1 domain.TaskParam) ([]domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockRepositoryMockRecorder) ListTasks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockRepository)(nil).ListTasks), arg0, arg1)
+}
@@ -0,0 +1,18 @@
+// Package calendar provides
+package calendar
+
+type Service struct {
+	postgresRepo Repository
+}
+
+// ServiceParam .
+type ServiceParam struct {
+	PostgresRepo Repository
+}
+
+// NewService .
+func NewService(param ServiceParam) *Service {
+	return &Service{
+		postgresRepo: param.PostgresRepo,
+	}
+}
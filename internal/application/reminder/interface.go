@@ -0,0 +1,45 @@
+// Package reminder provides
+package reminder
+
+import (
+	"context"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// Repository
+//
+//go:generate mockgen -destination mocks/repository.go -package=mocks . Repository
+type Repository interface {
+	ReminderRepository
+
+	// 透過ID取得任務
+	GetTaskByID(ctx context.Context, id int64) (*domain.Task, error)
+}
+
+// ReminderRepository .
+type ReminderRepository interface {
+	// 列出任務的提醒
+	ListRemindersByTaskID(ctx context.Context, taskID int64) ([]domain.Reminder, error)
+	// 列出多個任務的提醒, 依任務ID與提醒ID排序
+	ListRemindersByTaskIDs(ctx context.Context, taskIDs []int64) ([]domain.Reminder, error)
+	// 建立提醒
+	CreateReminder(ctx context.Context, param domain.Reminder) (*domain.Reminder, error)
+	// 刪除任務的提醒
+	DeleteReminder(ctx context.Context, taskID int64, id int64) error
+	// 鎖定到期的提醒並交由 handle 送出, 依結果標記為已送出或累計失敗次數,
+	// handle 回傳的錯誤訊息會存為最後一次失敗的原因並回應給使用者, 不應包含內部位址等資訊,
+	// 多個實例同時執行時不會取得相同的提醒
+	ProcessDueReminders(ctx context.Context, param domain.DueReminderParam, handle func(ctx context.Context, reminder domain.DueReminder) error) (int, error)
+}
+
+// Notifier delivers the reminder.
+type Notifier interface {
+	Notify(ctx context.Context, reminder domain.DueReminder) error
+}
+
+// RecipientValidator is implemented by the Notifier which restricts the recipients,
+// it is checked when the reminder is created.
+type RecipientValidator interface {
+	ValidateRecipient(recipient string) error
+}
@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/tingchima/gogolook/internal/application/reminder (interfaces: Repository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/tingchima/gogolook/internal/domain"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateReminder mocks base method.
+func (m *MockRepository) CreateReminder(arg0 context.Context, arg1 domain.Reminder) (*domain.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReminder", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReminder indicates an expected call of CreateReminder.
+func (mr *MockRepositoryMockRecorder) CreateReminder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReminder", reflect.TypeOf((*MockRepository)(nil).CreateReminder), arg0, arg1)
+}
+
+// DeleteReminder mocks base method.
+func (m *MockRepository) DeleteReminder(arg0 context.Context, arg1, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReminder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteReminder indicates an expected call of DeleteReminder.
+func (mr *MockRepositoryMockRecorder) DeleteReminder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReminder", reflect.TypeOf((*MockRepository)(nil).DeleteReminder), arg0, arg1, arg2)
+}
+
+// GetTaskByID mocks base method.
+func (m *MockRepository) GetTaskByID(arg0 context.Context, arg1 int64) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskByID", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaskByID indicates an expected call of GetTaskByID.
+func (mr *MockRepositoryMockRecorder) GetTaskByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskByID", reflect.TypeOf((*MockRepository)(nil).GetTaskByID), arg0, arg1)
+}
+
+// ListRemindersByTaskID mocks base method.
+func (m *MockRepository) ListRemindersByTaskID(arg0 context.Context, arg1 int64) ([]domain.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRemindersByTaskID", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRemindersByTaskID indicates an expected call of ListRemindersByTaskID.
+func (mr *MockRepositoryMockRecorder) ListRemindersByTaskID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRemindersByTaskID", reflect.TypeOf((*MockRepository)(nil).ListRemindersByTaskID), arg0, arg1)
+}
+
+// ListRemindersByTaskIDs mocks base method.
+func (m *MockRepository) ListRemindersByTaskIDs(arg0 context.Context, arg1 []int64) ([]domain.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRemindersByTaskIDs", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRemindersByTaskIDs indicates an expected call of ListRemindersByTaskIDs.
+func (mr *MockRepositoryMockRecorder) ListRemindersByTaskIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRemindersByTaskIDs", reflect.TypeOf((*MockRepository)(nil).ListRemindersByTaskIDs), arg0, arg1)
+}
+
+// ProcessDueReminders mocks base method.
+func (m *MockRepository) ProcessDueReminders(arg0 context.Context, arg1 domain.DueReminderParam, arg2 func(context.Context, domain.DueReminder) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessDueReminders", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessDueReminders indicates an expected call of ProcessDueReminders.
+func (mr *MockRepositoryMockRecorder) ProcessDueReminders(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessDueReminders", reflect.TypeOf((*MockRepository)(nil).ProcessDueReminders), arg0, arg1, arg2)
+}
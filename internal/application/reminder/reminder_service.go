@@ -0,0 +1,108 @@
+// Package reminder provides
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// 列出任務的提醒
+func (s *Service) ListReminders(ctx context.Context, taskID int64) ([]domain.Reminder, error) {
+
+	_, err := s.postgresRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.postgresRepo.ListRemindersByTaskID(ctx, taskID)
+}
+
+// 列出多個任務的提醒, 不存在的任務沒有提醒
+func (s *Service) ListRemindersByTaskIDs(ctx context.Context, taskIDs []int64) (map[int64][]domain.Reminder, error) {
+
+	reminders, err := s.postgresRepo.ListRemindersByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	remindersByTaskID := make(map[int64][]domain.Reminder, len(taskIDs))
+	for i := range reminders {
+		remindersByTaskID[reminders[i].TaskID] = append(remindersByTaskID[reminders[i].TaskID], reminders[i])
+	}
+
+	return remindersByTaskID, nil
+}
+
+// 建立提醒
+func (s *Service) CreateReminder(ctx context.Context, param domain.Reminder) (*domain.Reminder, error) {
+
+	err := validateReminder(param)
+	if err != nil {
+		return nil, err
+	}
+
+	if validator, ok := s.notifiers[param.Channel].(RecipientValidator); ok {
+		err = validator.ValidateRecipient(param.Recipient)
+		if err != nil {
+			return nil, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error()))
+		}
+	}
+
+	// the task may be created right before
+	task, err := s.postgresRepo.GetTaskByID(common.WithPrimaryRead(ctx), param.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if param.Offset.Valid && !task.DueAt.Valid {
+		msg := "the task has no due_at, the reminder relative to due date will never fire"
+		return nil, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	return s.postgresRepo.CreateReminder(ctx, param)
+}
+
+// 刪除任務的提醒
+func (s *Service) DeleteReminder(ctx context.Context, taskID int64, id int64) error {
+
+	return s.postgresRepo.DeleteReminder(ctx, taskID, id)
+}
+
+// validateReminder .
+func validateReminder(param domain.Reminder) error {
+
+	if param.RemindAt.Valid == param.Offset.Valid {
+		msg := "either remind_at or offset_seconds should be specified"
+		return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	switch param.Channel {
+	case domain.ReminderChannelLog:
+
+	case domain.ReminderChannelWebhook:
+		u, err := url.Parse(param.Recipient)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			msg := "the recipient of webhook reminder should be a http(s) url"
+			return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+		}
+
+	case domain.ReminderChannelSMTP:
+		_, err := mail.ParseAddress(param.Recipient)
+		if err != nil {
+			msg := "the recipient of smtp reminder should be an email address"
+			return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(msg))
+		}
+
+	default:
+		msg := fmt.Sprintf("the channel %q is not supported", param.Channel)
+		return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	return nil
+}
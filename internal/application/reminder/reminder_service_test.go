@@ -0,0 +1,155 @@
+// Package reminder provides
+package reminder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+// rejectingNotifier rejects all recipients.
+type rejectingNotifier struct{}
+
+// Notify .
+func (rejectingNotifier) Notify(context.Context, domain.DueReminder) error {
+	return nil
+}
+
+// ValidateRecipient .
+func (rejectingNotifier) ValidateRecipient(string) error {
+	return errors.New("the webhook address is not allowed")
+}
+
+// TestReminderService_CreateReminder .
+func TestReminderService_CreateReminder(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dueTask := domain.Task{ID: 1, Name: "繳房租", DueAt: null.TimeFrom(time.Now().Add(time.Hour))}
+
+	tests := []struct {
+		name            string
+		param           domain.Reminder
+		wantErr         bool
+		expectedErrCode common.ErrCode
+		setupService    func(t *testing.T) *Service
+	}{
+		{
+			name:  "relative to due date",
+			param: domain.Reminder{TaskID: 1, Offset: null.IntFrom(-600), Channel: domain.ReminderChannelLog},
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().GetTaskByID(gomock.Any(), int64(1)).Return(&dueTask, nil)
+				mock.postgresRepo.EXPECT().CreateReminder(gomock.Any(), gomock.Any()).Return(&domain.Reminder{ID: 1}, nil)
+
+				return buildService(mock)
+			},
+		},
+		{
+			name:  "webhook at absolute time",
+			param: domain.Reminder{TaskID: 1, RemindAt: null.TimeFrom(time.Now()), Channel: domain.ReminderChannelWebhook, Recipient: "https://example.com/hook"},
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().GetTaskByID(gomock.Any(), int64(1)).Return(&dueTask, nil)
+				mock.postgresRepo.EXPECT().CreateReminder(gomock.Any(), gomock.Any()).Return(&domain.Reminder{ID: 2}, nil)
+
+				return buildService(mock)
+			},
+		},
+		{
+			name:            "webhook recipient is not allowed",
+			param:           domain.Reminder{TaskID: 1, RemindAt: null.TimeFrom(time.Now()), Channel: domain.ReminderChannelWebhook, Recipient: "http://169.254.169.254/"},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+			setupService: func(t *testing.T) *Service {
+				return NewService(ServiceParam{
+					PostgresRepo: buildMockService(ctrl).postgresRepo,
+					Notifiers: map[domain.ReminderChannel]Notifier{
+						domain.ReminderChannelWebhook: rejectingNotifier{},
+					},
+				})
+			},
+		},
+		{
+			name:            "both remind_at and offset",
+			param:           domain.Reminder{TaskID: 1, RemindAt: null.TimeFrom(time.Now()), Offset: null.IntFrom(0), Channel: domain.ReminderChannelLog},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+			setupService: func(t *testing.T) *Service {
+				return buildService(buildMockService(ctrl))
+			},
+		},
+		{
+			name:            "invalid email",
+			param:           domain.Reminder{TaskID: 1, RemindAt: null.TimeFrom(time.Now()), Channel: domain.ReminderChannelSMTP, Recipient: "not an email"},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+			setupService: func(t *testing.T) *Service {
+				return buildService(buildMockService(ctrl))
+			},
+		},
+		{
+			name:            "relative to task without due date",
+			param:           domain.Reminder{TaskID: 2, Offset: null.IntFrom(-600), Channel: domain.ReminderChannelLog},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().GetTaskByID(gomock.Any(), int64(2)).Return(&domain.Task{ID: 2}, nil)
+
+				return buildService(mock)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setupService(t)
+
+			got, err := s.CreateReminder(context.Background(), tt.param)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, common.IsErrCode(err, tt.expectedErrCode))
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+// TestReminderService_ListRemindersByTaskIDs .
+func TestReminderService_ListRemindersByTaskIDs(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := buildMockService(ctrl)
+
+	mock.postgresRepo.EXPECT().ListRemindersByTaskIDs(gomock.Any(), []int64{1, 2, 3}).Return([]domain.Reminder{
+		{ID: 1, TaskID: 1},
+		{ID: 2, TaskID: 1},
+		{ID: 3, TaskID: 3},
+	}, nil)
+
+	got, err := buildService(mock).ListRemindersByTaskIDs(context.Background(), []int64{1, 2, 3})
+	require.NoError(t, err)
+
+	assert.Len(t, got[1], 2)
+	assert.Empty(t, got[2])
+	assert.Equal(t, int64(3), got[3][0].ID)
+}
@@ -0,0 +1,203 @@
+// Package reminder provides
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+const (
+	DefaultSchedulerInterval    = 30 * time.Second
+	DefaultSchedulerBatchSize   = 50
+	DefaultSchedulerMaxAttempts = 5
+	DefaultSchedulerRetryDelay  = time.Minute
+)
+
+// Scheduler picks up the due reminders periodically and delivers them by the notifier of channel.
+type Scheduler struct {
+	postgresRepo Repository
+	notifiers    map[domain.ReminderChannel]Notifier
+	interval     time.Duration
+	batchSize    int
+	maxAttempts  int
+	retryDelay   time.Duration
+
+	mu        sync.RWMutex
+	running   bool
+	lastRunAt time.Time
+	lastErr   error
+}
+
+// schedulerStalledRuns is the number of missed runs before the scheduler is reported as stalled.
+const schedulerStalledRuns = 3
+
+// ErrSchedulerNotRunning .
+var ErrSchedulerNotRunning = errors.New("reminder scheduler is not running")
+
+// SchedulerParam .
+type SchedulerParam struct {
+	PostgresRepo Repository
+	// 各通知管道的實作, 未設定的管道會送出失敗
+	Notifiers   map[domain.ReminderChannel]Notifier
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// NewScheduler .
+func NewScheduler(param SchedulerParam) *Scheduler {
+
+	s := &Scheduler{
+		postgresRepo: param.PostgresRepo,
+		notifiers:    param.Notifiers,
+		interval:     param.Interval,
+		batchSize:    param.BatchSize,
+		maxAttempts:  param.MaxAttempts,
+		retryDelay:   param.RetryDelay,
+	}
+
+	if s.interval <= 0 {
+		s.interval = DefaultSchedulerInterval
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = DefaultSchedulerBatchSize
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = DefaultSchedulerMaxAttempts
+	}
+	if s.retryDelay <= 0 {
+		s.retryDelay = DefaultSchedulerRetryDelay
+	}
+
+	return s
+}
+
+// Run delivers the due reminders every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+
+	s.setRunning(true)
+	defer s.setRunning(false)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.RunOnce(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "run reminder scheduler fail", "err", err)
+		}
+
+		s.mu.Lock()
+		s.lastRunAt, s.lastErr = time.Now(), err
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setRunning .
+func (s *Scheduler) setRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running = running
+}
+
+// Check returns error if the scheduler is not running, stalled, or failed in the last run.
+func (s *Scheduler) Check(_ context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.running {
+		return ErrSchedulerNotRunning
+	}
+
+	if !s.lastRunAt.IsZero() && time.Since(s.lastRunAt) > schedulerStalledRuns*s.interval {
+		return fmt.Errorf("reminder scheduler is stalled, last run at %s", s.lastRunAt.Format(time.RFC3339))
+	}
+
+	if s.lastErr != nil {
+		return fmt.Errorf("reminder scheduler last run fail: %w", s.lastErr)
+	}
+
+	return nil
+}
+
+// RunOnce delivers the due reminders batch by batch, and returns the number of processed reminders.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+
+	total := 0
+
+	for ctx.Err() == nil {
+		// the batch is not cancelled by ctx, otherwise the delivered reminders
+		// may not be marked as sent and will be delivered again
+		processed, err := s.postgresRepo.ProcessDueReminders(context.WithoutCancel(ctx), domain.DueReminderParam{
+			Now:         time.Now().UTC(),
+			Limit:       s.batchSize,
+			MaxAttempts: s.maxAttempts,
+			RetryDelay:  s.retryDelay,
+		}, s.deliver)
+		if err != nil {
+			return total, err
+		}
+
+		total += processed
+
+		if processed < s.batchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// deliver .
+func (s *Scheduler) deliver(ctx context.Context, reminder domain.DueReminder) error {
+
+	notifier, ok := s.notifiers[reminder.Reminder.Channel]
+	if !ok {
+		slog.WarnContext(ctx, "deliver reminder fail", "reminder_id", reminder.Reminder.ID, "channel", reminder.Reminder.Channel, "err", domain.ErrReminderNotifierNotConfigured)
+		return domain.ErrReminderNotifierNotConfigured
+	}
+
+	err := notifier.Notify(ctx, reminder)
+	if err != nil {
+		slog.WarnContext(ctx, "deliver reminder fail", "reminder_id", reminder.Reminder.ID, "channel", reminder.Reminder.Channel, "err", err)
+		return failureReason(err)
+	}
+
+	return nil
+}
+
+// failureReason returns the reason stored as the last error of reminder, the error of notifier is only logged.
+func failureReason(err error) error {
+
+	for _, reason := range []error{
+		domain.ErrReminderRecipientInvalid,
+		domain.ErrReminderRecipientNotAllowed,
+		domain.ErrReminderRejected,
+	} {
+		if errors.Is(err, reason) {
+			return reason
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return domain.ErrReminderTimeout
+	}
+
+	return domain.ErrReminderDeliveryFailed
+}
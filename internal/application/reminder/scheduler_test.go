@@ -0,0 +1,189 @@
+// Package reminder provides
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// recordNotifier records the delivered reminders, and fails if err is set.
+type recordNotifier struct {
+	delivered []int64
+	err       error
+}
+
+// Notify .
+func (n *recordNotifier) Notify(_ context.Context, reminder domain.DueReminder) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.delivered = append(n.delivered, reminder.Reminder.ID)
+	return nil
+}
+
+// TestScheduler_RunOnce .
+func TestScheduler_RunOnce(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := buildMockService(ctrl)
+
+	logNotifier := &recordNotifier{}
+	webhookNotifier := &recordNotifier{err: errors.New("mock webhook error")}
+
+	batches := [][]domain.DueReminder{
+		{
+			{Reminder: domain.Reminder{ID: 1, Channel: domain.ReminderChannelLog}},
+			{Reminder: domain.Reminder{ID: 2, Channel: domain.ReminderChannelWebhook}},
+		},
+		{
+			{Reminder: domain.Reminder{ID: 3, Channel: domain.ReminderChannelSMTP}},
+		},
+	}
+
+	var handleErrs []error
+
+	for i := range batches {
+		batch := batches[i]
+		mock.postgresRepo.EXPECT().ProcessDueReminders(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, param domain.DueReminderParam, handle func(context.Context, domain.DueReminder) error) (int, error) {
+				assert.Equal(t, 2, param.Limit)
+				for j := range batch {
+					handleErrs = append(handleErrs, handle(ctx, batch[j]))
+				}
+				return len(batch), nil
+			},
+		)
+	}
+
+	s := NewScheduler(SchedulerParam{
+		PostgresRepo: mock.postgresRepo,
+		Notifiers: map[domain.ReminderChannel]Notifier{
+			domain.ReminderChannelLog:     logNotifier,
+			domain.ReminderChannelWebhook: webhookNotifier,
+		},
+		BatchSize: 2,
+	})
+
+	// the second batch is not full, so the scheduler stops
+	processed, err := s.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, processed)
+
+	assert.Equal(t, []int64{1}, logNotifier.delivered)
+
+	require.Len(t, handleErrs, 3)
+	assert.NoError(t, handleErrs[0])
+	// the error of notifier is not stored
+	assert.Equal(t, domain.ErrReminderDeliveryFailed, handleErrs[1])
+	// smtp notifier is not configured
+	assert.Equal(t, domain.ErrReminderNotifierNotConfigured, handleErrs[2])
+}
+
+// TestFailureReason .
+func TestFailureReason(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "forbidden address",
+			err:  fmt.Errorf("%w: the webhook address is internal: 10.0.0.1:443", domain.ErrReminderRecipientNotAllowed),
+			want: domain.ErrReminderRecipientNotAllowed,
+		},
+		{
+			name: "rejected",
+			err:  fmt.Errorf("%w: 550 mailbox unavailable", domain.ErrReminderRejected),
+			want: domain.ErrReminderRejected,
+		},
+		{
+			name: "timeout",
+			err:  &url.Error{Op: "Post", URL: "https://example.com", Err: context.DeadlineExceeded},
+			want: domain.ErrReminderTimeout,
+		},
+		{
+			name: "dial",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: connection refused")},
+			want: domain.ErrReminderDeliveryFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, failureReason(tt.err), tt.name)
+	}
+}
+
+// TestScheduler_Check .
+func TestScheduler_Check(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := buildMockService(ctrl)
+
+	runErr := errors.New("mock db server error")
+
+	// the second run waits until the failure of the first run has been checked
+	proceed := make(chan struct{})
+
+	calls := 0
+	mock.postgresRepo.EXPECT().ProcessDueReminders(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, param domain.DueReminderParam, handle func(context.Context, domain.DueReminder) error) (int, error) {
+			calls++
+			switch calls {
+			case 1:
+				return 0, runErr
+			case 2:
+				<-proceed
+			}
+			return 0, nil
+		},
+	).AnyTimes()
+
+	s := NewScheduler(SchedulerParam{
+		PostgresRepo: mock.postgresRepo,
+		Interval:     10 * time.Millisecond,
+	})
+
+	assert.ErrorIs(t, s.Check(context.Background()), ErrSchedulerNotRunning)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	// the first run fails, and the next run recovers
+	assert.Eventually(t, func() bool {
+		return errors.Is(s.Check(context.Background()), runErr)
+	}, time.Second, time.Millisecond)
+
+	close(proceed)
+
+	assert.Eventually(t, func() bool {
+		return s.Check(context.Background()) == nil
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.ErrorIs(t, s.Check(context.Background()), ErrSchedulerNotRunning)
+}
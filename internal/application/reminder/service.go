@@ -0,0 +1,24 @@
+// Package reminder provides
+package reminder
+
+import "github.com/tingchima/gogolook/internal/domain"
+
+type Service struct {
+	postgresRepo Repository
+	notifiers    map[domain.ReminderChannel]Notifier
+}
+
+// ServiceParam .
+type ServiceParam struct {
+	PostgresRepo Repository
+	// Notifiers validates the recipients if they implement RecipientValidator
+	Notifiers map[domain.ReminderChannel]Notifier
+}
+
+// NewService .
+func NewService(param ServiceParam) *Service {
+	return &Service{
+		postgresRepo: param.PostgresRepo,
+		notifiers:    param.Notifiers,
+	}
+}
@@ -0,0 +1,226 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+const (
+	// DefaultBrokerReplaySize is the number of recent events kept for Last-Event-ID resume.
+	DefaultBrokerReplaySize = 1024
+
+	// subscriptionBufferSize is the number of pending events per subscriber,
+	// a subscriber falling further behind will be dropped.
+	subscriptionBufferSize = 64
+)
+
+// Broker fans task events out to in-process subscribers,
+// and keeps a bounded buffer of recent events for replay.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      int64
+	replay      []domain.TaskEvent
+	replaySize  int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+
+	// gapAfter is the last event id which may have been missed or evicted,
+	// resuming from an earlier id can not be complete.
+	gapAfter int64
+
+	// notifiedID is the last id issued by Notifier, the ids after it are assigned by this broker
+	notifiedID int64
+}
+
+// Subscription .
+type Subscription struct {
+	C <-chan domain.TaskEvent
+
+	ch     chan domain.TaskEvent
+	param  domain.TaskParam
+	broker *Broker
+	once   sync.Once
+}
+
+// NewBroker .
+func NewBroker(replaySize int) *Broker {
+	if replaySize <= 0 {
+		replaySize = DefaultBrokerReplaySize
+	}
+
+	return &Broker{
+		replay:      make([]domain.TaskEvent, 0, replaySize),
+		replaySize:  replaySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish delivers the event to the matched subscribers. The event without id is assigned
+// the next id of this broker, otherwise the id should be increasing, e.g. issued by Notifier
+// and shared by all instances, and the events before a skipped id are regarded as missed.
+//
+// The ids assigned by this broker give way to the ids issued by Notifier, for example the events
+// published in-process while NOTIFY is failing, they are removed from the replay buffer and
+// the clients resuming from them are told to reload.
+func (b *Broker) Publish(_ context.Context, event domain.TaskEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	notified := event.ID > 0
+
+	switch {
+	case !notified:
+		event.ID = b.lastID + 1
+	case event.ID <= b.notifiedID:
+		// the duplicated or reordered event
+		return nil
+	case event.ID <= b.lastID:
+		// the id has been assigned by this broker to another event
+		b.gapAfter = max(b.gapAfter, b.lastID+1)
+		b.dropAfter(b.notifiedID)
+	case event.ID > b.lastID+1:
+		// the events before it may have been sent before listening, or been lost
+		b.gapAfter = max(b.gapAfter, event.ID-1)
+	}
+	if notified {
+		b.notifiedID = event.ID
+	}
+	b.lastID = event.ID
+
+	if len(b.replay) == b.replaySize {
+		b.gapAfter = max(b.gapAfter, b.replay[0].ID)
+		copy(b.replay, b.replay[1:])
+		b.replay = b.replay[:len(b.replay)-1]
+	}
+	b.replay = append(b.replay, event)
+
+	for sub := range b.subscribers {
+		subEvent, ok := event.ForSubscriber(sub.param)
+		if !ok {
+			continue
+		}
+
+		select {
+		case sub.ch <- subEvent:
+		default:
+			// drop the slow subscriber, the client is able to resume by Last-Event-ID
+			b.remove(sub)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscriber for the events matched with param.
+// When lastEventID is greater than zero, the buffered events after it are returned for replay,
+// and complete is false if some of the events have already been evicted from the buffer,
+// or have not been received by this broker.
+func (b *Broker) Subscribe(param domain.TaskParam, lastEventID int64) (sub *Subscription, replay []domain.TaskEvent, complete bool) {
+	ch := make(chan domain.TaskEvent, subscriptionBufferSize)
+
+	sub = &Subscription{
+		C:      ch,
+		ch:     ch,
+		param:  param,
+		broker: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+
+	if lastEventID > 0 {
+		if lastEventID < b.gapAfter {
+			complete = false
+		}
+		if lastEventID > b.lastID {
+			// the id has not been received by this broker, for example the process has been restarted
+			complete = false
+		}
+
+		for i := range b.replay {
+			if b.replay[i].ID <= lastEventID {
+				continue
+			}
+			if event, ok := b.replay[i].ForSubscriber(param); ok {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	if b.closed {
+		close(ch)
+		return sub, replay, complete
+	}
+
+	b.subscribers[sub] = struct{}{}
+
+	return sub, replay, complete
+}
+
+// Reset marks the buffered events as incomplete and closes all subscriptions,
+// it is used when some events may have been lost, for example the notification connection was broken.
+// The clients resuming after reset are told to reload.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the next event may have been missed during the disconnection
+	b.gapAfter = b.lastID + 1
+
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// dropAfter removes the buffered events after id.
+func (b *Broker) dropAfter(id int64) {
+	for i := range b.replay {
+		if b.replay[i].ID > id {
+			b.replay = b.replay[:i]
+			return
+		}
+	}
+}
+
+// Close closes all subscriptions, and the later published events are discarded.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// remove should be called with b.mu held.
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
+
+// Close unregisters the subscription from broker.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.broker.mu.Lock()
+		defer s.broker.mu.Unlock()
+
+		s.broker.remove(s)
+	})
+}
@@ -0,0 +1,326 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestBroker_Subscribe .
+func TestBroker_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(10)
+	defer broker.Close()
+
+	sub, replay, complete := broker.Subscribe(domain.TaskParam{Status: null.BoolFrom(true)}, 0)
+	defer sub.Close()
+
+	assert.Empty(t, replay)
+	assert.True(t, complete)
+
+	ctx := context.Background()
+
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventCreated, Task: domain.Task{ID: 1, Status: false}}))
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventUpdated, Task: domain.Task{ID: 1, Status: true}}))
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventDeleted, Task: domain.Task{ID: 1}}))
+
+	// the created event does not match the status filter
+	event := <-sub.C
+	assert.Equal(t, int64(2), event.ID)
+	assert.Equal(t, domain.TaskEventUpdated, event.Type)
+
+	event = <-sub.C
+	assert.Equal(t, int64(3), event.ID)
+	assert.Equal(t, domain.TaskEventDeleted, event.Type)
+}
+
+// TestBroker_Removed .
+func TestBroker_Removed(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(10)
+	defer broker.Close()
+
+	undone := domain.TaskParam{Status: null.BoolFrom(false)}
+
+	sub, _, _ := broker.Subscribe(undone, 0)
+	defer sub.Close()
+
+	ctx := context.Background()
+
+	// the task is completed, it is moved out of the filter
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{
+		Type:     domain.TaskEventUpdated,
+		Task:     domain.Task{ID: 1, Status: true},
+		Previous: &domain.Task{ID: 1, Status: false},
+	}))
+	// the task has never matched the filter
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{
+		Type:     domain.TaskEventUpdated,
+		Task:     domain.Task{ID: 2, Status: true, Name: "renamed"},
+		Previous: &domain.Task{ID: 2, Status: true},
+	}))
+	// the previous task is unknown, e.g. updated by import
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventUpdated, Task: domain.Task{ID: 3, Status: true}}))
+
+	event := <-sub.C
+	assert.Equal(t, int64(1), event.ID)
+	assert.Equal(t, domain.TaskEventRemoved, event.Type)
+
+	event = <-sub.C
+	assert.Equal(t, int64(3), event.ID)
+	assert.Equal(t, domain.TaskEventRemoved, event.Type)
+
+	// the replay is delivered the same
+	resumed, replay, complete := broker.Subscribe(undone, 1)
+	defer resumed.Close()
+	assert.True(t, complete)
+	require.Len(t, replay, 1)
+	assert.Equal(t, int64(3), replay[0].ID)
+	assert.Equal(t, domain.TaskEventRemoved, replay[0].Type)
+
+	// the updated event is kept for the subscribers without filter
+	all, replay, _ := broker.Subscribe(domain.TaskParam{}, 1)
+	defer all.Close()
+	require.Len(t, replay, 2)
+	assert.Equal(t, domain.TaskEventUpdated, replay[0].Type)
+}
+
+// TestBroker_Replay .
+func TestBroker_Replay(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(3)
+	defer broker.Close()
+
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventCreated, Task: domain.Task{ID: int64(i)}}))
+	}
+
+	tests := []struct {
+		name             string
+		lastEventID      int64
+		expectedIDs      []int64
+		expectedComplete bool
+	}{
+		{
+			name:             "resume from buffered event",
+			lastEventID:      3,
+			expectedIDs:      []int64{4, 5},
+			expectedComplete: true,
+		},
+		{
+			name:             "resume right before the oldest buffered event",
+			lastEventID:      2,
+			expectedIDs:      []int64{3, 4, 5},
+			expectedComplete: true,
+		},
+		{
+			name:             "events have been evicted",
+			lastEventID:      1,
+			expectedIDs:      []int64{3, 4, 5},
+			expectedComplete: false,
+		},
+		{
+			name:             "unknown event id",
+			lastEventID:      100,
+			expectedIDs:      nil,
+			expectedComplete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, complete := broker.Subscribe(domain.TaskParam{}, tt.lastEventID)
+			defer sub.Close()
+
+			var ids []int64
+			for i := range replay {
+				ids = append(ids, replay[i].ID)
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedComplete, complete)
+		})
+	}
+}
+
+// TestBroker_Close .
+func TestBroker_Close(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(10)
+
+	sub, _, _ := broker.Subscribe(domain.TaskParam{}, 0)
+
+	broker.Close()
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	// closing twice should be safe
+	sub.Close()
+	broker.Close()
+
+	// subscribing a closed broker gets a closed subscription
+	sub, _, _ = broker.Subscribe(domain.TaskParam{}, 0)
+	_, ok = <-sub.C
+	assert.False(t, ok)
+}
+
+// TestBroker_Reset .
+func TestBroker_Reset(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(10)
+	defer broker.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventCreated, Task: domain.Task{ID: 1}}))
+
+	sub, _, _ := broker.Subscribe(domain.TaskParam{}, 0)
+
+	broker.Reset()
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventCreated, Task: domain.Task{ID: 2}}))
+
+	// resuming from the event before reset may miss events
+	_, replay, complete := broker.Subscribe(domain.TaskParam{}, 1)
+	assert.False(t, complete)
+	assert.Len(t, replay, 1)
+
+	// resuming from the event after reset is complete
+	_, replay, complete = broker.Subscribe(domain.TaskParam{}, 2)
+	assert.True(t, complete)
+	assert.Empty(t, replay)
+}
+
+// TestBroker_PublishWithID .
+func TestBroker_PublishWithID(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// instance a has received all events, instance b starts listening from the event 3
+	a, b := NewBroker(10), NewBroker(10)
+	defer a.Close()
+	defer b.Close()
+
+	for id := int64(1); id <= 5; id++ {
+		event := domain.TaskEvent{ID: id, Type: domain.TaskEventCreated, Task: domain.Task{ID: id}}
+		require.NoError(t, a.Publish(ctx, event))
+		if id >= 3 {
+			require.NoError(t, b.Publish(ctx, event))
+		}
+	}
+
+	// the duplicated event is discarded
+	require.NoError(t, b.Publish(ctx, domain.TaskEvent{ID: 4, Type: domain.TaskEventDeleted, Task: domain.Task{ID: 4}}))
+
+	tests := []struct {
+		name             string
+		lastEventID      int64
+		expectedIDs      []int64
+		expectedComplete bool
+	}{
+		{
+			name:             "resume the id issued by the other instance",
+			lastEventID:      3,
+			expectedIDs:      []int64{4, 5},
+			expectedComplete: true,
+		},
+		{
+			name:             "resume right before the first received event",
+			lastEventID:      2,
+			expectedIDs:      []int64{3, 4, 5},
+			expectedComplete: true,
+		},
+		{
+			name:             "the events before listening are unknown",
+			lastEventID:      1,
+			expectedIDs:      []int64{3, 4, 5},
+			expectedComplete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, complete := b.Subscribe(domain.TaskParam{}, tt.lastEventID)
+			defer sub.Close()
+
+			var ids []int64
+			for i := range replay {
+				ids = append(ids, replay[i].ID)
+				assert.Equal(t, domain.TaskEventCreated, replay[i].Type)
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedComplete, complete)
+		})
+	}
+
+	// the skipped ids may have been lost
+	require.NoError(t, b.Publish(ctx, domain.TaskEvent{ID: 8, Type: domain.TaskEventCreated, Task: domain.Task{ID: 8}}))
+
+	_, _, complete := b.Subscribe(domain.TaskParam{}, 5)
+	assert.False(t, complete)
+
+	_, replay, complete := b.Subscribe(domain.TaskParam{}, 7)
+	assert.True(t, complete)
+	assert.Len(t, replay, 1)
+}
+
+// TestBroker_PublishInProcess .
+func TestBroker_PublishInProcess(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	broker := NewBroker(10)
+	defer broker.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		require.NoError(t, broker.Publish(ctx, domain.TaskEvent{ID: id, Type: domain.TaskEventCreated, Task: domain.Task{ID: id}}))
+	}
+
+	sub, _, _ := broker.Subscribe(domain.TaskParam{}, 0)
+	defer sub.Close()
+
+	// the event published in-process while NOTIFY is failing, its id is assigned by the broker
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventUpdated, Task: domain.Task{ID: 1}}))
+	assert.Equal(t, int64(3), (<-sub.C).ID)
+
+	// the same id issued by Notifier is not discarded as duplicated
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{ID: 3, Type: domain.TaskEventCreated, Task: domain.Task{ID: 3}}))
+
+	got := <-sub.C
+	assert.Equal(t, int64(3), got.ID)
+	assert.Equal(t, domain.TaskEventCreated, got.Type)
+
+	// the in-process event is replaced, resuming from it should reload
+	_, replay, complete := broker.Subscribe(domain.TaskParam{}, 2)
+	assert.False(t, complete)
+	require.Len(t, replay, 1)
+	assert.Equal(t, domain.TaskEventCreated, replay[0].Type)
+
+	_, _, complete = broker.Subscribe(domain.TaskParam{}, 3)
+	assert.False(t, complete)
+
+	require.NoError(t, broker.Publish(ctx, domain.TaskEvent{ID: 4, Type: domain.TaskEventCreated, Task: domain.Task{ID: 4}}))
+
+	_, replay, complete = broker.Subscribe(domain.TaskParam{}, 4)
+	assert.True(t, complete)
+	assert.Empty(t, replay)
+}
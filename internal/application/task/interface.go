@@ -3,27 +3,77 @@ package task
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/tingchima/gogolook/internal/domain"
 )
 
 // Repository
 //
+// the tests of this package use their own mock, since the mocks package imports this package for WithTx
+//
 //go:generate mockgen -destination mocks/repository.go -package=mocks . Repository
+//go:generate mockgen -destination mock_repository_test.go -package=task -self_package=github.com/tingchima/gogolook/internal/application/task . Repository
 type Repository interface {
 	TaskRepository
 
 	// maybe other repositories
+
+	// 在交易中執行 fn, fn 回傳錯誤時回滾; 於 txRepo 中巢狀呼叫時使用 savepoint, 並沿用外層的隔離等級.
+	// 發生序列化失敗或死結時會重新執行 fn, 因此 fn 除了 txRepo 以外不應有副作用
+	WithTx(ctx context.Context, fn func(txRepo Repository) error, opts ...TxOption) error
+}
+
+// TxOptions of WithTx.
+type TxOptions struct {
+	// 隔離等級, 預設為資料庫的 read committed, repeatable read 與 serializable 可能發生序列化失敗
+	Isolation sql.IsolationLevel
+}
+
+// TxOption .
+type TxOption func(*TxOptions)
+
+// WithIsolation .
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
 }
 
 // TaskRepository .
 type TaskRepository interface {
 	// 列出任務
 	ListTasks(ctx context.Context, param domain.TaskParam) ([]domain.Task, error)
+	// 透過ID取得任務
+	GetTaskByID(ctx context.Context, id int64) (*domain.Task, error)
+	// 透過ID取得任務並鎖定至交易結束, 應於 WithTx 的 txRepo 中呼叫
+	LockTaskByID(ctx context.Context, id int64) (*domain.Task, error)
 	// 建立任務
 	CreateTask(ctx context.Context, param domain.Task) (*domain.Task, error)
 	// 修改任務
 	UpdateTask(ctx context.Context, param domain.Task) (*domain.Task, error)
 	// 透過ID刪除任務
 	DeleteTaskByID(ctx context.Context, id int64) error
+	// 統計未完成與已完成的任務數量
+	CountTasks(ctx context.Context) (domain.TaskCount, error)
+	// 逐筆讀取任務, 不會一次載入所有任務
+	IterateTasks(ctx context.Context, param domain.TaskParam, fn func(task domain.Task) error) error
+	// 透過外部ID列出任務
+	ListTasksByExternalIDs(ctx context.Context, externalIDs []string) ([]domain.Task, error)
+	// 依外部ID新增或更新任務, 全部成功或全部失敗
+	UpsertTasks(ctx context.Context, params []domain.Task) ([]domain.TaskUpsertResult, error)
+	// 複製任務中相對到期時間的提醒至另一任務, 應於 WithTx 的 txRepo 中呼叫
+	CopyOffsetReminders(ctx context.Context, fromTaskID int64, toTaskID int64) error
+}
+
+// EventPublisher .
+type EventPublisher interface {
+	// 發布任務異動事件
+	Publish(ctx context.Context, event domain.TaskEvent) error
+}
+
+// Notifier delivers the payload to the listeners of channel on every instance,
+// the "id" of payload is set to a number increasing in the order of delivery, shared by all instances.
+type Notifier interface {
+	Notify(ctx context.Context, channel string, payload []byte) error
 }
@@ -0,0 +1,217 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/tingchima/gogolook/internal/application/task (interfaces: Repository)
+
+// Package task is a generated GoMock package.
+package task
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/tingchima/gogolook/internal/domain"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CopyOffsetReminders mocks base method.
+func (m *MockRepository) CopyOffsetReminders(arg0 context.Context, arg1, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyOffsetReminders", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyOffsetReminders indicates an expected call of CopyOffsetReminders.
+func (mr *MockRepositoryMockRecorder) CopyOffsetReminders(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyOffsetReminders", reflect.TypeOf((*MockRepository)(nil).CopyOffsetReminders), arg0, arg1, arg2)
+}
+
+// CountTasks mocks base method.
+func (m *MockRepository) CountTasks(arg0 context.Context) (domain.TaskCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTasks", arg0)
+	ret0, _ := ret[0].(domain.TaskCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTasks indicates an expected call of CountTasks.
+func (mr *MockRepositoryMockRecorder) CountTasks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTasks", reflect.TypeOf((*MockRepository)(nil).CountTasks), arg0)
+}
+
+// CreateTask mocks base method.
+func (m *MockRepository) CreateTask(arg0 context.Context, arg1 domain.Task) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTask", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTask indicates an expected call of CreateTask.
+func (mr *MockRepositoryMockRecorder) CreateTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockRepository)(nil).CreateTask), arg0, arg1)
+}
+
+// DeleteTaskByID mocks base method.
+func (m *MockRepository) DeleteTaskByID(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskByID", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTaskByID indicates an expected call of DeleteTaskByID.
+func (mr *MockRepositoryMockRecorder) DeleteTaskByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskByID", reflect.TypeOf((*MockRepository)(nil).DeleteTaskByID), arg0, arg1)
+}
+
+// GetTaskByID mocks base method.
+func (m *MockRepository) GetTaskByID(arg0 context.Context, arg1 int64) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskByID", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaskByID indicates an expected call of GetTaskByID.
+func (mr *MockRepositoryMockRecorder) GetTaskByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskByID", reflect.TypeOf((*MockRepository)(nil).GetTaskByID), arg0, arg1)
+}
+
+// IterateTasks mocks base method.
+func (m *MockRepository) IterateTasks(arg0 context.Context, arg1 domain.TaskParam, arg2 func(domain.Task) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateTasks", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateTasks indicates an expected call of IterateTasks.
+func (mr *MockRepositoryMockRecorder) IterateTasks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateTasks", reflect.TypeOf((*MockRepository)(nil).IterateTasks), arg0, arg1, arg2)
+}
+
+// ListTasks mocks base method.
+func (m *MockRepository) ListTasks(arg0 context.Context, arg1 domain.TaskParam) ([]domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockRepositoryMockRecorder) ListTasks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockRepository)(nil).ListTasks), arg0, arg1)
+}
+
+// ListTasksByExternalIDs mocks base method.
+func (m *MockRepository) ListTasksByExternalIDs(arg0 context.Context, arg1 []string) ([]domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasksByExternalIDs", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasksByExternalIDs indicates an expected call of ListTasksByExternalIDs.
+func (mr *MockRepositoryMockRecorder) ListTasksByExternalIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasksByExternalIDs", reflect.TypeOf((*MockRepository)(nil).ListTasksByExternalIDs), arg0, arg1)
+}
+
+// LockTaskByID mocks base method.
+func (m *MockRepository) LockTaskByID(arg0 context.Context, arg1 int64) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockTaskByID", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockTaskByID indicates an expected call of LockTaskByID.
+func (mr *MockRepositoryMockRecorder) LockTaskByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockTaskByID", reflect.TypeOf((*MockRepository)(nil).LockTaskByID), arg0, arg1)
+}
+
+// UpdateTask mocks base method.
+func (m *MockRepository) UpdateTask(arg0 context.Context, arg1 domain.Task) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTask", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTask indicates an expected call of UpdateTask.
+func (mr *MockRepositoryMockRecorder) UpdateTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockRepository)(nil).UpdateTask), arg0, arg1)
+}
+
+// UpsertTasks mocks base method.
+func (m *MockRepository) UpsertTasks(arg0 context.Context, arg1 []domain.Task) ([]domain.TaskUpsertResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTasks", arg0, arg1)
+	ret0, _ := ret[0].([]domain.TaskUpsertResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertTasks indicates an expected call of UpsertTasks.
+func (mr *MockRepositoryMockRecorder) UpsertTasks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTasks", reflect.TypeOf((*MockRepository)(nil).UpsertTasks), arg0, arg1)
+}
+
+// WithTx mocks base method.
+func (m *MockRepository) WithTx(arg0 context.Context, arg1 func(Repository) error, arg2 ...TxOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithTx", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockRepositoryMockRecorder) WithTx(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockRepository)(nil).WithTx), varargs...)
+}
@@ -0,0 +1,136 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// EventChannel is the notification channel of task events shared by all instances.
+const EventChannel = "task_events"
+
+// NotifyPublisher publishes task events to every instance through Notifier,
+// each instance feeds the received events into its own Broker by HandleNotification.
+// The event ids are issued by Notifier, so that a Last-Event-ID is resumable on any instance.
+//
+// The events are published to fallback, usually the Broker of current instance, if Notifier fails,
+// so that the subscribers of current instance still receive them. The other instances miss them,
+// and their ids are assigned by fallback, which are not resumable on the other instances.
+type NotifyPublisher struct {
+	notifier Notifier
+	fallback EventPublisher
+}
+
+// NewNotifyPublisher .
+func NewNotifyPublisher(notifier Notifier, fallback EventPublisher) *NotifyPublisher {
+	return &NotifyPublisher{notifier: notifier, fallback: fallback}
+}
+
+// eventPayload is the wire format of task event, the id is set by Notifier.
+type eventPayload struct {
+	ID         int64                `json:"id,omitempty"`
+	Type       domain.TaskEventType `json:"type"`
+	TaskID     int64                `json:"task_id"`
+	Name       string               `json:"name"`
+	Status     bool                 `json:"status"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	OccurredAt time.Time            `json:"occurred_at"`
+
+	DueAt       null.Time `json:"due_at"`
+	RRule       string    `json:"rrule"`
+	Timezone    string    `json:"timezone"`
+	SeriesStart null.Time `json:"series_start"`
+	Priority    int       `json:"priority,omitempty"`
+	ExternalID  string    `json:"external_id,omitempty"`
+
+	// the fields of task before the update, which are matched by the subscriptions
+	Previous *previousPayload `json:"previous,omitempty"`
+}
+
+// previousPayload .
+type previousPayload struct {
+	Name   string `json:"name"`
+	Status bool   `json:"status"`
+}
+
+// Publish .
+func (p *NotifyPublisher) Publish(ctx context.Context, event domain.TaskEvent) error {
+
+	row := eventPayload{
+		Type:       event.Type,
+		TaskID:     event.Task.ID,
+		Name:       event.Task.Name,
+		Status:     event.Task.Status,
+		CreatedAt:  event.Task.CreatedAt,
+		UpdatedAt:  event.Task.UpdatedAt,
+		OccurredAt: event.OccurredAt,
+
+		DueAt:       event.Task.DueAt,
+		RRule:       event.Task.RRule,
+		Timezone:    event.Task.Timezone,
+		SeriesStart: event.Task.SeriesStart,
+		Priority:    event.Task.Priority,
+		ExternalID:  event.Task.ExternalID,
+	}
+
+	if event.Previous != nil {
+		row.Previous = &previousPayload{Name: event.Previous.Name, Status: event.Previous.Status}
+	}
+
+	payload, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	err = p.notifier.Notify(ctx, EventChannel, payload)
+	if err != nil {
+		slog.WarnContext(ctx, "notify task event fail, publish in-process", "type", event.Type, "task_id", event.Task.ID, "err", err)
+		return p.fallback.Publish(ctx, event)
+	}
+
+	return nil
+}
+
+// HandleNotification returns the notification handler which decodes the payload
+// and publishes it to the in-process publisher, like Broker.
+func HandleNotification(publisher EventPublisher) func(payload []byte) {
+	return func(payload []byte) {
+		var row eventPayload
+		if err := json.Unmarshal(payload, &row); err != nil {
+			slog.Error("decode task event notification fail", "err", err)
+			return
+		}
+
+		event := domain.TaskEvent{
+			ID:   row.ID,
+			Type: row.Type,
+			Task: domain.Task{
+				ID:        row.TaskID,
+				Name:      row.Name,
+				Status:    row.Status,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+
+				DueAt:       row.DueAt,
+				RRule:       row.RRule,
+				Timezone:    row.Timezone,
+				SeriesStart: row.SeriesStart,
+				Priority:    row.Priority,
+				ExternalID:  row.ExternalID,
+			},
+			OccurredAt: row.OccurredAt,
+		}
+
+		if row.Previous != nil {
+			event.Previous = &domain.Task{ID: row.TaskID, Name: row.Previous.Name, Status: row.Previous.Status}
+		}
+
+		_ = publisher.Publish(context.Background(), event)
+	}
+}
@@ -0,0 +1,151 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// loopbackNotifier delivers the payload to the handlers directly, like NOTIFY to the instances,
+// and sets the id like PostgresNotifier.
+type loopbackNotifier struct {
+	lastID   int64
+	handlers []func(payload []byte)
+}
+
+// Notify .
+func (n *loopbackNotifier) Notify(_ context.Context, channel string, payload []byte) error {
+	if channel != EventChannel {
+		return nil
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal(payload, &object); err != nil {
+		return err
+	}
+
+	n.lastID++
+	object["id"] = n.lastID
+
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	for i := range n.handlers {
+		n.handlers[i](payload)
+	}
+	return nil
+}
+
+// TestNotifyPublisher_Publish .
+func TestNotifyPublisher_Publish(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(10)
+	defer broker.Close()
+
+	publisher := NewNotifyPublisher(&loopbackNotifier{handlers: []func([]byte){HandleNotification(broker)}}, broker)
+
+	sub, _, _ := broker.Subscribe(domain.TaskParam{}, 0)
+	defer sub.Close()
+
+	event := domain.TaskEvent{
+		Type: domain.TaskEventUpdated,
+		Task: domain.Task{
+			ID:        1,
+			Name:      "買早餐",
+			Status:    true,
+			CreatedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+
+			DueAt:       null.TimeFrom(time.Date(2024, 2, 1, 1, 0, 0, 0, time.UTC)),
+			RRule:       "FREQ=MONTHLY",
+			Timezone:    "Asia/Taipei",
+			SeriesStart: null.TimeFrom(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)),
+		},
+		OccurredAt: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+	}
+
+	err := publisher.Publish(context.Background(), event)
+	require.NoError(t, err)
+
+	got := <-sub.C
+
+	event.ID = 1
+	assert.Equal(t, event, got)
+}
+
+// TestNotifyPublisher_Resume .
+func TestNotifyPublisher_Resume(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	a, b := NewBroker(10), NewBroker(10)
+	defer a.Close()
+	defer b.Close()
+
+	notifier := &loopbackNotifier{handlers: []func([]byte){HandleNotification(a)}}
+	publisher := NewNotifyPublisher(notifier, a)
+
+	for i := int64(1); i <= 5; i++ {
+		// instance b starts listening from the event 3
+		if i == 3 {
+			notifier.handlers = append(notifier.handlers, HandleNotification(b))
+		}
+		require.NoError(t, publisher.Publish(ctx, domain.TaskEvent{Type: domain.TaskEventCreated, Task: domain.Task{ID: i}}))
+	}
+
+	// the id received from instance a is resumed on instance b
+	sub, replay, complete := b.Subscribe(domain.TaskParam{}, 4)
+	defer sub.Close()
+
+	assert.True(t, complete)
+	require.Len(t, replay, 1)
+	assert.Equal(t, int64(5), replay[0].ID)
+	assert.Equal(t, int64(5), replay[0].Task.ID)
+
+	// the events before b started are not replayed as complete
+	_, _, complete = b.Subscribe(domain.TaskParam{}, 1)
+	assert.False(t, complete)
+}
+
+// failingNotifier .
+type failingNotifier struct{}
+
+// Notify .
+func (failingNotifier) Notify(context.Context, string, []byte) error {
+	return errors.New("connection refused")
+}
+
+// TestNotifyPublisher_Fallback .
+func TestNotifyPublisher_Fallback(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(10)
+	defer broker.Close()
+
+	publisher := NewNotifyPublisher(failingNotifier{}, broker)
+
+	sub, _, _ := broker.Subscribe(domain.TaskParam{}, 0)
+	defer sub.Close()
+
+	event := domain.TaskEvent{Type: domain.TaskEventCreated, Task: domain.Task{ID: 1, Name: "買早餐"}}
+
+	err := publisher.Publish(context.Background(), event)
+	require.NoError(t, err)
+
+	got := <-sub.C
+
+	event.ID = 1
+	assert.Equal(t, event, got)
+}
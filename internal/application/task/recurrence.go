@@ -0,0 +1,142 @@
+// Package task provides
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+const (
+	// DefaultOccurrencePreviewCount .
+	DefaultOccurrencePreviewCount = 5
+	// MaxOccurrencePreviewCount .
+	MaxOccurrencePreviewCount = 100
+)
+
+// loadTimezone returns UTC for the empty name.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// buildRRule builds the rule of task, the occurrences are computed in the time zone of task,
+// so that the wall clock time is kept across DST transitions.
+func buildRRule(task domain.Task) (*rrule.RRule, error) {
+
+	loc, err := loadTimezone(task.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := strings.TrimPrefix(strings.TrimSpace(task.RRule), "RRULE:")
+
+	option, err := rrule.StrToROptionInLocation(rule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	start := task.SeriesStart
+	if !start.Valid {
+		start = task.DueAt
+	}
+	option.Dtstart = start.Time.In(loc)
+
+	return rrule.NewRRule(*option)
+}
+
+// validateRecurrence .
+func validateRecurrence(task domain.Task) error {
+
+	if _, err := loadTimezone(task.Timezone); err != nil {
+		msg := fmt.Sprintf("the timezone %q is invalid", task.Timezone)
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(msg))
+	}
+
+	if !task.IsRecurring() {
+		return nil
+	}
+
+	if !task.DueAt.Valid {
+		msg := "the due_at is required for recurring task"
+		return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	if strings.Contains(strings.ToUpper(task.RRule), "DTSTART") {
+		msg := "the rrule should not contain DTSTART, it is the due_at of task"
+		return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	if _, err := buildRRule(task); err != nil {
+		msg := fmt.Sprintf("the rrule is invalid: %s", err.Error())
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(msg))
+	}
+
+	return nil
+}
+
+// nextOccurrence returns the first occurrence after the due time of task,
+// ok is false if the series has ended.
+func nextOccurrence(task domain.Task) (next time.Time, ok bool, err error) {
+
+	rule, err := buildRRule(task)
+	if err != nil {
+		return time.Time{}, false, common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	next = rule.After(task.DueAt.Time, false)
+
+	return next, !next.IsZero(), nil
+}
+
+// upcomingOccurrences returns at most count occurrences from the due time of task, inclusive.
+func upcomingOccurrences(task domain.Task, count int) ([]time.Time, error) {
+
+	rule, err := buildRRule(task)
+	if err != nil {
+		return nil, common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	occurrences := make([]time.Time, 0, count)
+
+	next := rule.Iterator()
+	for len(occurrences) < count {
+		occurrence, ok := next()
+		if !ok {
+			break
+		}
+		if occurrence.Before(task.DueAt.Time) {
+			continue
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, nil
+}
+
+// nextInstance returns the next task of the series after the task is completed.
+func nextInstance(task domain.Task, dueAt time.Time) domain.Task {
+
+	seriesStart := task.SeriesStart
+	if !seriesStart.Valid {
+		seriesStart = task.DueAt
+	}
+
+	return domain.Task{
+		Name:        task.Name,
+		Status:      false,
+		DueAt:       null.TimeFrom(dueAt),
+		RRule:       task.RRule,
+		Timezone:    task.Timezone,
+		SeriesStart: seriesStart,
+		Priority:    task.Priority,
+	}
+}
@@ -0,0 +1,254 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestValidateRecurrence .
+func TestValidateRecurrence(t *testing.T) {
+	t.Parallel()
+
+	dueAt := null.TimeFrom(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name    string
+		task    domain.Task
+		wantErr bool
+	}{
+		{
+			name: "not recurring",
+			task: domain.Task{Name: "買晚餐"},
+		},
+		{
+			name: "monthly",
+			task: domain.Task{DueAt: dueAt, RRule: "FREQ=MONTHLY;BYMONTHDAY=1", Timezone: "Asia/Taipei"},
+		},
+		{
+			name: "with RRULE prefix",
+			task: domain.Task{DueAt: dueAt, RRule: "RRULE:FREQ=WEEKLY;COUNT=3"},
+		},
+		{
+			name:    "missing due_at",
+			task:    domain.Task{RRule: "FREQ=DAILY"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid rrule",
+			task:    domain.Task{DueAt: dueAt, RRule: "FREQ=SOMETIMES"},
+			wantErr: true,
+		},
+		{
+			name:    "with DTSTART",
+			task:    domain.Task{DueAt: dueAt, RRule: "DTSTART=20240101T090000Z;FREQ=DAILY"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timezone",
+			task:    domain.Task{DueAt: dueAt, RRule: "FREQ=DAILY", Timezone: "Mars/Olympus"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRecurrence(tt.task)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, common.IsErrCode(err, common.ErrCodeInvalidParameter))
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestNextOccurrence .
+func TestNextOccurrence(t *testing.T) {
+	t.Parallel()
+
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		task         domain.Task
+		expectedNext time.Time
+		expectedOK   bool
+	}{
+		{
+			name: "keep wall clock time across DST",
+			task: domain.Task{
+				DueAt:    null.TimeFrom(time.Date(2024, 3, 30, 9, 0, 0, 0, london)),
+				RRule:    "FREQ=DAILY",
+				Timezone: "Europe/London",
+			},
+			// 2024-03-31 is the first day of BST, 09:00 BST is 08:00 UTC
+			expectedNext: time.Date(2024, 3, 31, 8, 0, 0, 0, time.UTC),
+			expectedOK:   true,
+		},
+		{
+			name: "monthly on the last day",
+			task: domain.Task{
+				DueAt: null.TimeFrom(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)),
+				RRule: "FREQ=MONTHLY;BYMONTHDAY=-1",
+			},
+			expectedNext: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			expectedOK:   true,
+		},
+		{
+			name: "count is counted from the series start",
+			task: domain.Task{
+				DueAt:       null.TimeFrom(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)),
+				SeriesStart: null.TimeFrom(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				RRule:       "FREQ=DAILY;COUNT=3",
+			},
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok, err := nextOccurrence(tt.task)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			if tt.expectedOK {
+				assert.True(t, tt.expectedNext.Equal(next), "expected %s, got %s", tt.expectedNext, next)
+			}
+		})
+	}
+}
+
+// TestUpcomingOccurrences .
+func TestUpcomingOccurrences(t *testing.T) {
+	t.Parallel()
+
+	task := domain.Task{
+		DueAt:       null.TimeFrom(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)),
+		SeriesStart: null.TimeFrom(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)),
+		RRule:       "FREQ=MONTHLY;COUNT=5",
+	}
+
+	got, err := upcomingOccurrences(task, 10)
+	require.NoError(t, err)
+
+	require.Len(t, got, 3)
+	assert.True(t, task.DueAt.Time.Equal(got[0]))
+	assert.True(t, time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC).Equal(got[2]))
+}
+
+// TestTaskService_UpdateTask_CompleteOccurrence .
+func TestTaskService_UpdateTask_CompleteOccurrence(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	task := domain.Task{
+		ID:          1,
+		Name:        "繳房租",
+		DueAt:       null.TimeFrom(time.Date(2024, 1, 5, 1, 0, 0, 0, time.UTC)),
+		RRule:       "FREQ=MONTHLY",
+		Timezone:    "Asia/Taipei",
+		SeriesStart: null.TimeFrom(time.Date(2024, 1, 5, 1, 0, 0, 0, time.UTC)),
+	}
+
+	mock := buildMockService(ctrl)
+
+	// the task is locked, the update and the next instance are in one transaction
+	mock.expectTx()
+	mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), task.ID).Return(&task, nil)
+
+	mock.postgresRepo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, param domain.Task) (*domain.Task, error) {
+			// the recurrence moves to the next instance
+			assert.True(t, param.Status)
+			assert.False(t, param.IsRecurring())
+			return &param, nil
+		},
+	)
+
+	mock.postgresRepo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, param domain.Task) (*domain.Task, error) {
+			assert.Equal(t, task.Name, param.Name)
+			assert.False(t, param.Status)
+			assert.Equal(t, task.RRule, param.RRule)
+			assert.Equal(t, task.Timezone, param.Timezone)
+			assert.True(t, task.SeriesStart.Time.Equal(param.SeriesStart.Time))
+			assert.True(t, time.Date(2024, 2, 5, 1, 0, 0, 0, time.UTC).Equal(param.DueAt.Time))
+			param.ID = 2
+			return &param, nil
+		},
+	)
+
+	// the reminders relative to the due time are copied to the next instance in the transaction
+	mock.postgresRepo.EXPECT().CopyOffsetReminders(gomock.Any(), task.ID, int64(2)).Return(nil)
+
+	s := buildService(mock)
+
+	got, err := s.UpdateTask(context.Background(), domain.Task{ID: task.ID, Name: task.Name, Status: true})
+	require.NoError(t, err)
+	assert.True(t, got.Status)
+}
+
+// TestTaskService_UpdateTask_RetryReadsAgain .
+func TestTaskService_UpdateTask_RetryReadsAgain(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	open := domain.Task{
+		ID:          1,
+		Name:        "繳房租",
+		DueAt:       null.TimeFrom(time.Date(2024, 1, 5, 1, 0, 0, 0, time.UTC)),
+		RRule:       "FREQ=MONTHLY",
+		Timezone:    "Asia/Taipei",
+		SeriesStart: null.TimeFrom(time.Date(2024, 1, 5, 1, 0, 0, 0, time.UTC)),
+	}
+
+	// the concurrent request has completed the occurrence when the transaction is retried
+	completed := open
+	completed.Status = true
+	completed.RRule = ""
+
+	mock := buildMockService(ctrl)
+
+	mock.postgresRepo.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(txRepo Repository) error, _ ...TxOption) error {
+			_ = fn(mock.postgresRepo)
+			return fn(mock.postgresRepo)
+		},
+	)
+
+	gomock.InOrder(
+		mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), open.ID).Return(&open, nil),
+		mock.postgresRepo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(nil, errors.New("serialization failure")),
+		mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), open.ID).Return(&completed, nil),
+		mock.postgresRepo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, param domain.Task) (*domain.Task, error) {
+				return &param, nil
+			},
+		),
+	)
+
+	// the next instance is not generated again
+	mock.postgresRepo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Times(0)
+
+	s := buildService(mock)
+
+	got, err := s.UpdateTask(context.Background(), domain.Task{ID: open.ID, Name: open.Name, Status: true})
+	require.NoError(t, err)
+	assert.True(t, got.Status)
+}
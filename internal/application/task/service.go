@@ -1,18 +1,42 @@
 // Package task provides
 package task
 
+import "go.opentelemetry.io/otel"
+
+// tracer .
+var tracer = otel.Tracer("github.com/tingchima/gogolook/internal/application/task")
+
 type Service struct {
 	postgresRepo Repository
+	broker       *Broker
+	publisher    EventPublisher
 }
 
 // ServiceParam .
 type ServiceParam struct {
 	PostgresRepo Repository
+	Broker       *Broker
+	// Publisher publishes the task events after mutations, default is Broker.
+	// Use NotifyPublisher to propagate the events across instances.
+	Publisher EventPublisher
 }
 
 // NewService .
 func NewService(param ServiceParam) *Service {
+
+	broker := param.Broker
+	if broker == nil {
+		broker = NewBroker(DefaultBrokerReplaySize)
+	}
+
+	var publisher EventPublisher = broker
+	if param.Publisher != nil {
+		publisher = param.Publisher
+	}
+
 	return &Service{
 		postgresRepo: param.PostgresRepo,
+		broker:       broker,
+		publisher:    publisher,
 	}
 }
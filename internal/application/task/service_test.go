@@ -2,10 +2,10 @@
 package task
 
 import (
+	"context"
 	"testing"
 
 	"github.com/golang/mock/gomock"
-	"github.com/tingchima/gogolook/internal/application/task/mocks"
 )
 
 func TestMain(m *testing.M) {
@@ -14,14 +14,14 @@ func TestMain(m *testing.M) {
 
 // mockService .
 type mockService struct {
-	postgresRepo *mocks.MockRepository
+	postgresRepo *MockRepository
 }
 
 // buildMockService .
 func buildMockService(ctrl *gomock.Controller) mockService {
 
 	return mockService{
-		postgresRepo: mocks.NewMockRepository(ctrl),
+		postgresRepo: NewMockRepository(ctrl),
 	}
 }
 
@@ -32,3 +32,12 @@ func buildService(param mockService) *Service {
 		PostgresRepo: param.postgresRepo,
 	})
 }
+
+// expectTx runs fn of WithTx with the mock repository itself.
+func (m mockService) expectTx() {
+	m.postgresRepo.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(txRepo Repository) error, _ ...TxOption) error {
+			return fn(m.postgresRepo)
+		},
+	)
+}
@@ -0,0 +1,183 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// MaxImportRows is the maximum number of rows of an import.
+const MaxImportRows = 10000
+
+// maxTaskFieldLength is the length of the varchar columns of task.
+const maxTaskFieldLength = 255
+
+// 匯出任務, 逐筆交給 fn 輸出
+func (s *Service) ExportTasks(ctx context.Context, param domain.TaskParam, fn func(task domain.Task) error) error {
+
+	ctx, span := tracer.Start(ctx, "task.Service.ExportTasks")
+	defer span.End()
+
+	return s.postgresRepo.IterateTasks(ctx, param, fn)
+}
+
+// 匯入任務, 有外部ID且已存在的任務會被更新, 其餘新增; 任一列驗證失敗則全部不匯入.
+// dryRun 只驗證並回報每一列將執行的動作, 不會寫入
+func (s *Service) ImportTasks(ctx context.Context, rows []domain.TaskImportRow, dryRun bool) ([]domain.TaskImportResult, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.ImportTasks")
+	defer span.End()
+
+	// the existing tasks decide the actions of rows, the replicas may lag behind
+	ctx = common.WithPrimaryRead(ctx)
+
+	if len(rows) == 0 {
+		msg := "there is no task to import"
+		return nil, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	if len(rows) > MaxImportRows {
+		msg := fmt.Sprintf("too many rows, at most %d rows", MaxImportRows)
+		return nil, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	if details := validateImportRows(rows); len(details) > 0 {
+		msg := "some rows are invalid"
+		return nil, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg), common.WithDetail(details))
+	}
+
+	tasks := make([]domain.Task, len(rows))
+
+	for i := range rows {
+		tasks[i] = rows[i].Task
+		tasks[i].ID = 0
+		tasks[i].SeriesStart.Valid = false
+		if tasks[i].IsRecurring() {
+			tasks[i].SeriesStart = tasks[i].DueAt
+		}
+	}
+
+	if dryRun {
+		return s.planImport(ctx, rows, tasks)
+	}
+
+	upserted, err := s.postgresRepo.UpsertTasks(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.TaskImportResult, len(upserted))
+
+	for i := range upserted {
+		action, eventType := domain.TaskImportUpdate, domain.TaskEventUpdated
+		if upserted[i].Created {
+			action, eventType = domain.TaskImportCreate, domain.TaskEventCreated
+		}
+
+		results[i] = domain.TaskImportResult{
+			Row:    rows[i].Row,
+			Action: action,
+			Task:   upserted[i].Task,
+		}
+
+		s.publishTaskEvent(ctx, eventType, upserted[i].Task)
+	}
+
+	return results, nil
+}
+
+// planImport decides the action of each row without writing.
+func (s *Service) planImport(ctx context.Context, rows []domain.TaskImportRow, tasks []domain.Task) ([]domain.TaskImportResult, error) {
+
+	var externalIDs []string
+
+	for i := range tasks {
+		if tasks[i].ExternalID != "" {
+			externalIDs = append(externalIDs, tasks[i].ExternalID)
+		}
+	}
+
+	existingTasks, err := s.postgresRepo.ListTasksByExternalIDs(ctx, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]domain.Task, len(existingTasks))
+	for i := range existingTasks {
+		existing[existingTasks[i].ExternalID] = existingTasks[i]
+	}
+
+	results := make([]domain.TaskImportResult, len(tasks))
+
+	for i := range tasks {
+		results[i] = domain.TaskImportResult{
+			Row:    rows[i].Row,
+			Action: domain.TaskImportCreate,
+			Task:   tasks[i],
+		}
+
+		if task, ok := existing[tasks[i].ExternalID]; ok && tasks[i].ExternalID != "" {
+			results[i].Action = domain.TaskImportUpdate
+			results[i].Task.ID = task.ID
+		}
+	}
+
+	return results, nil
+}
+
+// validateImportRows returns the details of invalid rows, "row N: field: message".
+func validateImportRows(rows []domain.TaskImportRow) []any {
+
+	var details []any
+
+	addDetail := func(row int, field string, msg string) {
+		details = append(details, fmt.Sprintf("row %d: %s: %s", row, field, msg))
+	}
+
+	seenExternalIDs := make(map[string]int, len(rows))
+
+	for i := range rows {
+		row, task := rows[i].Row, rows[i].Task
+
+		name := strings.TrimSpace(task.Name)
+		switch {
+		case name == "":
+			addDetail(row, "name", "is required")
+		case utf8.RuneCountInString(task.Name) > maxTaskFieldLength:
+			addDetail(row, "name", fmt.Sprintf("should be at most %d characters", maxTaskFieldLength))
+		}
+
+		if task.ExternalID != "" {
+			if utf8.RuneCountInString(task.ExternalID) > maxTaskFieldLength {
+				addDetail(row, "external_id", fmt.Sprintf("should be at most %d characters", maxTaskFieldLength))
+			}
+
+			if firstRow, ok := seenExternalIDs[task.ExternalID]; ok {
+				addDetail(row, "external_id", fmt.Sprintf("is duplicated with row %d", firstRow))
+			} else {
+				seenExternalIDs[task.ExternalID] = row
+			}
+		}
+
+		if task.Priority < domain.TaskPriorityUndefined || task.Priority > domain.TaskPriorityLowest {
+			addDetail(row, "priority", fmt.Sprintf("should be between %d and %d", domain.TaskPriorityUndefined, domain.TaskPriorityLowest))
+		}
+
+		if err := validateRecurrence(task); err != nil {
+			var domainErr *common.Error
+			if common.AsErr(err, &domainErr) {
+				addDetail(row, "rrule", domainErr.ClientMsg())
+			} else {
+				addDetail(row, "rrule", err.Error())
+			}
+		}
+	}
+
+	return details
+}
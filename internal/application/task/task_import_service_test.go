@@ -0,0 +1,142 @@
+// Package task provides
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestTaskService_ImportTasks .
+func TestTaskService_ImportTasks(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dueAt := null.TimeFrom(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	rows := []domain.TaskImportRow{
+		{Row: 1, Task: domain.Task{Name: "new task"}},
+		{Row: 2, Task: domain.Task{Name: "existing task", ExternalID: "ext-1", DueAt: dueAt, RRule: "FREQ=DAILY"}},
+	}
+
+	tests := []struct {
+		name            string
+		rows            []domain.TaskImportRow
+		dryRun          bool
+		wantErr         bool
+		expectedErrCode common.ErrCode
+		expectedDetails []string
+		expectedActions []domain.TaskImportAction
+		setupService    func(t *testing.T) *Service
+	}{
+		{
+			name: "success",
+			rows: rows,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().UpsertTasks(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, tasks []domain.Task) ([]domain.TaskUpsertResult, error) {
+						require.Len(t, tasks, 2)
+						assert.False(t, tasks[0].SeriesStart.Valid)
+						assert.Equal(t, dueAt, tasks[1].SeriesStart)
+
+						return []domain.TaskUpsertResult{
+							{Task: domain.Task{ID: 1, Name: tasks[0].Name}, Created: true},
+							{Task: domain.Task{ID: 2, Name: tasks[1].Name, ExternalID: "ext-1"}, Created: false},
+						}, nil
+					})
+
+				return buildService(mock)
+			},
+			expectedActions: []domain.TaskImportAction{domain.TaskImportCreate, domain.TaskImportUpdate},
+		},
+		{
+			name:   "dry run",
+			rows:   rows,
+			dryRun: true,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().ListTasksByExternalIDs(gomock.Any(), []string{"ext-1"}).
+					Return([]domain.Task{{ID: 2, ExternalID: "ext-1"}}, nil)
+
+				return buildService(mock)
+			},
+			expectedActions: []domain.TaskImportAction{domain.TaskImportCreate, domain.TaskImportUpdate},
+		},
+		{
+			name: "invalid rows",
+			rows: []domain.TaskImportRow{
+				{Row: 1, Task: domain.Task{Name: " ", ExternalID: "ext-1"}},
+				{Row: 2, Task: domain.Task{Name: strings.Repeat("a", 256), ExternalID: "ext-1"}},
+				{Row: 3, Task: domain.Task{Name: "task", RRule: "FREQ=DAILY"}},
+			},
+			setupService: func(t *testing.T) *Service {
+				return buildService(buildMockService(ctrl))
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+			expectedDetails: []string{
+				"row 1: name: is required",
+				"row 2: name: should be at most 255 characters",
+				"row 2: external_id: is duplicated with row 1",
+				"row 3: rrule: the due_at is required for recurring task",
+			},
+		},
+		{
+			name: "internal server error",
+			rows: rows,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				err := common.NewError(common.ErrCodeInternalProcess, errors.New("mock db server error"))
+
+				mock.postgresRepo.EXPECT().UpsertTasks(gomock.Any(), gomock.Any()).Return(nil, err)
+
+				return buildService(mock)
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInternalProcess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setupService(t)
+
+			got, err := s.ImportTasks(context.Background(), tt.rows, tt.dryRun)
+			if tt.wantErr {
+				require.Error(t, err)
+
+				var domainErr *common.Error
+				assert.True(t, common.AsErr(err, &domainErr))
+				assert.True(t, common.IsErrCode(err, tt.expectedErrCode))
+
+				for i, detail := range tt.expectedDetails {
+					assert.Equal(t, detail, domainErr.DetailMsg()[i])
+				}
+
+			} else {
+				require.NoError(t, err)
+				require.Len(t, got, len(tt.expectedActions))
+
+				for i := range got {
+					assert.Equal(t, tt.rows[i].Row, got[i].Row)
+					assert.Equal(t, tt.expectedActions[i], got[i].Action)
+				}
+			}
+		})
+	}
+}
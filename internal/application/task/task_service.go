@@ -3,36 +3,427 @@ package task
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
 )
 
 // 列出任務
 func (s *Service) ListTasks(ctx context.Context, param domain.TaskParam) ([]domain.Task, error) {
 
+	ctx, span := tracer.Start(ctx, "task.Service.ListTasks")
+	defer span.End()
+
 	return s.postgresRepo.ListTasks(ctx, param)
 }
 
+// 透過ID取得任務
+func (s *Service) GetTaskByID(ctx context.Context, id int64) (*domain.Task, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.GetTaskByID")
+	defer span.End()
+
+	return s.postgresRepo.GetTaskByID(ctx, id)
+}
+
+// 統計未完成與已完成的任務數量
+func (s *Service) CountTasks(ctx context.Context) (domain.TaskCount, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.CountTasks")
+	defer span.End()
+
+	return s.postgresRepo.CountTasks(ctx)
+}
+
 // 建立任務
 func (s *Service) CreateTask(ctx context.Context, param domain.Task) (*domain.Task, error) {
 
-	return s.postgresRepo.CreateTask(ctx, param)
+	ctx, span := tracer.Start(ctx, "task.Service.CreateTask")
+	defer span.End()
+
+	err := validatePriority(param)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRecurrence(param)
+	if err != nil {
+		return nil, err
+	}
+
+	if param.IsRecurring() {
+		param.SeriesStart = param.DueAt
+	}
+
+	createdTask, err := s.postgresRepo.CreateTask(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishTaskEvent(ctx, domain.TaskEventCreated, *createdTask)
+
+	return createdTask, nil
 }
 
 // 修改任務
 func (s *Service) UpdateTask(ctx context.Context, param domain.Task) (*domain.Task, error) {
 
+	ctx, span := tracer.Start(ctx, "task.Service.UpdateTask")
+	defer span.End()
+
 	// update task
 	// if task is not exist, should return not found error
 
-	return s.postgresRepo.UpdateTask(ctx, param)
+	return s.modifyTask(ctx, param.ID, func(task domain.Task) (domain.Task, error) {
+		task.Name = param.Name
+		task.Status = param.Status
+		return task, nil
+	})
+}
+
+// Precondition checks the current task in the transaction of modification,
+// e.g. the ETag of If-Match, the task is locked until the modification is saved.
+type Precondition func(task domain.Task) error
+
+// 取代任務的所有欄位, 外部ID與建立時間除外
+func (s *Service) ReplaceTask(ctx context.Context, param domain.Task, preconditions ...Precondition) (*domain.Task, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.ReplaceTask")
+	defer span.End()
+
+	err := validatePriority(param)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRecurrence(param)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.modifyTask(ctx, param.ID, func(task domain.Task) (domain.Task, error) {
+		if err := checkPreconditions(task, preconditions); err != nil {
+			return task, err
+		}
+
+		updates := task
+		updates.Name = param.Name
+		updates.Status = param.Status
+		updates.DueAt = param.DueAt
+		updates.Priority = param.Priority
+		updates.RRule = param.RRule
+		updates.Timezone = param.Timezone
+
+		// a changed series starts again from the due time
+		seriesChanged := updates.RRule != task.RRule || updates.Timezone != task.Timezone || !task.SeriesStart.Valid
+		switch {
+		case !updates.IsRecurring():
+			updates.SeriesStart = null.Time{}
+		case seriesChanged:
+			updates.SeriesStart = updates.DueAt
+		}
+
+		return updates, nil
+	})
+}
+
+// modifyTask locks the task and saves the modification of it in a transaction, so that the concurrent
+// modifications of task are serialized. modify is run again if the transaction is retried, and the
+// events are published after the commit.
+func (s *Service) modifyTask(ctx context.Context, id int64, modify func(task domain.Task) (domain.Task, error)) (*domain.Task, error) {
+
+	var previousTask, updatedTask, createdTask *domain.Task
+
+	err := s.postgresRepo.WithTx(ctx, func(txRepo Repository) error {
+		task, err := txRepo.LockTaskByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		previousTask = task
+
+		updates, err := modify(*task)
+		if err != nil {
+			return err
+		}
+
+		updatedTask, createdTask, err = s.applyUpdate(ctx, txRepo, *task, updates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the subscribers are told when the task is moved out of their filters
+	s.publishEvent(ctx, domain.TaskEvent{Type: domain.TaskEventUpdated, Task: *updatedTask, Previous: previousTask})
+	if createdTask != nil {
+		s.publishTaskEvent(ctx, domain.TaskEventCreated, *createdTask)
+	}
+
+	return updatedTask, nil
+}
+
+// applyUpdate saves the updates of task by txRepo, completing an occurrence of series generates
+// the next instance with the reminders relative to the due time, which is returned as created.
+func (s *Service) applyUpdate(ctx context.Context, txRepo Repository, task domain.Task, updates domain.Task) (updated *domain.Task, created *domain.Task, err error) {
+
+	// the recurrence moves to the next instance so that it will not be generated twice
+	var next *domain.Task
+
+	if !task.Status && updates.Status && updates.IsRecurring() {
+		dueAt, ok, err := nextOccurrence(updates)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ok {
+			instance := nextInstance(updates, dueAt)
+			next = &instance
+		}
+
+		updates.RRule = ""
+	}
+
+	updated, err = txRepo.UpdateTask(ctx, updates)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if next == nil {
+		return updated, nil, nil
+	}
+
+	created, err = txRepo.CreateTask(ctx, *next)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the reminders at absolute time only belong to the completed occurrence
+	err = txRepo.CopyOffsetReminders(ctx, task.ID, created.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return updated, created, nil
+}
+
+// checkPreconditions .
+func checkPreconditions(task domain.Task, preconditions []Precondition) error {
+	for i := range preconditions {
+		if err := preconditions[i](task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 透過外部ID取得任務
+func (s *Service) GetTaskByExternalID(ctx context.Context, externalID string) (*domain.Task, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.GetTaskByExternalID")
+	defer span.End()
+
+	tasks, err := s.postgresRepo.ListTasksByExternalIDs(ctx, []string{externalID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		err := domain.ErrNotFoundTask
+		return nil, common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+	}
+
+	return &tasks[0], nil
 }
 
 // 透過ID刪除任務
-func (s *Service) DeleteTaskByID(ctx context.Context, id int64) error {
+func (s *Service) DeleteTaskByID(ctx context.Context, id int64, preconditions ...Precondition) error {
+
+	ctx, span := tracer.Start(ctx, "task.Service.DeleteTaskByID")
+	defer span.End()
 
 	// delete task by id
 	// if task is not exist, should return not found error
 
-	return s.postgresRepo.DeleteTaskByID(ctx, id)
+	var err error
+	if len(preconditions) == 0 {
+		err = s.postgresRepo.DeleteTaskByID(ctx, id)
+	} else {
+		err = s.postgresRepo.WithTx(ctx, func(txRepo Repository) error {
+			task, err := txRepo.LockTaskByID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if err = checkPreconditions(*task, preconditions); err != nil {
+				return err
+			}
+
+			return txRepo.DeleteTaskByID(ctx, id)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	s.publishTaskEvent(ctx, domain.TaskEventDeleted, domain.Task{ID: id})
+
+	return nil
+}
+
+// 設定任務的重複規則, 並以 dueAt 作為系列的起始時間
+func (s *Service) SetRecurrence(ctx context.Context, id int64, dueAt time.Time, rule string, timezone string) (*domain.Task, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.SetRecurrence")
+	defer span.End()
+
+	return s.modifyTask(ctx, id, func(task domain.Task) (domain.Task, error) {
+		task.DueAt = null.TimeFrom(dueAt)
+		task.RRule = rule
+		task.Timezone = timezone
+		task.SeriesStart = task.DueAt
+
+		return task, validateRecurrence(task)
+	})
+}
+
+// 預覽重複任務接下來的發生時間, 包含目前的到期時間
+func (s *Service) PreviewOccurrences(ctx context.Context, id int64, count int) ([]time.Time, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.PreviewOccurrences")
+	defer span.End()
+
+	task, err := s.getRecurringTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpcomingOccurrences(*task, count)
+}
+
+// 取得已載入任務接下來的發生時間, 包含目前的到期時間, 不重複的任務沒有發生時間
+func (s *Service) UpcomingOccurrences(task domain.Task, count int) ([]time.Time, error) {
+
+	if !task.IsRecurring() {
+		return nil, nil
+	}
+
+	if count <= 0 {
+		count = DefaultOccurrencePreviewCount
+	}
+	if count > MaxOccurrencePreviewCount {
+		count = MaxOccurrencePreviewCount
+	}
+
+	return upcomingOccurrences(task, count)
+}
+
+// 跳過重複任務目前的發生時間, 到期時間移至下一次, 若已無下一次則停止重複
+func (s *Service) SkipOccurrence(ctx context.Context, id int64) (*domain.Task, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.SkipOccurrence")
+	defer span.End()
+
+	return s.modifyTask(ctx, id, func(task domain.Task) (domain.Task, error) {
+		err := requireRecurring(task)
+		if err != nil {
+			return task, err
+		}
+
+		dueAt, ok, err := nextOccurrence(task)
+		if err != nil {
+			return task, err
+		}
+
+		if ok {
+			task.DueAt = null.TimeFrom(dueAt)
+		} else {
+			task.RRule = ""
+		}
+
+		return task, nil
+	})
+}
+
+// 停止重複任務, 保留目前的任務
+func (s *Service) StopRecurrence(ctx context.Context, id int64) (*domain.Task, error) {
+
+	ctx, span := tracer.Start(ctx, "task.Service.StopRecurrence")
+	defer span.End()
+
+	return s.modifyTask(ctx, id, func(task domain.Task) (domain.Task, error) {
+		err := requireRecurring(task)
+		if err != nil {
+			return task, err
+		}
+
+		task.RRule = ""
+
+		return task, nil
+	})
+}
+
+// getRecurringTask .
+func (s *Service) getRecurringTask(ctx context.Context, id int64) (*domain.Task, error) {
+
+	task, err := s.postgresRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = requireRecurring(*task)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// requireRecurring .
+func requireRecurring(task domain.Task) error {
+
+	if !task.IsRecurring() {
+		msg := "the task is not recurring"
+		return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	return nil
+}
+
+// validatePriority .
+func validatePriority(task domain.Task) error {
+
+	if task.Priority < domain.TaskPriorityUndefined || task.Priority > domain.TaskPriorityLowest {
+		msg := fmt.Sprintf("the priority should be between %d and %d", domain.TaskPriorityUndefined, domain.TaskPriorityLowest)
+		return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	return nil
+}
+
+// 訂閱任務異動事件
+func (s *Service) SubscribeTaskEvents(param domain.TaskParam, lastEventID int64) (*Subscription, []domain.TaskEvent, bool) {
+
+	return s.broker.Subscribe(param, lastEventID)
+}
+
+// publishTaskEvent publishes the change after the mutation has been done,
+// a failure of publishing should not fail the mutation.
+func (s *Service) publishTaskEvent(ctx context.Context, eventType domain.TaskEventType, task domain.Task) {
+	s.publishEvent(ctx, domain.TaskEvent{Type: eventType, Task: task})
+}
+
+// publishEvent .
+func (s *Service) publishEvent(ctx context.Context, event domain.TaskEvent) {
+
+	event.OccurredAt = time.Now().UTC()
+
+	err := s.publisher.Publish(ctx, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "publish task event fail", "type", event.Type, "task_id", event.Task.ID, "err", err)
+	}
 }
@@ -106,6 +106,11 @@ func TestTaskService_CreateTask(t *testing.T) {
 	err := faker.FakeData(&args)
 	require.NoError(t, err)
 
+	// the recurrence is covered by recurrence_test.go
+	args.Task.RRule = ""
+	args.Task.Timezone = ""
+	args.Task.Priority = domain.TaskPriorityHighest
+
 	tests := []struct {
 		name            string
 		wantErr         bool
@@ -186,6 +191,8 @@ func TestTaskService_UpdateTask(t *testing.T) {
 			setupService: func(t *testing.T) *Service {
 				mock := buildMockService(ctrl)
 
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.Task.ID).Return(&args.Task, nil)
 				mock.postgresRepo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(&args.Task, nil)
 
 				return buildService(mock)
@@ -199,7 +206,8 @@ func TestTaskService_UpdateTask(t *testing.T) {
 
 				err := common.NewError(common.ErrCodeResourceNotFound, errors.New("mock task not found error"))
 
-				mock.postgresRepo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(nil, err)
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.Task.ID).Return(nil, err)
 
 				return buildService(mock)
 			},
@@ -228,6 +236,190 @@ func TestTaskService_UpdateTask(t *testing.T) {
 	}
 }
 
+// TestTaskService_ReplaceTask .
+func TestTaskService_ReplaceTask(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type Args struct {
+		Task domain.Task
+	}
+
+	var args Args
+
+	err := faker.FakeData(&args)
+	require.NoError(t, err)
+
+	args.Task.Status = false
+	args.Task.RRule = ""
+	args.Task.Timezone = ""
+	args.Task.Priority = domain.TaskPriorityHighest
+
+	tests := []struct {
+		name            string
+		param           domain.Task
+		preconditions   []Precondition
+		wantErr         bool
+		expectedErrCode common.ErrCode
+		setupService    func(t *testing.T) *Service
+	}{
+		{
+			name:  "success",
+			param: args.Task,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.Task.ID).Return(&args.Task, nil)
+				mock.postgresRepo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, task domain.Task) (*domain.Task, error) {
+						assert.False(t, task.SeriesStart.Valid)
+						return &task, nil
+					})
+
+				return buildService(mock)
+			},
+			wantErr: false,
+		},
+		{
+			name:  "precondition failed error",
+			param: args.Task,
+			preconditions: []Precondition{func(task domain.Task) error {
+				assert.Equal(t, args.Task, task)
+				return common.NewError(common.ErrCodePreconditionFailed, errors.New("mock etag mismatch"))
+			}},
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				// the task is not updated
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.Task.ID).Return(&args.Task, nil)
+
+				return buildService(mock)
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodePreconditionFailed,
+		},
+		{
+			name: "invalid priority error",
+			param: func() domain.Task {
+				task := args.Task
+				task.Priority = 10
+				return task
+			}(),
+			setupService: func(t *testing.T) *Service {
+				return buildService(buildMockService(ctrl))
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeInvalidParameter,
+		},
+		{
+			name:  "task not found error",
+			param: args.Task,
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				err := common.NewError(common.ErrCodeResourceNotFound, errors.New("mock task not found error"))
+
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.Task.ID).Return(nil, err)
+
+				return buildService(mock)
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeResourceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setupService(t)
+
+			got, err := s.ReplaceTask(context.Background(), tt.param, tt.preconditions...)
+			if tt.wantErr {
+				require.Error(t, err)
+
+				var domainErr *common.Error
+				assert.True(t, common.AsErr(err, &domainErr))
+				assert.True(t, common.IsErrCode(err, tt.expectedErrCode))
+
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+// TestTaskService_GetTaskByExternalID .
+func TestTaskService_GetTaskByExternalID(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type Args struct {
+		Task domain.Task
+	}
+
+	var args Args
+
+	err := faker.FakeData(&args)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		wantErr         bool
+		expectedErrCode common.ErrCode
+		setupService    func(t *testing.T) *Service
+	}{
+		{
+			name: "success",
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().ListTasksByExternalIDs(gomock.Any(), []string{args.Task.ExternalID}).Return([]domain.Task{args.Task}, nil)
+
+				return buildService(mock)
+			},
+			wantErr: false,
+		},
+		{
+			name: "task not found error",
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.postgresRepo.EXPECT().ListTasksByExternalIDs(gomock.Any(), []string{args.Task.ExternalID}).Return(nil, nil)
+
+				return buildService(mock)
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodeResourceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setupService(t)
+
+			got, err := s.GetTaskByExternalID(context.Background(), args.Task.ExternalID)
+			if tt.wantErr {
+				require.Error(t, err)
+
+				var domainErr *common.Error
+				assert.True(t, common.AsErr(err, &domainErr))
+				assert.True(t, common.IsErrCode(err, tt.expectedErrCode))
+
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, args.Task.ID, got.ID)
+			}
+		})
+	}
+}
+
 // TestTaskService_DeleteTask .
 func TestTaskService_DeleteTask(t *testing.T) {
 	t.Parallel()
@@ -246,6 +438,7 @@ func TestTaskService_DeleteTask(t *testing.T) {
 
 	tests := []struct {
 		name            string
+		preconditions   []Precondition
 		wantErr         bool
 		expectedErrCode common.ErrCode
 		setupService    func(t *testing.T) *Service
@@ -261,6 +454,39 @@ func TestTaskService_DeleteTask(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "success with precondition",
+			preconditions: []Precondition{func(task domain.Task) error {
+				return nil
+			}},
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.TaskID).Return(&domain.Task{ID: args.TaskID}, nil)
+				mock.postgresRepo.EXPECT().DeleteTaskByID(gomock.Any(), args.TaskID).Return(nil)
+
+				return buildService(mock)
+			},
+			wantErr: false,
+		},
+		{
+			name: "precondition failed error",
+			preconditions: []Precondition{func(task domain.Task) error {
+				return common.NewError(common.ErrCodePreconditionFailed, errors.New("mock etag mismatch"))
+			}},
+			setupService: func(t *testing.T) *Service {
+				mock := buildMockService(ctrl)
+
+				// the task is not deleted
+				mock.expectTx()
+				mock.postgresRepo.EXPECT().LockTaskByID(gomock.Any(), args.TaskID).Return(&domain.Task{ID: args.TaskID}, nil)
+
+				return buildService(mock)
+			},
+			wantErr:         true,
+			expectedErrCode: common.ErrCodePreconditionFailed,
+		},
 		{
 			name: "task not found error",
 			setupService: func(t *testing.T) *Service {
@@ -281,7 +507,7 @@ func TestTaskService_DeleteTask(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := tt.setupService(t)
 
-			err := s.DeleteTaskByID(context.Background(), args.TaskID)
+			err := s.DeleteTaskByID(context.Background(), args.TaskID, tt.preconditions...)
 			if tt.wantErr {
 				require.Error(t, err)
 
@@ -0,0 +1,20 @@
+// Package taskdetail provides the tags, the subtasks and the history of tasks.
+package taskdetail
+
+import (
+	"context"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// Repository
+//
+//go:generate mockgen -destination mocks/repository.go -package=mocks . Repository
+type Repository interface {
+	// 列出多個任務的標籤, 依任務ID與標籤排序
+	ListTagsByTaskIDs(ctx context.Context, taskIDs []int64) ([]domain.TaskTag, error)
+	// 列出多個任務的子任務, 依任務ID與子任務ID排序
+	ListSubtasksByTaskIDs(ctx context.Context, taskIDs []int64) ([]domain.Subtask, error)
+	// 列出多個任務的異動紀錄, 依任務ID與異動順序排序, 每個任務最多 limit 筆最新的紀錄
+	ListTaskHistoryByTaskIDs(ctx context.Context, taskIDs []int64, limit int) ([]domain.TaskHistory, error)
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/tingchima/gogolook/internal/application/taskdetail (interfaces: Repository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/tingchima/gogolook/internal/domain"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListSubtasksByTaskIDs mocks base method.
+func (m *MockRepository) ListSubtasksByTaskIDs(arg0 context.Context, arg1 []int64) ([]domain.Subtask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubtasksByTaskIDs", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Subtask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubtasksByTaskIDs indicates an expected call of ListSubtasksByTaskIDs.
+func (mr *MockRepositoryMockRecorder) ListSubtasksByTaskIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubtasksByTaskIDs", reflect.TypeOf((*MockRepository)(nil).ListSubtasksByTaskIDs), arg0, arg1)
+}
+
+// ListTagsByTaskIDs mocks base method.
+func (m *MockRepository) ListTagsByTaskIDs(arg0 context.Context, arg1 []int64) ([]domain.TaskTag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTagsByTaskIDs", arg0, arg1)
+	ret0, _ := ret[0].([]domain.TaskTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTagsByTaskIDs indicates an expected call of ListTagsByTaskIDs.
+func (mr *MockRepositoryMockRecorder) ListTagsByTaskIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTagsByTaskIDs", reflect.TypeOf((*MockRepository)(nil).ListTagsByTaskIDs), arg0, arg1)
+}
+
+// ListTaskHistoryByTaskIDs mocks base method.
+func (m *MockRepository) ListTaskHistoryByTaskIDs(arg0 context.Context, arg1 []int64, arg2 int) ([]domain.TaskHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTaskHistoryByTaskIDs", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.TaskHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTaskHistoryByTaskIDs indicates an expected call of ListTaskHistoryByTaskIDs.
+func (mr *MockRepositoryMockRecorder) ListTaskHistoryByTaskIDs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaskHistoryByTaskIDs", reflect.TypeOf((*MockRepository)(nil).ListTaskHistoryByTaskIDs), arg0, arg1, arg2)
+}
@@ -0,0 +1,18 @@
+// Package taskdetail provides the tags, the subtasks and the history of tasks.
+package taskdetail
+
+type Service struct {
+	postgresRepo Repository
+}
+
+// ServiceParam .
+type ServiceParam struct {
+	PostgresRepo Repository
+}
+
+// NewService .
+func NewService(param ServiceParam) *Service {
+	return &Service{
+		postgresRepo: param.PostgresRepo,
+	}
+}
@@ -0,0 +1,59 @@
+// Package taskdetail provides the tags, the subtasks and the history of tasks.
+package taskdetail
+
+import (
+	"context"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// MaxHistoryCount is the max number of the latest history records listed per task.
+const MaxHistoryCount = 100
+
+// 列出多個任務的標籤, 不存在的任務沒有標籤
+func (s *Service) ListTagsByTaskIDs(ctx context.Context, taskIDs []int64) (map[int64][]string, error) {
+
+	tags, err := s.postgresRepo.ListTagsByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tagsByTaskID := make(map[int64][]string, len(taskIDs))
+	for i := range tags {
+		tagsByTaskID[tags[i].TaskID] = append(tagsByTaskID[tags[i].TaskID], tags[i].Tag)
+	}
+
+	return tagsByTaskID, nil
+}
+
+// 列出多個任務的子任務, 不存在的任務沒有子任務
+func (s *Service) ListSubtasksByTaskIDs(ctx context.Context, taskIDs []int64) (map[int64][]domain.Subtask, error) {
+
+	subtasks, err := s.postgresRepo.ListSubtasksByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	subtasksByTaskID := make(map[int64][]domain.Subtask, len(taskIDs))
+	for i := range subtasks {
+		subtasksByTaskID[subtasks[i].TaskID] = append(subtasksByTaskID[subtasks[i].TaskID], subtasks[i])
+	}
+
+	return subtasksByTaskID, nil
+}
+
+// 列出多個任務最新的異動紀錄, 依異動順序排序, 每個任務最多 MaxHistoryCount 筆
+func (s *Service) ListTaskHistoryByTaskIDs(ctx context.Context, taskIDs []int64) (map[int64][]domain.TaskHistory, error) {
+
+	history, err := s.postgresRepo.ListTaskHistoryByTaskIDs(ctx, taskIDs, MaxHistoryCount)
+	if err != nil {
+		return nil, err
+	}
+
+	historyByTaskID := make(map[int64][]domain.TaskHistory, len(taskIDs))
+	for i := range history {
+		historyByTaskID[history[i].TaskID] = append(historyByTaskID[history[i].TaskID], history[i])
+	}
+
+	return historyByTaskID, nil
+}
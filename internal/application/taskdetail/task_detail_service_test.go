@@ -0,0 +1,52 @@
+// Package taskdetail provides the tags, the subtasks and the history of tasks.
+package taskdetail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// TestTaskDetailService_ListByTaskIDs .
+func TestTaskDetailService_ListByTaskIDs(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := buildMockService(ctrl)
+	s := buildService(mock)
+
+	mock.postgresRepo.EXPECT().ListTagsByTaskIDs(gomock.Any(), []int64{1, 2}).Return([]domain.TaskTag{
+		{TaskID: 2, Tag: "Work"},
+		{TaskID: 2, Tag: "home"},
+	}, nil)
+	mock.postgresRepo.EXPECT().ListSubtasksByTaskIDs(gomock.Any(), []int64{1, 2}).Return([]domain.Subtask{
+		{ID: 1, TaskID: 1},
+		{ID: 2, TaskID: 1},
+	}, nil)
+	mock.postgresRepo.EXPECT().ListTaskHistoryByTaskIDs(gomock.Any(), []int64{1, 2}, MaxHistoryCount).Return([]domain.TaskHistory{
+		{ID: 1, TaskID: 1, Action: domain.TaskHistoryCreated},
+		{ID: 2, TaskID: 2, Action: domain.TaskHistoryCreated},
+		{ID: 3, TaskID: 2, Action: domain.TaskHistoryUpdated},
+	}, nil)
+
+	tags, err := s.ListTagsByTaskIDs(context.Background(), []int64{1, 2})
+	require.NoError(t, err)
+	assert.Empty(t, tags[1])
+	assert.Equal(t, []string{"Work", "home"}, tags[2])
+
+	subtasks, err := s.ListSubtasksByTaskIDs(context.Background(), []int64{1, 2})
+	require.NoError(t, err)
+	assert.Len(t, subtasks[1], 2)
+	assert.Empty(t, subtasks[2])
+
+	history, err := s.ListTaskHistoryByTaskIDs(context.Background(), []int64{1, 2})
+	require.NoError(t, err)
+	assert.Len(t, history[1], 1)
+	assert.Equal(t, []int64{2, 3}, []int64{history[2][0].ID, history[2][1].ID})
+}
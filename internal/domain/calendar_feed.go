@@ -0,0 +1,19 @@
+// Package domain provides
+package domain
+
+import "time"
+
+// CalendarFeed is the secret subscription url of a user for calendar clients,
+// only the hash of the token is stored.
+type CalendarFeed struct {
+	ID int64
+	// 使用者
+	User string
+	// 日曆名稱
+	Name string
+	// 訂閱token的 SHA-256 (hex)
+	TokenHash string
+	// 任務的篩選條件
+	Param     TaskParam
+	CreatedAt time.Time
+}
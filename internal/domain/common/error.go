@@ -4,21 +4,28 @@ package common
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+
+	pkgerrors "github.com/pkg/errors"
 )
 
-// Error .
+// Error is the error of domain, the client message is responded to the clients,
+// the cause and the stack of NewError are only for the server logs.
 type Error struct {
 	errCode   ErrCode
 	causeErr  error
 	clientMsg string
 	details   []any
+	stack     pkgerrors.StackTrace
 }
 
 // ErrOption .
 type ErrOption func(*Error)
 
+// WithMsg sets the client message, it should not contain the internal details, e.g. the sql.
 func WithMsg(msg string) ErrOption {
 	return func(e *Error) {
 		e.clientMsg = msg
@@ -31,11 +38,18 @@ func WithDetail(detail []any) ErrOption {
 	}
 }
 
+// stackTracer is implemented by the errors of pkg/errors.
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
 // NewError .
 func NewError(errCode ErrCode, causeErr error, options ...ErrOption) *Error {
 	err := Error{
 		errCode:  errCode,
 		causeErr: causeErr,
+		// skip the frame of NewError
+		stack: pkgerrors.New("").(stackTracer).StackTrace()[1:],
 	}
 
 	for _, o := range options {
@@ -79,11 +93,23 @@ func (e *Error) Error() string {
 	return b.String()
 }
 
-// CauseErr .
-func (e *Error) CauseErr() error {
-	if e.causeErr == nil {
-		e.causeErr = errors.New("cause error should be implemented")
+// Unwrap returns the cause, so that errors.Is and errors.As see through Error.
+func (e *Error) Unwrap() error {
+	return e.causeErr
+}
+
+// Is reports whether target is an Error of the same ErrCode, e.g.
+// errors.Is(err, common.NewError(common.ErrCodeResourceNotFound, nil)).
+func (e *Error) Is(target error) bool {
+	targetErr, ok := target.(*Error)
+	if !ok {
+		return false
 	}
+	return e.errCode == targetErr.errCode
+}
+
+// CauseErr returns nil if there is no cause.
+func (e *Error) CauseErr() error {
 	return e.causeErr
 }
 
@@ -92,16 +118,22 @@ func (e *Error) Name() string {
 	return e.errCode.Name
 }
 
-// ClientMsg .
+// ClientMsg falls back to the default message of ErrCode, the cause is never responded to the clients.
 func (e *Error) ClientMsg() string {
-	if e.clientMsg == "" {
-		return e.causeErr.Error()
+	if e.clientMsg != "" {
+		return e.clientMsg
 	}
-	return e.clientMsg
+	if e.errCode.Msg != "" {
+		return e.errCode.Msg
+	}
+	return strings.ToLower(http.StatusText(e.HTTPStatus()))
 }
 
 // CauseMsg .
 func (e *Error) CauseMsg() string {
+	if e.causeErr == nil {
+		return ""
+	}
 	return e.causeErr.Error()
 }
 
@@ -110,6 +142,11 @@ func (e *Error) DetailMsg() []any {
 	return e.details
 }
 
+// StackTrace returns the stack of NewError.
+func (e *Error) StackTrace() pkgerrors.StackTrace {
+	return e.stack
+}
+
 // HTTPStatus .
 func (e *Error) HTTPStatus() int {
 	if e.errCode.StatusCode == 0 {
@@ -123,3 +160,35 @@ func (e *Error) WithDetails(errs ...any) {
 	l := len(e.details)
 	e.details = append(e.details[:l:l], errs...)
 }
+
+// Format prints the stack of NewError for %+v like pkg/errors.
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		_, _ = io.WriteString(s, e.Error())
+		e.stack.Format(s, verb)
+	case verb == 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.Error())
+	default:
+		_, _ = io.WriteString(s, e.Error())
+	}
+}
+
+// LogValue logs the full chain of cause, and the stack of NewError for the server errors.
+func (e *Error) LogValue() slog.Value {
+
+	attrs := []slog.Attr{
+		slog.String("name", e.Name()),
+		slog.String("msg", e.ClientMsg()),
+	}
+
+	if e.causeErr != nil {
+		attrs = append(attrs, slog.String("cause", e.CauseMsg()))
+	}
+
+	if e.HTTPStatus() >= http.StatusInternalServerError && len(e.stack) > 0 {
+		attrs = append(attrs, slog.String("stack", fmt.Sprintf("%+v", e.stack)))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -7,6 +7,7 @@ import "net/http"
 type ErrCode struct {
 	Name       string
 	StatusCode int
+	Msg        string // 預設的用戶端訊息, 錯誤未指定訊息時使用
 }
 
 /*
@@ -17,6 +18,7 @@ type ErrCode struct {
 var ErrCodeInvalidParameter = ErrCode{
 	Name:       "INVALID_PARAMETER",
 	StatusCode: http.StatusBadRequest,
+	Msg:        "invalid parameter",
 }
 
 /*
@@ -27,6 +29,7 @@ var ErrCodeInvalidParameter = ErrCode{
 var ErrCodeUnauthorized = ErrCode{
 	Name:       "UNAUTHORIZED",
 	StatusCode: http.StatusUnauthorized,
+	Msg:        "unauthorized",
 }
 
 /*
@@ -37,6 +40,7 @@ var ErrCodeUnauthorized = ErrCode{
 var ErrCodeAccessNotAllowed = ErrCode{
 	Name:       "ACCESS_NOT_ALLOWED",
 	StatusCode: http.StatusForbidden,
+	Msg:        "access not allowed",
 }
 
 /*
@@ -47,6 +51,18 @@ var ErrCodeAccessNotAllowed = ErrCode{
 var ErrCodeResourceNotFound = ErrCode{
 	Name:       "RESOURCE_NOT_FOUND",
 	StatusCode: http.StatusNotFound,
+	Msg:        "resource not found",
+}
+
+/*
+	405
+*/
+
+// ErrCodeMethodNotAllowed .
+var ErrCodeMethodNotAllowed = ErrCode{
+	Name:       "METHOD_NOT_ALLOWED",
+	StatusCode: http.StatusMethodNotAllowed,
+	Msg:        "method not allowed",
 }
 
 /*
@@ -57,6 +73,51 @@ var ErrCodeResourceNotFound = ErrCode{
 var ErrCodeResourceAlreadyExisted = ErrCode{
 	Name:       "RESOURCE_ALREADY_EXISTED",
 	StatusCode: http.StatusConflict,
+	Msg:        "resource already existed",
+}
+
+/*
+	412
+*/
+
+// ErrCodePreconditionFailed .
+var ErrCodePreconditionFailed = ErrCode{
+	Name:       "PRECONDITION_FAILED",
+	StatusCode: http.StatusPreconditionFailed,
+	Msg:        "precondition failed",
+}
+
+/*
+	413
+*/
+
+// ErrCodeRequestTooLarge .
+var ErrCodeRequestTooLarge = ErrCode{
+	Name:       "REQUEST_TOO_LARGE",
+	StatusCode: http.StatusRequestEntityTooLarge,
+	Msg:        "request too large",
+}
+
+/*
+	422
+*/
+
+// ErrCodeUnprocessableEntity .
+var ErrCodeUnprocessableEntity = ErrCode{
+	Name:       "UNPROCESSABLE_ENTITY",
+	StatusCode: http.StatusUnprocessableEntity,
+	Msg:        "unprocessable entity",
+}
+
+/*
+	429
+*/
+
+// ErrCodeTooManyRequests .
+var ErrCodeTooManyRequests = ErrCode{
+	Name:       "TOO_MANY_REQUESTS",
+	StatusCode: http.StatusTooManyRequests,
+	Msg:        "too many requests",
 }
 
 /*
@@ -67,4 +128,16 @@ var ErrCodeResourceAlreadyExisted = ErrCode{
 var ErrCodeInternalProcess = ErrCode{
 	Name:       "INTERNAL_PROCESS",
 	StatusCode: http.StatusInternalServerError,
+	Msg:        "internal error",
+}
+
+/*
+	503
+*/
+
+// ErrCodeServiceUnavailable .
+var ErrCodeServiceUnavailable = ErrCode{
+	Name:       "SERVICE_UNAVAILABLE",
+	StatusCode: http.StatusServiceUnavailable,
+	Msg:        "service unavailable, please retry later",
 }
@@ -0,0 +1,104 @@
+// Package common provides
+package common
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestError_ClientMsg .
+func TestError_ClientMsg(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New(`pq: relation "tasks" does not exist`)
+
+	tests := []struct {
+		name string
+		err  *Error
+		msg  string
+	}{
+		{
+			name: "client message",
+			err:  NewError(ErrCodeInvalidParameter, cause, WithMsg("the name is required")),
+			msg:  "the name is required",
+		},
+		{
+			name: "default message of code",
+			err:  NewError(ErrCodeInternalProcess, cause),
+			msg:  ErrCodeInternalProcess.Msg,
+		},
+		{
+			name: "unknown code",
+			err:  NewError(ErrCode{Name: "TEAPOT", StatusCode: 418}, cause),
+			msg:  "i'm a teapot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.msg, tt.err.ClientMsg())
+			assert.NotContains(t, tt.err.ClientMsg(), "relation")
+		})
+	}
+}
+
+// TestError_Unwrap .
+func TestError_Unwrap(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("get task: %w", NewError(ErrCodeResourceNotFound, sql.ErrNoRows))
+
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(err, NewError(ErrCodeResourceNotFound, nil)))
+	assert.False(t, errors.Is(err, NewError(ErrCodeInternalProcess, nil)))
+
+	var appErr *Error
+	require.True(t, AsErr(err, &appErr))
+	assert.Equal(t, sql.ErrNoRows, errors.Unwrap(appErr))
+
+	// the error without cause is not mutated
+	noCause := NewError(ErrCodeInternalProcess, nil)
+	assert.Nil(t, noCause.CauseErr())
+	assert.Nil(t, noCause.CauseErr())
+	assert.Empty(t, noCause.CauseMsg())
+}
+
+// TestError_StackTrace .
+func TestError_StackTrace(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(ErrCodeInternalProcess, errors.New("boom"))
+
+	require.NotEmpty(t, err.StackTrace())
+	// the first frame is the caller of NewError
+	assert.Equal(t, "TestError_StackTrace", fmt.Sprintf("%n", err.StackTrace()[0]))
+	assert.Contains(t, fmt.Sprintf("%+v", err), "error_test.go")
+	assert.NotContains(t, fmt.Sprintf("%v", err), "error_test.go")
+}
+
+// TestError_LogValue .
+func TestError_LogValue(t *testing.T) {
+	t.Parallel()
+
+	attrs := func(err *Error) map[string]string {
+		m := map[string]string{}
+		for _, a := range err.LogValue().Group() {
+			m[a.Key] = a.Value.String()
+		}
+		return m
+	}
+
+	serverErr := attrs(NewError(ErrCodeInternalProcess, fmt.Errorf("list tasks: %w", errors.New("conn refused"))))
+	assert.Equal(t, "INTERNAL_PROCESS", serverErr["name"])
+	assert.Equal(t, "list tasks: conn refused", serverErr["cause"])
+	assert.Contains(t, serverErr["stack"], "error_test.go")
+
+	// the stack of client errors is not logged
+	clientErr := attrs(NewError(ErrCodeInvalidParameter, errors.New("bad"), WithMsg("bad")))
+	assert.NotContains(t, clientErr, "stack")
+}
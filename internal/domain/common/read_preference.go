@@ -0,0 +1,92 @@
+// Package common provides
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// primaryReadContextKey .
+type primaryReadContextKey struct{}
+
+// WithPrimaryRead hints the repositories to read from the primary database instead of the replicas,
+// e.g. the read-modify-write and the reads right after a write, which the replicas may not have applied yet.
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadContextKey{}, true)
+}
+
+// IsPrimaryRead .
+func IsPrimaryRead(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadContextKey{}).(bool)
+	return primary
+}
+
+// StickyPrimary routes the reads of a principal to the primary database within the replica lag
+// after its last write, so that the principal reads its own writes. The writes are only known by
+// this instance, the nil StickyPrimary never hints the primary read.
+type StickyPrimary struct {
+	lag time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	writtenAt map[string]time.Time
+	sweptAt   time.Time
+}
+
+// NewStickyPrimary returns nil if lag is not positive, e.g. there is no replica.
+func NewStickyPrimary(lag time.Duration) *StickyPrimary {
+
+	if lag <= 0 {
+		return nil
+	}
+
+	return &StickyPrimary{
+		lag:       lag,
+		now:       time.Now,
+		writtenAt: make(map[string]time.Time),
+	}
+}
+
+// MarkWrite starts the window of principal, the expired windows are swept at most once per lag.
+func (s *StickyPrimary) MarkWrite(principal string) {
+
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.writtenAt[principal] = now
+
+	if now.Sub(s.sweptAt) < s.lag {
+		return
+	}
+
+	for p, writtenAt := range s.writtenAt {
+		if now.Sub(writtenAt) >= s.lag {
+			delete(s.writtenAt, p)
+		}
+	}
+	s.sweptAt = now
+}
+
+// Context hints the primary read if the principal has written within the lag.
+func (s *StickyPrimary) Context(ctx context.Context, principal string) context.Context {
+
+	if s == nil {
+		return ctx
+	}
+
+	s.mu.Lock()
+	writtenAt, ok := s.writtenAt[principal]
+	s.mu.Unlock()
+
+	if ok && s.now().Sub(writtenAt) < s.lag {
+		return WithPrimaryRead(ctx)
+	}
+
+	return ctx
+}
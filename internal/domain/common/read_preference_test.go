@@ -0,0 +1,44 @@
+// Package common provides
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStickyPrimary .
+func TestStickyPrimary(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sticky := NewStickyPrimary(2 * time.Second)
+	sticky.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	assert.False(t, IsPrimaryRead(sticky.Context(ctx, "key:a")))
+
+	sticky.MarkWrite("key:a")
+	assert.True(t, IsPrimaryRead(sticky.Context(ctx, "key:a")))
+	assert.False(t, IsPrimaryRead(sticky.Context(ctx, "key:b")))
+
+	now = now.Add(time.Second)
+	assert.True(t, IsPrimaryRead(sticky.Context(ctx, "key:a")))
+
+	// the window ends after the lag, the expired windows are swept by the next write
+	now = now.Add(time.Second)
+	assert.False(t, IsPrimaryRead(sticky.Context(ctx, "key:a")))
+
+	sticky.MarkWrite("key:b")
+	assert.NotContains(t, sticky.writtenAt, "key:a")
+	assert.True(t, IsPrimaryRead(sticky.Context(ctx, "key:b")))
+
+	// no replica
+	none := NewStickyPrimary(0)
+	none.MarkWrite("key:a")
+	assert.False(t, IsPrimaryRead(none.Context(ctx, "key:a")))
+}
@@ -0,0 +1,88 @@
+// Package domain provides
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// ReminderChannel .
+type ReminderChannel string
+
+const (
+	ReminderChannelLog     ReminderChannel = "log"
+	ReminderChannelWebhook ReminderChannel = "webhook"
+	ReminderChannelSMTP    ReminderChannel = "smtp"
+)
+
+// the failure reasons of delivery, they are stored as the last error of reminder and responded to the users,
+// the errors of notifiers are only logged since they may contain the resolved addresses or the server replies
+var (
+	// ErrReminderNotifierNotConfigured .
+	ErrReminderNotifierNotConfigured = errors.New("the notifier of channel is not configured")
+	// ErrReminderRecipientInvalid .
+	ErrReminderRecipientInvalid = errors.New("the recipient is invalid")
+	// ErrReminderRecipientNotAllowed .
+	ErrReminderRecipientNotAllowed = errors.New("the recipient is not allowed")
+	// ErrReminderRejected is returned when the recipient responds with an error.
+	ErrReminderRejected = errors.New("the recipient rejected the reminder")
+	// ErrReminderTimeout .
+	ErrReminderTimeout = errors.New("the delivery timed out")
+	// ErrReminderDeliveryFailed is the reason of the other errors.
+	ErrReminderDeliveryFailed = errors.New("the delivery failed")
+)
+
+// Reminder .
+type Reminder struct {
+	ID     int64
+	TaskID int64
+	// 提醒時間 (絕對時間), 與 Offset 擇一
+	RemindAt null.Time
+	// 相對任務到期時間的秒數, 負值為到期前, 與 RemindAt 擇一
+	Offset null.Int
+	// 通知管道
+	Channel ReminderChannel
+	// 通知對象, webhook url 或 email
+	Recipient string
+	// 送出時間
+	SentAt null.Time
+	// 送出失敗次數
+	Attempts int
+	// 最後一次送出失敗的原因
+	LastError string
+	// 送出失敗後的下次重試時間
+	RetryAt   null.Time
+	CreatedAt time.Time
+}
+
+// FireAt returns the time to send the reminder of task, ok is false if it never fires.
+func (r Reminder) FireAt(task Task) (fireAt time.Time, ok bool) {
+	if r.RemindAt.Valid {
+		return r.RemindAt.Time, true
+	}
+	if r.Offset.Valid && task.DueAt.Valid {
+		return task.DueAt.Time.Add(time.Duration(r.Offset.Int64) * time.Second), true
+	}
+	return time.Time{}, false
+}
+
+// DueReminder is a reminder picked up by the scheduler.
+type DueReminder struct {
+	Reminder Reminder
+	Task     Task
+	FireAt   time.Time
+}
+
+// DueReminderParam .
+type DueReminderParam struct {
+	// 以此時間判斷是否到期
+	Now time.Time
+	// 一次取出的筆數
+	Limit int
+	// 送出失敗超過此次數的提醒不再重試
+	MaxAttempts int
+	// 第一次重試的間隔, 之後每次加倍
+	RetryDelay time.Duration
+}
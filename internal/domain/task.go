@@ -1,7 +1,16 @@
 // Package domain provides
 package domain
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// ErrNotFoundTask is the cause of the task not found errors, shared by the repositories and the services.
+var ErrNotFoundTask = errors.New("task not found")
 
 // Task .
 type Task struct {
@@ -10,10 +19,62 @@ type Task struct {
 	Status    bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// 到期時間
+	DueAt null.Time
+	// 重複規則 (RFC 5545 RRULE), 例如 FREQ=MONTHLY;BYMONTHDAY=1
+	RRule string
+	// 重複規則的時區 (IANA), 例如 Asia/Taipei, 空值為 UTC
+	Timezone string
+	// 重複系列的起始時間 (DTSTART), 即第一次的到期時間
+	SeriesStart null.Time
+
+	// 優先順序 (RFC 5545 PRIORITY), 0 為未指定, 1 最高, 9 最低
+	Priority int
+
+	// 外部系統的任務ID, 匯入時依此更新既有任務
+	ExternalID string
+}
+
+// task priority
+const (
+	TaskPriorityUndefined = 0
+	TaskPriorityHighest   = 1
+	TaskPriorityLowest    = 9
+)
+
+// TaskCount .
+type TaskCount struct {
+	// 未完成
+	Open int64
+	// 已完成
+	Completed int64
+}
+
+// IsRecurring .
+func (t Task) IsRecurring() bool {
+	return t.RRule != ""
 }
 
 // TaskParam .
 type TaskParam struct {
+	// 任務狀態
+	Status null.Bool
+	// 任務名稱關鍵字, 不分大小寫
+	Name string
+}
+
+// Match reports whether the task satisfies the query condition,
+// it should be kept consistent with the repository implementation.
+func (p TaskParam) Match(task Task) bool {
+
+	if p.Status.Valid && p.Status.Bool != task.Status {
+		return false
+	}
+
+	if p.Name != "" && !strings.Contains(strings.ToLower(task.Name), strings.ToLower(p.Name)) {
+		return false
+	}
 
-	// implement query task condition, like page, per_page, sort_by ...etc
+	return true
 }
@@ -0,0 +1,50 @@
+// Package domain provides
+package domain
+
+import (
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// TaskTag .
+type TaskTag struct {
+	TaskID int64
+	// 標籤, 同一任務不重複
+	Tag string
+}
+
+// Subtask is a checklist item of task.
+type Subtask struct {
+	ID        int64
+	TaskID    int64
+	Name      string
+	Status    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TaskHistoryAction .
+type TaskHistoryAction string
+
+const (
+	TaskHistoryCreated TaskHistoryAction = "created"
+	TaskHistoryUpdated TaskHistoryAction = "updated"
+)
+
+// TaskHistory is the snapshot of task after it is created or updated.
+type TaskHistory struct {
+	ID     int64
+	TaskID int64
+	// 異動類型
+	Action TaskHistoryAction
+	// 異動後的任務名稱
+	Name string
+	// 異動後的任務狀態
+	Status bool
+	// 異動後的到期時間
+	DueAt null.Time
+	// 異動後的優先順序
+	Priority  int
+	ChangedAt time.Time
+}
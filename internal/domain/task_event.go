@@ -0,0 +1,45 @@
+// Package domain provides
+package domain
+
+import "time"
+
+// TaskEventType .
+type TaskEventType string
+
+const (
+	TaskEventCreated TaskEventType = "created"
+	TaskEventUpdated TaskEventType = "updated"
+	TaskEventDeleted TaskEventType = "deleted"
+	// TaskEventRemoved is delivered instead of updated, when the updated task no longer matches the subscription.
+	TaskEventRemoved TaskEventType = "removed"
+)
+
+// TaskEvent describes a change of task.
+type TaskEvent struct {
+	// 事件序號, 由 broker 發布時指定
+	ID int64
+	// 事件類型
+	Type TaskEventType
+	// 異動後的任務, 刪除事件只會帶有任務ID
+	Task Task
+	// 異動前的任務, 只有修改事件帶有, 用於判斷任務是否移出訂閱條件
+	Previous *Task
+	// 發生時間
+	OccurredAt time.Time
+}
+
+// ForSubscriber returns the event delivered to the subscriber with the param, ok is false if it is not delivered.
+// Deleted events only carry the task id, so they are always delivered. The updated task which no longer
+// matches the param is delivered as removed, if it matched before the update or the previous task is unknown.
+func (e TaskEvent) ForSubscriber(param TaskParam) (event TaskEvent, ok bool) {
+	switch {
+	case e.Type == TaskEventDeleted:
+		return e, true
+	case param.Match(e.Task):
+		return e, true
+	case e.Type == TaskEventUpdated && (e.Previous == nil || param.Match(*e.Previous)):
+		e.Type = TaskEventRemoved
+		return e, true
+	}
+	return e, false
+}
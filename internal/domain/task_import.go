@@ -0,0 +1,31 @@
+// Package domain provides
+package domain
+
+// TaskImportAction .
+type TaskImportAction string
+
+const (
+	TaskImportCreate TaskImportAction = "create"
+	TaskImportUpdate TaskImportAction = "update"
+)
+
+// TaskImportRow is a decoded row of the import file.
+type TaskImportRow struct {
+	// 資料列序號, 從 1 開始
+	Row  int
+	Task Task
+}
+
+// TaskImportResult .
+type TaskImportResult struct {
+	Row    int
+	Action TaskImportAction
+	// 試算 (dry run) 時只有匯入的欄位, 不含ID
+	Task Task
+}
+
+// TaskUpsertResult .
+type TaskUpsertResult struct {
+	Task    Task
+	Created bool
+}
@@ -0,0 +1,167 @@
+// Package graphql provides the /graphql endpoint over the tasks, the schema is schema.graphqls
+// and the executor is generated by gqlgen.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/tingchima/gogolook/infra/logger"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Path .
+const Path = "/graphql"
+
+// DefaultComplexityLimit .
+const DefaultComplexityLimit = 1000
+
+// listComplexity is the estimated size of the task, reminder, tag, subtask and history lists,
+// which multiplies the complexity of their fields.
+const listComplexity = 10
+
+// queryCacheSize is the number of parsed queries which are cached.
+const queryCacheSize = 1000
+
+// errorCodeKey is the extension key of error, the value is the ErrCode name.
+const errorCodeKey = "code"
+
+// HandlerParam .
+type HandlerParam struct {
+	App *application.Application
+	// 查詢複雜度上限, 0 為 DefaultComplexityLimit
+	ComplexityLimit int
+	// 是否開放 introspection
+	Introspection bool
+}
+
+// NewHandler returns the handler of GraphQL queries over GET and POST, the queries beyond the complexity
+// limit are rejected before they are executed.
+func NewHandler(param HandlerParam) http.Handler {
+
+	limit := param.ComplexityLimit
+	if limit <= 0 {
+		limit = DefaultComplexityLimit
+	}
+
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{app: param.App},
+		Complexity: complexity(),
+	}))
+
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	srv.SetQueryCache(lru.New(queryCacheSize))
+
+	if param.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+	srv.Use(extension.FixedComplexityLimit(limit))
+
+	srv.SetErrorPresenter(presentError)
+	srv.SetRecoverFunc(recoverPanic)
+
+	return withLoaders(param.App, srv)
+}
+
+// complexity multiplies the complexity of list fields, the occurrences are counted by the requested count,
+// which is capped like the resolver.
+func complexity() ComplexityRoot {
+
+	var root ComplexityRoot
+
+	list := func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+
+	root.Query.Tasks = func(childComplexity int, _ *bool, _ *string) int {
+		return list(childComplexity)
+	}
+	root.Task.Reminders = list
+	root.Task.Tags = list
+	root.Task.Subtasks = list
+	root.Task.History = list
+	root.Task.Occurrences = func(childComplexity int, count *int) int {
+		n := task.DefaultOccurrencePreviewCount
+		if count != nil && *count > 0 {
+			n = min(*count, task.MaxOccurrencePreviewCount)
+		}
+		return n * childComplexity
+	}
+
+	return root
+}
+
+// presentError responds the client message of error, the ErrCode name and the request ID are in the extensions.
+// The errors of parsing and validation keep the codes of gqlgen.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var appErr *common.Error
+	_ = common.AsErr(err, &appErr)
+
+	if appErr == nil {
+		var validationErr *gqlerror.Error
+		if errors.As(err, &validationErr) {
+			return withRequestID(ctx, gqlErr)
+		}
+		appErr = common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	if appErr.HTTPStatus() >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "graphql resolver fail", "path", graphql.GetPath(ctx).String(), "err", appErr)
+	}
+
+	gqlErr.Message = appErr.ClientMsg()
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]any{}
+	}
+	gqlErr.Extensions[errorCodeKey] = appErr.Name()
+	if details := appErr.DetailMsg(); len(details) > 0 {
+		gqlErr.Extensions["details"] = details
+	}
+
+	return withRequestID(ctx, gqlErr)
+}
+
+// withRequestID .
+func withRequestID(ctx context.Context, gqlErr *gqlerror.Error) *gqlerror.Error {
+
+	requestID := logger.RequestIDFromContext(ctx)
+	if requestID == "" {
+		return gqlErr
+	}
+
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]any{}
+	}
+	gqlErr.Extensions[logger.RequestIDKey] = requestID
+
+	return gqlErr
+}
+
+// recoverPanic logs the panic of resolver with the stack, the client gets an internal error.
+func recoverPanic(ctx context.Context, r any) error {
+
+	slog.ErrorContext(ctx, "graphql resolver panic",
+		"panic", fmt.Sprint(r),
+		"stack", string(debug.Stack()),
+	)
+
+	err := fmt.Errorf("panic: %v", r)
+	return common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg("internal error"))
+}
@@ -0,0 +1,222 @@
+// Package graphql provides the /graphql endpoint over the tasks, the schema is schema.graphqls
+// and the executor is generated by gqlgen.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/infra/logger"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/reminder"
+	reminder_mocks "github.com/tingchima/gogolook/internal/application/reminder/mocks"
+	"github.com/tingchima/gogolook/internal/application/task"
+	task_mocks "github.com/tingchima/gogolook/internal/application/task/mocks"
+	"github.com/tingchima/gogolook/internal/application/taskdetail"
+	taskdetail_mocks "github.com/tingchima/gogolook/internal/application/taskdetail/mocks"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+// response .
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// buildHandler .
+func buildHandler(t *testing.T, limit int) (http.Handler, *task_mocks.MockRepository, *reminder_mocks.MockRepository, *taskdetail_mocks.MockRepository) {
+
+	ctrl := gomock.NewController(t)
+
+	taskRepo := task_mocks.NewMockRepository(ctrl)
+	reminderRepo := reminder_mocks.NewMockRepository(ctrl)
+	taskDetailRepo := taskdetail_mocks.NewMockRepository(ctrl)
+
+	app := &application.Application{
+		TaskService:       task.NewService(task.ServiceParam{PostgresRepo: taskRepo}),
+		ReminderService:   reminder.NewService(reminder.ServiceParam{PostgresRepo: reminderRepo}),
+		TaskDetailService: taskdetail.NewService(taskdetail.ServiceParam{PostgresRepo: taskDetailRepo}),
+	}
+
+	return NewHandler(HandlerParam{App: app, ComplexityLimit: limit}), taskRepo, reminderRepo, taskDetailRepo
+}
+
+// query .
+func query(t *testing.T, h http.Handler, q string) response {
+
+	body, err := json.Marshal(map[string]string{"query": q})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(logger.WithRequestID(req.Context(), "req-1"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+// TestHandler_Tasks .
+func TestHandler_Tasks(t *testing.T) {
+	t.Parallel()
+
+	h, taskRepo, reminderRepo, _ := buildHandler(t, 0)
+
+	dueAt := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+
+	taskRepo.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{Status: null.BoolFrom(false)}).Return([]domain.Task{
+		{ID: 1, Name: "monthly", DueAt: null.TimeFrom(dueAt), RRule: "FREQ=MONTHLY", Timezone: "UTC"},
+		{ID: 2, Name: "once"},
+	}, nil)
+
+	// the reminders of all tasks are listed by one query
+	reminderRepo.EXPECT().ListRemindersByTaskIDs(gomock.Any(), gomock.InAnyOrder([]int64{1, 2})).Return([]domain.Reminder{
+		{ID: 10, TaskID: 1, Offset: null.IntFrom(-3600), Channel: domain.ReminderChannelLog},
+	}, nil).Times(1)
+
+	resp := query(t, h, `{ tasks(status: false) { id name dueAt reminders { id offsetSeconds channel } occurrences(count: 2) } }`)
+	require.Empty(t, resp.Errors)
+
+	assert.JSONEq(t, `{"tasks": [
+		{"id": 1, "name": "monthly", "dueAt": "2024-02-01T09:00:00Z",
+			"reminders": [{"id": 10, "offsetSeconds": -3600, "channel": "log"}],
+			"occurrences": ["2024-02-01T09:00:00Z", "2024-03-01T09:00:00Z"]},
+		{"id": 2, "name": "once", "dueAt": null, "reminders": [], "occurrences": []}
+	]}`, string(resp.Data))
+}
+
+// TestHandler_TaskDetails .
+func TestHandler_TaskDetails(t *testing.T) {
+	t.Parallel()
+
+	h, taskRepo, _, taskDetailRepo := buildHandler(t, 0)
+
+	changedAt := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+
+	taskRepo.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{}).Return([]domain.Task{
+		{ID: 1, Name: "繳房租"},
+		{ID: 2, Name: "買牛奶"},
+	}, nil)
+
+	// the details of all tasks are listed by one query each
+	taskDetailRepo.EXPECT().ListTagsByTaskIDs(gomock.Any(), gomock.InAnyOrder([]int64{1, 2})).Return([]domain.TaskTag{
+		{TaskID: 1, Tag: "home"},
+	}, nil).Times(1)
+	taskDetailRepo.EXPECT().ListSubtasksByTaskIDs(gomock.Any(), gomock.InAnyOrder([]int64{1, 2})).Return([]domain.Subtask{
+		{ID: 3, TaskID: 2, Name: "全脂"},
+	}, nil).Times(1)
+	taskDetailRepo.EXPECT().ListTaskHistoryByTaskIDs(gomock.Any(), gomock.InAnyOrder([]int64{1, 2}), taskdetail.MaxHistoryCount).Return([]domain.TaskHistory{
+		{ID: 4, TaskID: 1, Action: domain.TaskHistoryCreated, Name: "繳房租", ChangedAt: changedAt},
+	}, nil).Times(1)
+
+	resp := query(t, h, `{ tasks { id tags subtasks { id name status } history { action name dueAt changedAt } } }`)
+	require.Empty(t, resp.Errors)
+
+	assert.JSONEq(t, `{"tasks": [
+		{"id": 1, "tags": ["home"], "subtasks": [],
+			"history": [{"action": "created", "name": "繳房租", "dueAt": null, "changedAt": "2024-02-01T09:00:00Z"}]},
+		{"id": 2, "tags": [], "subtasks": [{"id": 3, "name": "全脂", "status": false}], "history": []}
+	]}`, string(resp.Data))
+}
+
+// TestHandler_Errors .
+func TestHandler_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		limit     int
+		query     string
+		setupMock func(taskRepo *task_mocks.MockRepository)
+		wantMsg   string
+		wantCode  string
+	}{
+		{
+			name:  "not found",
+			query: `{ task(id: 2) { id } }`,
+			setupMock: func(taskRepo *task_mocks.MockRepository) {
+				taskRepo.EXPECT().GetTaskByID(gomock.Any(), int64(2)).
+					Return(nil, common.NewError(common.ErrCodeResourceNotFound, errors.New("sql: no rows"), common.WithMsg("task not found")))
+			},
+			wantMsg:  "task not found",
+			wantCode: common.ErrCodeResourceNotFound.Name,
+		},
+		{
+			name:      "invalid parameter",
+			query:     `mutation { createTask(input: {name: " "}) { id } }`,
+			setupMock: func(taskRepo *task_mocks.MockRepository) {},
+			wantMsg:   "the name is required",
+			wantCode:  common.ErrCodeInvalidParameter.Name,
+		},
+		{
+			name:      "complexity limit",
+			limit:     50,
+			query:     `{ tasks { id reminders { id } occurrences(count: 100) } }`,
+			setupMock: func(taskRepo *task_mocks.MockRepository) {},
+			wantCode:  "COMPLEXITY_LIMIT_EXCEEDED",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h, taskRepo, _, _ := buildHandler(t, tt.limit)
+			tt.setupMock(taskRepo)
+
+			resp := query(t, h, tt.query)
+			require.Len(t, resp.Errors, 1)
+
+			if tt.wantMsg != "" {
+				assert.Equal(t, tt.wantMsg, resp.Errors[0].Message)
+			}
+			assert.Equal(t, tt.wantCode, resp.Errors[0].Extensions[errorCodeKey])
+			assert.Equal(t, "req-1", resp.Errors[0].Extensions[logger.RequestIDKey])
+		})
+	}
+}
+
+// TestHandler_OccurrenceComplexity .
+func TestHandler_OccurrenceComplexity(t *testing.T) {
+	t.Parallel()
+
+	h, taskRepo, _, _ := buildHandler(t, 150)
+
+	taskRepo.EXPECT().GetTaskByID(gomock.Any(), int64(1)).Return(&domain.Task{ID: 1}, nil)
+
+	// the count is capped by the resolver, so is the complexity
+	resp := query(t, h, `{ task(id: 1) { id occurrences(count: 1000000000) } }`)
+	require.Empty(t, resp.Errors)
+
+	assert.JSONEq(t, `{"task": {"id": 1, "occurrences": []}}`, string(resp.Data))
+}
+
+// TestHandler_Introspection .
+func TestHandler_Introspection(t *testing.T) {
+	t.Parallel()
+
+	h, _, _, _ := buildHandler(t, 0)
+
+	// introspection is disabled by default
+	resp := query(t, h, `{ __schema { queryType { name } } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "introspection disabled", resp.Errors[0].Message)
+}
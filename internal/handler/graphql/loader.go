@@ -0,0 +1,87 @@
+// Package graphql provides the /graphql endpoint over the tasks, the schema is schema.graphqls
+// and the executor is generated by gqlgen.
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// loaderWait is how long the loaders wait to collect the keys of a batch.
+const loaderWait = 2 * time.Millisecond
+
+// loadersContextKey .
+type loadersContextKey struct{}
+
+// loaders are created for every request, so that the cached results are not shared between the requests.
+type loaders struct {
+	reminders *dataloader.Loader[int64, []domain.Reminder]
+	tags      *dataloader.Loader[int64, []string]
+	subtasks  *dataloader.Loader[int64, []domain.Subtask]
+	history   *dataloader.Loader[int64, []domain.TaskHistory]
+}
+
+// newLoaders .
+func newLoaders(app *application.Application) *loaders {
+	return &loaders{
+		reminders: newTaskLoader(app.ReminderService.ListRemindersByTaskIDs),
+		tags:      newTaskLoader(app.TaskDetailService.ListTagsByTaskIDs),
+		subtasks:  newTaskLoader(app.TaskDetailService.ListSubtasksByTaskIDs),
+		history:   newTaskLoader(app.TaskDetailService.ListTaskHistoryByTaskIDs),
+	}
+}
+
+// withLoaders puts the loaders of request into the context.
+func withLoaders(app *application.Application, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersContextKey{}, newLoaders(app))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loadersFromContext returns the loaders of request, a new one is returned if ctx has no loaders.
+func loadersFromContext(ctx context.Context, app *application.Application) *loaders {
+
+	if l, ok := ctx.Value(loadersContextKey{}).(*loaders); ok {
+		return l
+	}
+
+	return newLoaders(app)
+}
+
+// newTaskLoader .
+func newTaskLoader[T any](list func(ctx context.Context, taskIDs []int64) (map[int64][]T, error)) *dataloader.Loader[int64, []T] {
+	return dataloader.NewBatchedLoader(batchByTaskIDs(list), dataloader.WithWait[int64, []T](loaderWait))
+}
+
+// batchByTaskIDs lists the items of the tasks by one query, e.g. the reminders,
+// the task without items gets an empty list.
+func batchByTaskIDs[T any](list func(ctx context.Context, taskIDs []int64) (map[int64][]T, error)) dataloader.BatchFunc[int64, []T] {
+	return func(ctx context.Context, taskIDs []int64) []*dataloader.Result[[]T] {
+
+		results := make([]*dataloader.Result[[]T], len(taskIDs))
+
+		items, err := list(ctx, taskIDs)
+		if err != nil {
+			for i := range results {
+				results[i] = &dataloader.Result[[]T]{Error: err}
+			}
+			return results
+		}
+
+		for i := range taskIDs {
+			data := items[taskIDs[i]]
+			if data == nil {
+				data = []T{}
+			}
+			results[i] = &dataloader.Result[[]T]{Data: data}
+		}
+
+		return results
+	}
+}
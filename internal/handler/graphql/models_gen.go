@@ -0,0 +1,33 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphql
+
+import (
+	"time"
+)
+
+type CreateTaskInput struct {
+	// 任務名稱
+	Name string `json:"name"`
+	// 到期時間
+	DueAt *time.Time `json:"dueAt,omitempty"`
+	// 重複規則 (RFC 5545 RRULE), 例如 FREQ=MONTHLY;BYMONTHDAY=1
+	Rrule *string `json:"rrule,omitempty"`
+	// 重複規則的時區 (IANA), 例如 Asia/Taipei
+	Timezone *string `json:"timezone,omitempty"`
+	// 優先順序, 0 為未指定, 1 最高, 9 最低
+	Priority *int `json:"priority,omitempty"`
+}
+
+type Mutation struct {
+}
+
+type Query struct {
+}
+
+type UpdateTaskInput struct {
+	// 任務名稱
+	Name string `json:"name"`
+	// 任務狀態
+	Status bool `json:"status"`
+}
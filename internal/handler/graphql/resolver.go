@@ -0,0 +1,22 @@
+// Package graphql provides the /graphql endpoint over the tasks, the schema is schema.graphqls
+// and the executor is generated by gqlgen.
+package graphql
+
+//go:generate gqlgen generate
+
+import (
+	"errors"
+
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// Resolver .
+type Resolver struct {
+	app *application.Application
+}
+
+// invalidParameter .
+func invalidParameter(msg string) error {
+	return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+}
@@ -0,0 +1,199 @@
+// Package graphql provides the /graphql endpoint over the tasks, the schema is schema.graphqls
+// and the executor is generated by gqlgen.
+package graphql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// Tasks .
+func (r *queryResolver) Tasks(ctx context.Context, status *bool, name *string) ([]domain.Task, error) {
+
+	param := domain.TaskParam{Status: null.BoolFromPtr(status)}
+	if name != nil {
+		param.Name = *name
+	}
+
+	return r.app.TaskService.ListTasks(ctx, param)
+}
+
+// Task .
+func (r *queryResolver) Task(ctx context.Context, id int64) (*domain.Task, error) {
+	return r.app.TaskService.GetTaskByID(ctx, id)
+}
+
+// CreateTask .
+func (r *mutationResolver) CreateTask(ctx context.Context, input CreateTaskInput) (*domain.Task, error) {
+
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, invalidParameter("the name is required")
+	}
+
+	param := domain.Task{
+		Name:  input.Name,
+		DueAt: null.TimeFromPtr(input.DueAt),
+	}
+
+	if input.Rrule != nil {
+		param.RRule = *input.Rrule
+	}
+	if input.Timezone != nil {
+		param.Timezone = *input.Timezone
+	}
+	if input.Priority != nil {
+		param.Priority = *input.Priority
+	}
+
+	return r.app.TaskService.CreateTask(ctx, param)
+}
+
+// UpdateTask .
+func (r *mutationResolver) UpdateTask(ctx context.Context, id int64, input UpdateTaskInput) (*domain.Task, error) {
+
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, invalidParameter("the name is required")
+	}
+
+	return r.app.TaskService.UpdateTask(ctx, domain.Task{
+		ID:     id,
+		Name:   input.Name,
+		Status: input.Status,
+	})
+}
+
+// DeleteTask .
+func (r *mutationResolver) DeleteTask(ctx context.Context, id int64) (int64, error) {
+
+	err := r.app.TaskService.DeleteTaskByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// DueAt .
+func (r *taskResolver) DueAt(ctx context.Context, obj *domain.Task) (*time.Time, error) {
+	return obj.DueAt.Ptr(), nil
+}
+
+// Reminders are loaded by the dataloader, so that the reminders of all tasks in a request are queried at once.
+func (r *taskResolver) Reminders(ctx context.Context, obj *domain.Task) ([]domain.Reminder, error) {
+
+	reminders, err := loadersFromContext(ctx, r.app).reminders.Load(ctx, obj.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Occurrences .
+func (r *taskResolver) Occurrences(ctx context.Context, obj *domain.Task, count *int) ([]time.Time, error) {
+
+	n := 0
+	if count != nil {
+		n = *count
+	}
+
+	occurrences, err := r.app.TaskService.UpcomingOccurrences(*obj, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+
+	return occurrences, nil
+}
+
+// Tags are loaded by the dataloader like the reminders.
+func (r *taskResolver) Tags(ctx context.Context, obj *domain.Task) ([]string, error) {
+	return loadersFromContext(ctx, r.app).tags.Load(ctx, obj.ID)()
+}
+
+// Subtasks are loaded by the dataloader like the reminders.
+func (r *taskResolver) Subtasks(ctx context.Context, obj *domain.Task) ([]domain.Subtask, error) {
+	return loadersFromContext(ctx, r.app).subtasks.Load(ctx, obj.ID)()
+}
+
+// History is loaded by the dataloader like the reminders.
+func (r *taskResolver) History(ctx context.Context, obj *domain.Task) ([]domain.TaskHistory, error) {
+	return loadersFromContext(ctx, r.app).history.Load(ctx, obj.ID)()
+}
+
+// RemindAt .
+func (r *reminderResolver) RemindAt(ctx context.Context, obj *domain.Reminder) (*time.Time, error) {
+	return obj.RemindAt.Ptr(), nil
+}
+
+// OffsetSeconds .
+func (r *reminderResolver) OffsetSeconds(ctx context.Context, obj *domain.Reminder) (*int, error) {
+
+	if !obj.Offset.Valid {
+		return nil, nil
+	}
+
+	offset := int(obj.Offset.Int64)
+
+	return &offset, nil
+}
+
+// Channel .
+func (r *reminderResolver) Channel(ctx context.Context, obj *domain.Reminder) (string, error) {
+	return string(obj.Channel), nil
+}
+
+// SentAt .
+func (r *reminderResolver) SentAt(ctx context.Context, obj *domain.Reminder) (*time.Time, error) {
+	return obj.SentAt.Ptr(), nil
+}
+
+// UpdatedAt .
+func (r *subtaskResolver) UpdatedAt(ctx context.Context, obj *domain.Subtask) (*time.Time, error) {
+	if obj.UpdatedAt.IsZero() {
+		return nil, nil
+	}
+	return &obj.UpdatedAt, nil
+}
+
+// Action .
+func (r *taskHistoryResolver) Action(ctx context.Context, obj *domain.TaskHistory) (string, error) {
+	return string(obj.Action), nil
+}
+
+// DueAt .
+func (r *taskHistoryResolver) DueAt(ctx context.Context, obj *domain.TaskHistory) (*time.Time, error) {
+	return obj.DueAt.Ptr(), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Reminder returns ReminderResolver implementation.
+func (r *Resolver) Reminder() ReminderResolver { return &reminderResolver{r} }
+
+// Subtask returns SubtaskResolver implementation.
+func (r *Resolver) Subtask() SubtaskResolver { return &subtaskResolver{r} }
+
+// Task returns TaskResolver implementation.
+func (r *Resolver) Task() TaskResolver { return &taskResolver{r} }
+
+// TaskHistory returns TaskHistoryResolver implementation.
+func (r *Resolver) TaskHistory() TaskHistoryResolver { return &taskHistoryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type reminderResolver struct{ *Resolver }
+type subtaskResolver struct{ *Resolver }
+type taskResolver struct{ *Resolver }
+type taskHistoryResolver struct{ *Resolver }
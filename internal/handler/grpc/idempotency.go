@@ -0,0 +1,217 @@
+// Package grpc provides the gRPC TaskService on top of the application, the messages are
+// defined in proto/gogolook/task/v1 and generated into taskpb by buf.
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+	"unicode"
+
+	"github.com/tingchima/gogolook/infra/idempotency"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/handler/grpc/taskpb"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// idempotency metadata keys, the same as the HTTP headers
+const (
+	IdempotencyKeyMetadata     = "idempotency-key"
+	IdempotentReplayedMetadata = "idempotent-replayed"
+)
+
+// protoContentType of the stored responses, so that they are not mixed up with the HTTP ones.
+const protoContentType = "application/grpc+proto"
+
+// maxIdempotencyKeyLength .
+const maxIdempotencyKeyLength = 255
+
+// ErrInvalidIdempotencyKey .
+var ErrInvalidIdempotencyKey = errors.New("the idempotency key should be 1 to 255 printable characters")
+
+// idempotentMethods accept the idempotency-key metadata, the value is the response message of method.
+var idempotentMethods = map[string]func() proto.Message{
+	taskpb.TaskService_CreateTask_FullMethodName: func() proto.Message { return new(taskpb.CreateTaskResponse) },
+}
+
+// IdempotencyParam .
+type IdempotencyParam struct {
+	Store idempotency.Store
+	// 保留回應的時間, 到期後可重新使用此 key
+	TTL time.Duration
+	// 處理中的 key 於此時間後視為中斷, 應大於請求的處理時間
+	LockTimeout time.Duration
+}
+
+// IdempotencyUnary replays the response of the calls which are retried with the same idempotency-key
+// metadata, the keys are scoped by principal and shared with the HTTP requests. The server errors
+// are not stored so that they can be retried, and the calls are processed without the key if the store fails.
+func IdempotencyUnary(param IdempotencyParam) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+
+		newResponse, ok := idempotentMethods[info.FullMethod]
+		key := firstMetadata(ctx, IdempotencyKeyMetadata)
+		if !ok || key == "" {
+			return handler(ctx, req)
+		}
+
+		if !validIdempotencyKey(key) {
+			return nil, statusError(invalidParameter(ErrInvalidIdempotencyKey.Error()))
+		}
+
+		fingerprint, err := callFingerprint(info.FullMethod, req)
+		if err != nil {
+			return nil, statusError(common.NewError(common.ErrCodeInternalProcess, err))
+		}
+
+		storeKey := requestPrincipal(ctx) + "|" + key
+
+		stored, lockToken, err := param.Store.Begin(ctx, storeKey, fingerprint, param.LockTimeout)
+		switch {
+		case errors.Is(err, idempotency.ErrInFlight):
+			return nil, statusError(common.NewError(common.ErrCodeResourceAlreadyExisted, err, common.WithMsg(err.Error())))
+		case errors.Is(err, idempotency.ErrFingerprintMismatch):
+			return nil, statusError(common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+		case err != nil:
+			slog.WarnContext(ctx, "begin idempotency key fail", "err", err)
+			return handler(ctx, req)
+		case stored != nil:
+			_ = grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedMetadata, "true"))
+			return replayResponse(*stored, newResponse())
+		}
+
+		// the key is released unless the response is stored, including when the handler panics
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := param.Store.Release(context.WithoutCancel(ctx), storeKey, lockToken); err != nil {
+				slog.WarnContext(ctx, "release idempotency key fail", "err", err)
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+
+		if retryableCode(status.Code(err)) {
+			return resp, err
+		}
+
+		storedResp, marshalErr := storeResponse(resp, err)
+		if marshalErr != nil {
+			slog.WarnContext(ctx, "save idempotency key fail", "err", marshalErr)
+			return resp, err
+		}
+
+		// the response should be kept even if the client has gone
+		if saveErr := param.Store.Complete(context.WithoutCancel(ctx), storeKey, lockToken, storedResp, param.TTL); saveErr != nil {
+			slog.WarnContext(ctx, "save idempotency key fail", "err", saveErr)
+			return resp, err
+		}
+		completed = true
+
+		return resp, err
+	}
+}
+
+// validIdempotencyKey .
+func validIdempotencyKey(key string) bool {
+
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// callFingerprint hashes the method and the deterministic encoding of request.
+func callFingerprint(method string, req any) (string, error) {
+
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", errors.New("the request is not a proto message")
+	}
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(method + "\n"))
+	_, _ = h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// retryableCode are the server errors, they are not stored like the 5xx HTTP responses.
+func retryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Canceled, codes.Unknown, codes.DeadlineExceeded, codes.Aborted,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// storeResponse encodes the response message, or the status of the client error.
+func storeResponse(resp any, err error) (idempotency.Response, error) {
+
+	var (
+		msg  proto.Message
+		code = codes.OK
+	)
+
+	if err != nil {
+		st := status.Convert(err)
+		msg, code = st.Proto(), st.Code()
+	} else if m, ok := resp.(proto.Message); ok {
+		msg = m
+	} else {
+		return idempotency.Response{}, errors.New("the response is not a proto message")
+	}
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return idempotency.Response{}, err
+	}
+
+	return idempotency.Response{StatusCode: int(code), ContentType: protoContentType, Body: body}, nil
+}
+
+// replayResponse decodes the stored response into resp, or returns the stored status.
+func replayResponse(stored idempotency.Response, resp proto.Message) (any, error) {
+
+	if stored.ContentType != protoContentType {
+		err := idempotency.ErrFingerprintMismatch
+		return nil, statusError(common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+	}
+
+	if codes.Code(stored.StatusCode) != codes.OK {
+		var st spb.Status
+		if err := proto.Unmarshal(stored.Body, &st); err != nil {
+			return nil, statusError(common.NewError(common.ErrCodeInternalProcess, err))
+		}
+		return nil, status.ErrorProto(&st)
+	}
+
+	if err := proto.Unmarshal(stored.Body, resp); err != nil {
+		return nil, statusError(common.NewError(common.ErrCodeInternalProcess, err))
+	}
+
+	return resp, nil
+}
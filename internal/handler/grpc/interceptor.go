@@ -0,0 +1,385 @@
+// Package grpc provides the gRPC TaskService on top of the application, the messages are
+// defined in proto/gogolook/task/v1 and generated into taskpb by buf.
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tingchima/gogolook/infra/logger"
+	"github.com/tingchima/gogolook/infra/ratelimit"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/handler/grpc/taskpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// metadata keys
+const (
+	RequestIDMetadata     = "x-request-id"
+	APIKeyMetadata        = "x-api-key"
+	AuthorizationMetadata = "authorization"
+)
+
+// rate limit metadata keys, the same as the HTTP headers
+const (
+	RateLimitLimitMetadata     = "ratelimit-limit"
+	RateLimitRemainingMetadata = "ratelimit-remaining"
+	RateLimitResetMetadata     = "ratelimit-reset"
+	RetryAfterMetadata         = "retry-after"
+)
+
+// ErrRateLimited .
+var ErrRateLimited = errors.New("too many requests, retry later")
+
+// ErrRateLimitUnavailable .
+var ErrRateLimitUnavailable = errors.New("the rate limit is unavailable, retry later")
+
+// readOnlyMethods are allowed when the rate limit store fails, and the other methods start the sticky primary read.
+var readOnlyMethods = map[string]bool{
+	taskpb.TaskService_ListTasks_FullMethodName: true,
+	taskpb.TaskService_GetTask_FullMethodName:   true,
+}
+
+// maxRequestIDLength .
+const maxRequestIDLength = 128
+
+// wrappedStream replaces the context of stream.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context .
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
+
+// RequestIDUnary uses the x-request-id metadata of call or generates one, it is sent back in the header.
+func RequestIDUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+// RequestIDStream .
+func RequestIDStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+// withRequestID .
+func withRequestID(ctx context.Context) context.Context {
+
+	requestID := firstMetadata(ctx, RequestIDMetadata)
+	if !validRequestID(requestID) {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		requestID = hex.EncodeToString(b)
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+
+	return logger.WithRequestID(ctx, requestID)
+}
+
+// validRequestID only accepts the printable ASCII characters, so that the ID can not break the logs.
+func validRequestID(requestID string) bool {
+
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AccessLogUnary logs every call after it is handled.
+func AccessLogUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// AccessLogStream .
+func AccessLogStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		logCall(ss.Context(), info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// logCall logs the server errors at error level, the client errors at warn level.
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	attrs := []any{
+		"method", method,
+		"code", code.String(),
+		"latency", time.Since(start),
+	}
+	// the full chain of domain error is logged, the client only gets the client message
+	var appErr *common.Error
+	switch {
+	case common.AsErr(err, &appErr):
+		attrs = append(attrs, "err", appErr)
+	case err != nil:
+		attrs = append(attrs, "err", err)
+	}
+
+	slog.Log(ctx, level, "grpc call", attrs...)
+}
+
+// RecoveryUnary responds Internal instead of crashing the server when the handler panics.
+func RecoveryUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStream .
+func RecoveryStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// recovered .
+func recovered(ctx context.Context, method string, r any) error {
+
+	slog.ErrorContext(ctx, "panic recovered", "method", method, "panic", r, "stack", string(debug.Stack()))
+
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// APIKeyAuthUnary accepts the API key from the x-api-key metadata or "authorization: Bearer <key>".
+func APIKeyAuthUnary(apiKeys []string) grpc.UnaryServerInterceptor {
+
+	valid := apiKeyValidator(apiKeys)
+
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		key := requestAPIKey(ctx)
+		if !valid(key) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or missing api key")
+		}
+
+		return handler(withPrincipal(ctx, ratelimit.APIKeyPrincipal(key)), req)
+	}
+}
+
+// APIKeyAuthStream .
+func APIKeyAuthStream(apiKeys []string) grpc.StreamServerInterceptor {
+
+	valid := apiKeyValidator(apiKeys)
+
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		key := requestAPIKey(ss.Context())
+		if !valid(key) {
+			return status.Error(codes.Unauthenticated, "invalid or missing api key")
+		}
+
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withPrincipal(ss.Context(), ratelimit.APIKeyPrincipal(key))})
+	}
+}
+
+// apiKeyValidator compares the digests so that the length of keys does not leak by timing.
+func apiKeyValidator(apiKeys []string) func(key string) bool {
+
+	digests := make([][sha256.Size]byte, len(apiKeys))
+	for i := range apiKeys {
+		digests[i] = sha256.Sum256([]byte(apiKeys[i]))
+	}
+
+	return func(key string) bool {
+		if key == "" {
+			return false
+		}
+
+		digest := sha256.Sum256([]byte(key))
+
+		ok := 0
+		for i := range digests {
+			ok |= subtle.ConstantTimeCompare(digest[:], digests[i][:])
+		}
+		return ok == 1
+	}
+}
+
+// principalKey is the context key of the authenticated principal.
+type principalKey struct{}
+
+// withPrincipal .
+func withPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// requestPrincipal falls back to the peer IP if the call is not authenticated,
+// the principals are the same as the HTTP requests, so that a client shares its buckets.
+func requestPrincipal(ctx context.Context) string {
+
+	if principal, _ := ctx.Value(principalKey{}).(string); principal != "" {
+		return principal
+	}
+
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+	}
+
+	return ratelimit.PrincipalIP + ":" + addr
+}
+
+// RateLimitUnary limits the calls by full method and principal, e.g. a rule of route
+// "/gogolook.task.v1.TaskService/CreateTask". If the store fails, the reads are allowed
+// and the writes are rejected.
+func RateLimitUnary(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		result, limited, err := limiter.Allow(ctx, info.FullMethod, requestPrincipal(ctx))
+		if err != nil {
+			slog.WarnContext(ctx, "rate limit fail", "err", err)
+
+			if !readOnlyMethods[info.FullMethod] {
+				err := ErrRateLimitUnavailable
+				return nil, statusError(common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg(err.Error())))
+			}
+		}
+
+		if !limited {
+			return handler(ctx, req)
+		}
+
+		md := metadata.Pairs(
+			RateLimitLimitMetadata, strconv.Itoa(result.Limit),
+			RateLimitRemainingMetadata, strconv.Itoa(result.Remaining),
+			RateLimitResetMetadata, strconv.Itoa(ceilSeconds(result.Reset)),
+		)
+
+		if !result.Allowed {
+			md.Set(RetryAfterMetadata, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			_ = grpc.SetHeader(ctx, md)
+
+			err := ErrRateLimited
+			return nil, statusError(common.NewError(common.ErrCodeTooManyRequests, err, common.WithMsg(err.Error())))
+		}
+
+		_ = grpc.SetHeader(ctx, md)
+
+		return handler(ctx, req)
+	}
+}
+
+// StickyPrimaryUnary reads from the primary database within the replica lag after a write call
+// of the principal, the window is also started before the write as the client may call again
+// once the response is sent.
+func StickyPrimaryUnary(sticky *common.StickyPrimary) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		principal := requestPrincipal(ctx)
+
+		write := !readOnlyMethods[info.FullMethod]
+		if write {
+			sticky.MarkWrite(principal)
+		}
+
+		resp, err := handler(sticky.Context(ctx, principal), req)
+
+		if write {
+			sticky.MarkWrite(principal)
+		}
+
+		return resp, err
+	}
+}
+
+// ceilSeconds .
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// requestAPIKey .
+func requestAPIKey(ctx context.Context) string {
+
+	if key := firstMetadata(ctx, APIKeyMetadata); key != "" {
+		return key
+	}
+
+	authorization := firstMetadata(ctx, AuthorizationMetadata)
+	if scheme, key, ok := strings.Cut(authorization, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return key
+	}
+
+	return ""
+}
+
+// firstMetadata .
+func firstMetadata(ctx context.Context, key string) string {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
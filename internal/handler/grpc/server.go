@@ -0,0 +1,279 @@
+// Package grpc provides the gRPC TaskService on top of the application, the messages are
+// defined in proto/gogolook/task/v1 and generated into taskpb by buf.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tingchima/gogolook/infra/ratelimit"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/handler/grpc/taskpb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/guregu/null.v4"
+)
+
+// ServerParam .
+type ServerParam struct {
+	App *application.Application
+	// APIKeys authenticates the calls by the x-api-key or authorization metadata if it is not empty
+	APIKeys []string
+	// Reflection lets the clients like grpcurl list the services
+	Reflection bool
+	// Limiter limits the unary calls by method and principal if it is not nil, it is shared with the HTTP requests
+	Limiter *ratelimit.Limiter
+	// Idempotency replays CreateTask retried with the same idempotency-key metadata if Store is not nil
+	Idempotency IdempotencyParam
+	// StickyPrimary reads from the primary database after a write of the principal if it is not nil
+	StickyPrimary *common.StickyPrimary
+}
+
+// NewServer returns the server with TaskService registered, the calls are traced, logged and recovered.
+func NewServer(param ServerParam) *grpc.Server {
+
+	unary := []grpc.UnaryServerInterceptor{RequestIDUnary(), AccessLogUnary(), RecoveryUnary()}
+	stream := []grpc.StreamServerInterceptor{RequestIDStream(), AccessLogStream(), RecoveryStream()}
+
+	if len(param.APIKeys) > 0 {
+		unary = append(unary, APIKeyAuthUnary(param.APIKeys))
+		stream = append(stream, APIKeyAuthStream(param.APIKeys))
+	}
+
+	// after the auth, so that the authenticated clients are limited by their API keys
+	if param.Limiter != nil {
+		unary = append(unary, RateLimitUnary(param.Limiter))
+	}
+
+	// after the auth, so that the windows are tracked by the principal
+	if param.StickyPrimary != nil {
+		unary = append(unary, StickyPrimaryUnary(param.StickyPrimary))
+	}
+
+	// after the auth, so that the keys are scoped by the principal
+	if param.Idempotency.Store != nil {
+		unary = append(unary, IdempotencyUnary(param.Idempotency))
+	}
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+
+	taskpb.RegisterTaskServiceServer(server, NewTaskServer(param.App))
+
+	if param.Reflection {
+		reflection.Register(server)
+	}
+
+	return server
+}
+
+// TaskServer .
+type TaskServer struct {
+	taskpb.UnimplementedTaskServiceServer
+
+	app *application.Application
+}
+
+// NewTaskServer .
+func NewTaskServer(app *application.Application) *TaskServer {
+	return &TaskServer{app: app}
+}
+
+// ListTasks .
+func (s *TaskServer) ListTasks(ctx context.Context, req *taskpb.ListTasksRequest) (*taskpb.ListTasksResponse, error) {
+
+	tasks, err := s.app.TaskService.ListTasks(ctx, taskParam(req.GetStatus(), req.GetName()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &taskpb.ListTasksResponse{Tasks: make([]*taskpb.Task, len(tasks))}
+	for i := range tasks {
+		resp.Tasks[i] = newTask(tasks[i])
+	}
+
+	return resp, nil
+}
+
+// GetTask .
+func (s *TaskServer) GetTask(ctx context.Context, req *taskpb.GetTaskRequest) (*taskpb.GetTaskResponse, error) {
+
+	task, err := s.app.TaskService.GetTaskByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &taskpb.GetTaskResponse{Task: newTask(*task)}, nil
+}
+
+// CreateTask .
+func (s *TaskServer) CreateTask(ctx context.Context, req *taskpb.CreateTaskRequest) (*taskpb.CreateTaskResponse, error) {
+
+	if strings.TrimSpace(req.GetName()) == "" {
+		return nil, statusError(invalidParameter("the name is required"))
+	}
+
+	param := domain.Task{
+		Name:     req.GetName(),
+		RRule:    req.GetRrule(),
+		Timezone: req.GetTimezone(),
+		Priority: int(req.GetPriority()),
+	}
+
+	if req.GetDueAt() != nil {
+		param.DueAt = null.TimeFrom(req.GetDueAt().AsTime())
+	}
+
+	createdTask, err := s.app.TaskService.CreateTask(ctx, param)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &taskpb.CreateTaskResponse{Task: newTask(*createdTask)}, nil
+}
+
+// UpdateTask .
+func (s *TaskServer) UpdateTask(ctx context.Context, req *taskpb.UpdateTaskRequest) (*taskpb.UpdateTaskResponse, error) {
+
+	if strings.TrimSpace(req.GetName()) == "" {
+		return nil, statusError(invalidParameter("the name is required"))
+	}
+
+	updatedTask, err := s.app.TaskService.UpdateTask(ctx, domain.Task{
+		ID:     req.GetId(),
+		Name:   req.GetName(),
+		Status: req.GetStatus(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &taskpb.UpdateTaskResponse{Task: newTask(*updatedTask)}, nil
+}
+
+// DeleteTask .
+func (s *TaskServer) DeleteTask(ctx context.Context, req *taskpb.DeleteTaskRequest) (*taskpb.DeleteTaskResponse, error) {
+
+	err := s.app.TaskService.DeleteTaskByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &taskpb.DeleteTaskResponse{}, nil
+}
+
+// WatchTasks sends the missed events after last_event_id first, the stream ends with Unavailable
+// when the server is shutting down or the client is too slow, the client should watch again.
+func (s *TaskServer) WatchTasks(req *taskpb.WatchTasksRequest, stream taskpb.TaskService_WatchTasksServer) error {
+
+	if req.GetLastEventId() < 0 {
+		return statusError(invalidParameter("the last_event_id should not be negative"))
+	}
+
+	ctx := stream.Context()
+
+	sub, replay, complete := s.app.TaskService.SubscribeTaskEvents(taskParam(req.GetStatus(), req.GetName()), req.GetLastEventId())
+	defer sub.Close()
+
+	if !complete {
+		err := stream.Send(&taskpb.WatchTasksResponse{Type: taskpb.WatchTasksResponse_TYPE_RESET})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range replay {
+		err := stream.Send(newTaskEvent(replay[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "the task event stream is closed, please watch again")
+			}
+
+			err := stream.Send(newTaskEvent(event))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// invalidParameter .
+func invalidParameter(msg string) error {
+	return common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+}
+
+// taskParam .
+func taskParam(taskStatus *wrapperspb.BoolValue, name string) domain.TaskParam {
+
+	param := domain.TaskParam{Name: name}
+	if taskStatus != nil {
+		param.Status = null.BoolFrom(taskStatus.GetValue())
+	}
+
+	return param
+}
+
+// newTask .
+func newTask(task domain.Task) *taskpb.Task {
+
+	resp := &taskpb.Task{
+		Id:         task.ID,
+		Name:       task.Name,
+		Status:     task.Status,
+		Rrule:      task.RRule,
+		Timezone:   task.Timezone,
+		Priority:   int32(task.Priority),
+		ExternalId: task.ExternalID,
+	}
+
+	if task.DueAt.Valid {
+		resp.DueAt = timestamppb.New(task.DueAt.Time)
+	}
+	if !task.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(task.CreatedAt)
+	}
+	if !task.UpdatedAt.IsZero() {
+		resp.UpdatedAt = timestamppb.New(task.UpdatedAt)
+	}
+
+	return resp
+}
+
+// taskEventTypes .
+var taskEventTypes = map[domain.TaskEventType]taskpb.WatchTasksResponse_Type{
+	domain.TaskEventCreated: taskpb.WatchTasksResponse_TYPE_CREATED,
+	domain.TaskEventUpdated: taskpb.WatchTasksResponse_TYPE_UPDATED,
+	domain.TaskEventDeleted: taskpb.WatchTasksResponse_TYPE_DELETED,
+	domain.TaskEventRemoved: taskpb.WatchTasksResponse_TYPE_REMOVED,
+}
+
+// newTaskEvent .
+func newTaskEvent(event domain.TaskEvent) *taskpb.WatchTasksResponse {
+	return &taskpb.WatchTasksResponse{
+		Id:         event.ID,
+		Type:       taskEventTypes[event.Type],
+		Task:       newTask(event.Task),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
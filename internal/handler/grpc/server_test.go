@@ -0,0 +1,288 @@
+// Package grpc provides the gRPC TaskService on top of the application, the messages are
+// defined in proto/gogolook/task/v1 and generated into taskpb by buf.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/infra/idempotency"
+	"github.com/tingchima/gogolook/infra/ratelimit"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/application/task/mocks"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/handler/grpc/taskpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testAPIKey .
+const testAPIKey = "0123456789abcdef"
+
+// startServer serves the TaskService of repo in memory, it is stopped when the test finishes.
+func startServer(t *testing.T, repo task.Repository) taskpb.TaskServiceClient {
+	return startServerWithParam(t, repo, ServerParam{})
+}
+
+// startServerWithParam serves with the limiter and the idempotency of param.
+func startServerWithParam(t *testing.T, repo task.Repository, param ServerParam) taskpb.TaskServiceClient {
+
+	param.App = &application.Application{
+		TaskService: task.NewService(task.ServiceParam{PostgresRepo: repo}),
+	}
+	param.APIKeys = []string{testAPIKey}
+
+	server := NewServer(param)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return taskpb.NewTaskServiceClient(conn)
+}
+
+// authContext .
+func authContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, testAPIKey)
+}
+
+// TestTaskServer .
+func TestTaskServer(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockRepository(ctrl)
+	client := startServer(t, repo)
+
+	now := time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC)
+
+	repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, param domain.Task) (*domain.Task, error) {
+		param.ID = 1
+		param.CreatedAt = now
+		param.UpdatedAt = now
+		return &param, nil
+	}).Times(2)
+
+	repo.EXPECT().GetTaskByID(gomock.Any(), int64(2)).
+		Return(nil, common.NewError(common.ErrCodeResourceNotFound, errors.New("task not found"), common.WithMsg("task not found")))
+
+	// unauthenticated
+	_, err := client.CreateTask(context.Background(), &taskpb.CreateTaskRequest{Name: "task"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// invalid parameter
+	_, err = client.CreateTask(authContext(), &taskpb.CreateTaskRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	created, err := client.CreateTask(authContext(), &taskpb.CreateTaskRequest{Name: "task", Priority: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.GetTask().GetId())
+	assert.Equal(t, "task", created.GetTask().GetName())
+	assert.Equal(t, int32(1), created.GetTask().GetPriority())
+	assert.Nil(t, created.GetTask().GetDueAt())
+	assert.Equal(t, now, created.GetTask().GetCreatedAt().AsTime())
+
+	// the error name is the reason of ErrorInfo
+	_, err = client.GetTask(authContext(), &taskpb.GetTaskRequest{Id: 2})
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "task not found", st.Message())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, common.ErrCodeResourceNotFound.Name, st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+
+	// the events after last_event_id are replayed
+	_, err = client.CreateTask(authContext(), &taskpb.CreateTaskRequest{Name: "next"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(authContext())
+	defer cancel()
+
+	stream, err := client.WatchTasks(ctx, &taskpb.WatchTasksRequest{LastEventId: 1})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), event.GetId())
+	assert.Equal(t, taskpb.WatchTasksResponse_TYPE_CREATED, event.GetType())
+	assert.Equal(t, "next", event.GetTask().GetName())
+}
+
+// TestRateLimitUnary .
+func TestRateLimitUnary(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockRepository(ctrl)
+
+	// the bucket of API key is the same one as the HTTP requests
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), []ratelimit.Rule{
+		{Route: taskpb.TaskService_GetTask_FullMethodName, Principal: ratelimit.PrincipalAPIKey, Limit: ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1}},
+	})
+	require.NoError(t, err)
+
+	client := startServerWithParam(t, repo, ServerParam{Limiter: limiter})
+
+	repo.EXPECT().GetTaskByID(gomock.Any(), int64(1)).Return(&domain.Task{ID: 1}, nil).Times(1)
+
+	var header metadata.MD
+
+	_, err = client.GetTask(authContext(), &taskpb.GetTaskRequest{Id: 1}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0"}, header.Get(RateLimitRemainingMetadata))
+
+	_, err = client.GetTask(authContext(), &taskpb.GetTaskRequest{Id: 1}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"3600"}, header.Get(RetryAfterMetadata))
+
+	// the other methods are not limited by the rule
+	repo.EXPECT().ListTasks(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	_, err = client.ListTasks(authContext(), &taskpb.ListTasksRequest{})
+	require.NoError(t, err)
+}
+
+// TestIdempotencyUnary .
+func TestIdempotencyUnary(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockRepository(ctrl)
+
+	client := startServerWithParam(t, repo, ServerParam{Idempotency: IdempotencyParam{
+		Store:       idempotency.NewMemoryStore(),
+		TTL:         time.Hour,
+		LockTimeout: time.Minute,
+	}})
+
+	// the task is created once for the retries
+	repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, param domain.Task) (*domain.Task, error) {
+		param.ID = 1
+		return &param, nil
+	}).Times(1)
+
+	ctx := metadata.AppendToOutgoingContext(authContext(), IdempotencyKeyMetadata, "key-1")
+
+	var header metadata.MD
+
+	created, err := client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "task"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Empty(t, header.Get(IdempotentReplayedMetadata))
+
+	replayed, err := client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "task"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"true"}, header.Get(IdempotentReplayedMetadata))
+	assert.Equal(t, created.GetTask().GetId(), replayed.GetTask().GetId())
+
+	// the key is reused for a different request
+	_, err = client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "other"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the server error is not stored, the call can be retried with the same key
+	ctx = metadata.AppendToOutgoingContext(authContext(), IdempotencyKeyMetadata, "key-2")
+
+	gomock.InOrder(
+		repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused")),
+		repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Return(&domain.Task{ID: 2, Name: "task"}, nil),
+	)
+
+	_, err = client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "task"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	created, err = client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "task"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), created.GetTask().GetId())
+
+	// the key is released when the handler panics
+	ctx = metadata.AppendToOutgoingContext(authContext(), IdempotencyKeyMetadata, "key-3")
+
+	gomock.InOrder(
+		repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Do(func(context.Context, domain.Task) { panic("boom") }),
+		repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Return(&domain.Task{ID: 3, Name: "task"}, nil),
+	)
+
+	_, err = client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "task"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	created, err = client.CreateTask(ctx, &taskpb.CreateTaskRequest{Name: "task"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), created.GetTask().GetId())
+}
+
+// TestStatusError .
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{
+			name:    "invalid parameter",
+			err:     common.NewError(common.ErrCodeInvalidParameter, errors.New("bad"), common.WithMsg("bad")),
+			code:    codes.InvalidArgument,
+			message: "bad",
+		},
+		{
+			name:    "already existed",
+			err:     common.NewError(common.ErrCodeResourceAlreadyExisted, errors.New("dup"), common.WithMsg("dup")),
+			code:    codes.AlreadyExists,
+			message: "dup",
+		},
+		{
+			name:    "precondition failed",
+			err:     common.NewError(common.ErrCodePreconditionFailed, errors.New("changed"), common.WithMsg("changed")),
+			code:    codes.FailedPrecondition,
+			message: "changed",
+		},
+		{
+			name:    "service unavailable",
+			err:     common.NewError(common.ErrCodeServiceUnavailable, errors.New("timeout"), common.WithMsg("retry later")),
+			code:    codes.Unavailable,
+			message: "retry later",
+		},
+		{
+			// the cause is not responded
+			name:    "unknown error",
+			err:     errors.New("boom"),
+			code:    codes.Internal,
+			message: "internal error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(statusError(tt.err))
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package grpc provides the gRPC TaskService on top of the application, the messages are
+// defined in proto/gogolook/task/v1 and generated into taskpb by buf.
+package grpc
+
+import (
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain of ErrorInfo.
+const errorDomain = "gogolook"
+
+// statusCodes maps the ErrCode names to the gRPC codes, the unknown names are Internal.
+var statusCodes = map[string]codes.Code{
+	common.ErrCodeInvalidParameter.Name:       codes.InvalidArgument,
+	common.ErrCodeUnauthorized.Name:           codes.Unauthenticated,
+	common.ErrCodeAccessNotAllowed.Name:       codes.PermissionDenied,
+	common.ErrCodeResourceNotFound.Name:       codes.NotFound,
+	common.ErrCodeMethodNotAllowed.Name:       codes.Unimplemented,
+	common.ErrCodeResourceAlreadyExisted.Name: codes.AlreadyExists,
+	common.ErrCodePreconditionFailed.Name:     codes.FailedPrecondition,
+	common.ErrCodeRequestTooLarge.Name:        codes.ResourceExhausted,
+	common.ErrCodeUnprocessableEntity.Name:    codes.InvalidArgument,
+	common.ErrCodeTooManyRequests.Name:        codes.ResourceExhausted,
+	common.ErrCodeInternalProcess.Name:        codes.Internal,
+	common.ErrCodeServiceUnavailable.Name:     codes.Unavailable,
+}
+
+// statusError converts err to the gRPC status with the client message of error,
+// the ErrCode name is the reason of ErrorInfo detail.
+func statusError(err error) error {
+
+	var appErr *common.Error
+	_ = common.AsErr(err, &appErr)
+
+	if appErr == nil {
+		appErr = common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	code, ok := statusCodes[appErr.Name()]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, appErr.ClientMsg())
+
+	withDetails, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: appErr.Name(),
+		Domain: errorDomain,
+	})
+	if detailErr == nil {
+		st = withDetails
+	}
+
+	return &appStatusError{st: st, appErr: appErr}
+}
+
+// appStatusError is the gRPC status of domain error, the domain error is kept for the access log.
+type appStatusError struct {
+	st     *status.Status
+	appErr *common.Error
+}
+
+// Error .
+func (e *appStatusError) Error() string {
+	return e.st.Err().Error()
+}
+
+// GRPCStatus .
+func (e *appStatusError) GRPCStatus() *status.Status {
+	return e.st
+}
+
+// Unwrap .
+func (e *appStatusError) Unwrap() error {
+	return e.appErr
+}
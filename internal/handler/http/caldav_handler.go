@@ -0,0 +1,712 @@
+// Package http provides
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/ical"
+)
+
+// CalDAV (RFC 4791) paths, the principal and the calendar home are the root,
+// the tasks are the only calendar collection.
+const (
+	CalDAVRoot       = "/caldav/"
+	CalDAVCollection = "/caldav/tasks/"
+)
+
+// CalDAV methods
+const (
+	MethodPropfind = "PROPFIND"
+	MethodReport   = "REPORT"
+)
+
+// caldavMaxBodySize .
+const caldavMaxBodySize = 1 << 20
+
+// caldavContentType of the calendar object resources.
+const caldavContentType = "text/calendar; charset=utf-8; component=vtodo"
+
+// caldavTaskUIDPattern matches the UID of tasks not created by calendar clients, see ical.TaskUID.
+var caldavTaskUIDPattern = regexp.MustCompile(`^task-(\d+)@` + regexp.QuoteMeta(ical.UIDDomain) + `$`)
+
+// ErrNotFoundCalendarObject .
+var ErrNotFoundCalendarObject = errors.New("calendar object not found")
+
+// ErrReservedCalendarUID is returned when a client creates a resource with the UID of a task
+// that does not exist, the task would get another ID and so another UID.
+var ErrReservedCalendarUID = errors.New("the UID is reserved for the tasks of server")
+
+// @Summary CalDAV 支援的方法
+// @Router /caldav/ [OPTIONS]
+// @Tags CalDAV
+// @Success 200 {string} string ""
+func CalDAVOptions(_ *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		c.Header("DAV", "1, 3, calendar-access")
+		c.Header("Allow", strings.Join([]string{
+			http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, MethodPropfind, MethodReport,
+		}, ", "))
+
+		responseWithNoContent(c, http.StatusOK)
+	}
+}
+
+// @Summary CalDAV 服務探索, 導向 CalDAV 根目錄
+// @Router /.well-known/caldav [GET]
+// @Tags CalDAV
+// @Success 301 {string} string ""
+func CalDAVWellKnown(_ *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, CalDAVRoot)
+	}
+}
+
+// @Summary CalDAV 查詢屬性 (PROPFIND)
+// @Description Depth 0 只回傳目標本身, Depth 1 包含子資源, infinity 視為 1
+// @Router /caldav/tasks/ [PROPFIND]
+// @Accept xml
+// @Produce xml
+// @Tags CalDAV
+// @Param Depth header string false "0, 1"
+// @Success 207 {string} string "Multi-Status"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CalDAVPropfind(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req davPropfind
+		empty, err := decodeDAVBody(http.MaxBytesReader(c.Writer, c.Request.Body, caldavMaxBodySize), &req)
+		if err != nil {
+			responseWithError(c, requestBodyError(err))
+			return
+		}
+
+		allProp := empty || req.AllProp != nil || req.PropName != nil
+		names := req.Prop.names()
+		depthOne := c.GetHeader("Depth") != "0"
+
+		ms := newMultistatus()
+
+		switch path := c.Request.URL.Path; {
+		case path == CalDAVRoot:
+			ms.addResource(caldavRootResource(), names, allProp)
+
+			if depthOne {
+				tasks, err := app.TaskService.ListTasks(ctx, domain.TaskParam{})
+				if err != nil {
+					responseWithError(c, err)
+					return
+				}
+				ms.addResource(caldavCollectionResource(tasks), names, allProp)
+			}
+
+		case path == CalDAVCollection:
+			tasks, err := app.TaskService.ListTasks(ctx, domain.TaskParam{})
+			if err != nil {
+				responseWithError(c, err)
+				return
+			}
+
+			ms.addResource(caldavCollectionResource(tasks), names, allProp)
+
+			if depthOne {
+				for i := range tasks {
+					resource, err := caldavTaskResource(tasks[i], names, allProp)
+					if err != nil {
+						responseWithError(c, err)
+						return
+					}
+					ms.addResource(resource, names, allProp)
+				}
+			}
+
+		default:
+			task, err := caldavFindTask(ctx, app, c.Param("resource"))
+			if err != nil {
+				responseWithError(c, err)
+				return
+			}
+
+			resource, err := caldavTaskResource(*task, names, allProp)
+			if err != nil {
+				responseWithError(c, err)
+				return
+			}
+			ms.addResource(resource, names, allProp)
+		}
+
+		c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", ms.bytes())
+	}
+}
+
+// @Summary CalDAV 查詢任務 (REPORT calendar-query, calendar-multiget)
+// @Description calendar-query 支援 VTODO 的 comp-filter 與 time-range, 其他條件會被忽略
+// @Router /caldav/tasks/ [REPORT]
+// @Accept xml
+// @Produce xml
+// @Tags CalDAV
+// @Success 207 {string} string "Multi-Status"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 403 {object} ErrResponse "{"code":"400403","message":"Access not allowed"}" "不支援的報表"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CalDAVReport(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req calReport
+		_, err := decodeDAVBody(http.MaxBytesReader(c.Writer, c.Request.Body, caldavMaxBodySize), &req)
+		if err != nil {
+			responseWithError(c, requestBodyError(err))
+			return
+		}
+
+		allProp := req.AllProp != nil || req.Prop == nil
+		names := req.Prop.names()
+
+		ms := newMultistatus()
+
+		switch req.XMLName {
+		case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+			tasks, err := app.TaskService.ListTasks(ctx, domain.TaskParam{})
+			if err != nil {
+				responseWithError(c, err)
+				return
+			}
+
+			match, err := caldavQueryMatcher(req)
+			if err != nil {
+				responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+				return
+			}
+
+			for i := range tasks {
+				if !match(tasks[i]) {
+					continue
+				}
+				resource, err := caldavTaskResource(tasks[i], names, allProp)
+				if err != nil {
+					responseWithError(c, err)
+					return
+				}
+				ms.addResource(resource, names, allProp)
+			}
+
+		case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+			for _, href := range req.Hrefs {
+				task, err := caldavFindTask(ctx, app, caldavResourceName(href))
+				if common.IsErrCode(err, common.ErrCodeResourceNotFound) {
+					ms.addStatus(href, http.StatusNotFound)
+					continue
+				}
+				if err != nil {
+					responseWithError(c, err)
+					return
+				}
+
+				resource, err := caldavTaskResource(*task, names, allProp)
+				if err != nil {
+					responseWithError(c, err)
+					return
+				}
+				ms.addResource(resource, names, allProp)
+			}
+
+		default:
+			msg := fmt.Sprintf("the report %s is not supported", req.XMLName.Local)
+			responseWithError(c, common.NewError(common.ErrCodeAccessNotAllowed, errors.New(msg), common.WithMsg(msg)))
+			return
+		}
+
+		c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", ms.bytes())
+	}
+}
+
+// @Summary CalDAV 取得任務 (iCalendar)
+// @Router /caldav/tasks/:resource [GET]
+// @Produce text/calendar
+// @Tags CalDAV
+// @Param resource path string true "<UID>.ics"
+// @Success 200 {string} string "iCalendar"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CalDAVGet(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		task, err := caldavFindTask(ctx, app, c.Param("resource"))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		etag := caldavETag(*task)
+
+		c.Header("ETag", etag)
+		c.Header("Last-Modified", caldavLastModified(*task).UTC().Format(http.TimeFormat))
+
+		if c.GetHeader("If-None-Match") == etag {
+			responseWithNoContent(c, http.StatusNotModified)
+			return
+		}
+
+		data, err := caldavCalendarData(*task)
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, caldavContentType, data)
+	}
+}
+
+// @Summary CalDAV 建立或取代任務 (iCalendar)
+// @Description 新的資源名稱需為 <UID>.ics; 支援 If-Match 與 If-None-Match: *
+// @Router /caldav/tasks/:resource [PUT]
+// @Accept text/calendar
+// @Tags CalDAV
+// @Param resource path string true "<UID>.ics"
+// @Success 201 {string} string "" Created
+// @Success 204 {string} string "" No Content
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 403 {object} ErrResponse "{"code":"400403","message":"Access not allowed"}" "不支援的元件或保留的 UID"
+// @Failure 412 {object} ErrResponse "{"code":"400412","message":"Precondition failed"}" "ETag 不符"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CalDAVPut(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		name := c.Param("resource")
+
+		param, uid, err := ical.DecodeTask(http.MaxBytesReader(c.Writer, c.Request.Body, caldavMaxBodySize))
+		if err != nil {
+			if errors.Is(err, ical.ErrNoVTodo) {
+				// CALDAV:supported-calendar-component
+				responseWithError(c, common.NewError(common.ErrCodeAccessNotAllowed, err, common.WithMsg(err.Error())))
+				return
+			}
+			responseWithError(c, requestBodyError(err))
+			return
+		}
+
+		if strings.TrimSpace(param.Name) == "" {
+			msg := "the SUMMARY of VTODO is required"
+			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg)))
+			return
+		}
+
+		// the preconditions are checked against the primary, a replica may not have applied the last PUT
+		task, err := caldavFindTask(common.WithPrimaryRead(ctx), app, name)
+		if err != nil && !common.IsErrCode(err, common.ErrCodeResourceNotFound) {
+			responseWithError(c, err)
+			return
+		}
+
+		if err = caldavCheckPreconditions(c, task); err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		// the resource is named by UID, so that it can be found after stored
+		if caldavResourceUID(name) != uid || (task != nil && ical.TaskUID(*task) != uid) {
+			msg := fmt.Sprintf("the resource name should be the UID of VTODO, %s.ics", uid)
+			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg)))
+			return
+		}
+
+		if task == nil {
+			// the created task would be named by its new ID instead of the requested resource
+			if caldavTaskUIDPattern.MatchString(uid) {
+				err := ErrReservedCalendarUID
+				responseWithError(c, common.NewError(common.ErrCodeAccessNotAllowed, err, common.WithMsg(err.Error())))
+				return
+			}
+
+			param.ExternalID = uid
+
+			createdTask, err := app.TaskService.CreateTask(ctx, param)
+			if err != nil {
+				responseWithError(c, caldavPreconditionError(c, err))
+				return
+			}
+
+			c.Header("ETag", caldavETag(*createdTask))
+			responseWithNoContent(c, http.StatusCreated)
+			return
+		}
+
+		param.ID = task.ID
+
+		// the preconditions are checked again with the locked task, so that the task
+		// modified after the check above is not overwritten
+		updatedTask, err := app.TaskService.ReplaceTask(ctx, param, caldavPrecondition(c))
+		if err != nil {
+			responseWithError(c, caldavPreconditionError(c, err))
+			return
+		}
+
+		c.Header("ETag", caldavETag(*updatedTask))
+		responseWithNoContent(c, http.StatusNoContent)
+	}
+}
+
+// @Summary CalDAV 刪除任務
+// @Router /caldav/tasks/:resource [DELETE]
+// @Tags CalDAV
+// @Param resource path string true "<UID>.ics"
+// @Success 204 {string} string "" No Content
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 412 {object} ErrResponse "{"code":"400412","message":"Precondition failed"}" "ETag 不符"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CalDAVDelete(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		// the preconditions are checked against the primary, a replica may not have applied the last PUT
+		task, err := caldavFindTask(common.WithPrimaryRead(ctx), app, c.Param("resource"))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		if err = caldavCheckPreconditions(c, task); err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		err = app.TaskService.DeleteTaskByID(ctx, task.ID, caldavPrecondition(c))
+		if err != nil {
+			responseWithError(c, caldavPreconditionError(c, err))
+			return
+		}
+
+		responseWithNoContent(c, http.StatusNoContent)
+	}
+}
+
+// caldavCheckPreconditions checks If-Match and If-None-Match: *, task is nil if the resource does not exist.
+func caldavCheckPreconditions(c *gin.Context, task *domain.Task) error {
+
+	failed := func(msg string) error {
+		return common.NewError(common.ErrCodePreconditionFailed, errors.New(msg), common.WithMsg(msg))
+	}
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch == "*" && task != nil {
+		return failed("the resource already exists")
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return nil
+	}
+
+	if task == nil {
+		return failed("the resource does not exist")
+	}
+
+	if ifMatch == "*" {
+		return nil
+	}
+
+	etag := caldavETag(*task)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(candidate) == etag {
+			return nil
+		}
+	}
+
+	return failed("the resource has been modified")
+}
+
+// caldavPrecondition checks the preconditions with the task locked by TaskService.
+func caldavPrecondition(c *gin.Context) task.Precondition {
+	return func(current domain.Task) error {
+		return caldavCheckPreconditions(c, &current)
+	}
+}
+
+// caldavPreconditionError maps the conflicts with the concurrent requests to 412,
+// e.g. the resource has been deleted after If-Match is checked.
+func caldavPreconditionError(c *gin.Context, err error) error {
+
+	failed := func(msg string) error {
+		return common.NewError(common.ErrCodePreconditionFailed, err, common.WithMsg(msg))
+	}
+
+	switch {
+	case c.GetHeader("If-Match") != "" && common.IsErrCode(err, common.ErrCodeResourceNotFound):
+		return failed("the resource does not exist")
+	case c.GetHeader("If-None-Match") == "*" && common.IsErrCode(err, common.ErrCodeResourceAlreadyExisted):
+		return failed("the resource already exists")
+	}
+
+	return err
+}
+
+// caldavFindTask finds the task by the resource name, <UID>.ics.
+func caldavFindTask(ctx context.Context, app *application.Application, name string) (*domain.Task, error) {
+
+	notFound := func() error {
+		err := ErrNotFoundCalendarObject
+		return common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+	}
+
+	uid := caldavResourceUID(name)
+	if uid == "" {
+		return nil, notFound()
+	}
+
+	if matches := caldavTaskUIDPattern.FindStringSubmatch(uid); matches != nil {
+		id, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, notFound()
+		}
+
+		task, err := app.TaskService.GetTaskByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// the task is named by its external ID
+		if task.ExternalID != "" {
+			return nil, notFound()
+		}
+
+		return task, nil
+	}
+
+	return app.TaskService.GetTaskByExternalID(ctx, uid)
+}
+
+// caldavResourceUID returns the UID of resource name, empty if it is not <UID>.ics.
+func caldavResourceUID(name string) string {
+	uid, ok := strings.CutSuffix(strings.TrimPrefix(name, "/"), ".ics")
+	if !ok {
+		return ""
+	}
+	return uid
+}
+
+// caldavResourceName returns the resource name of href, e.g. /caldav/tasks/<UID>.ics.
+func caldavResourceName(href string) string {
+
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+
+	name, ok := strings.CutPrefix(href, CalDAVCollection)
+	if !ok || strings.Contains(name, "/") {
+		return ""
+	}
+
+	return name
+}
+
+// caldavHref .
+func caldavHref(task domain.Task) string {
+	return CalDAVCollection + url.PathEscape(ical.TaskUID(task)) + ".ics"
+}
+
+// caldavLastModified .
+func caldavLastModified(task domain.Task) time.Time {
+	if task.UpdatedAt.IsZero() {
+		return task.CreatedAt
+	}
+	return task.UpdatedAt
+}
+
+// caldavETag is derived from the modified time, which is changed on every update.
+func caldavETag(task domain.Task) string {
+	return fmt.Sprintf(`"%d-%d"`, task.ID, caldavLastModified(task).UnixMicro())
+}
+
+// caldavCTag changes whenever a task of collection is created, modified or deleted.
+func caldavCTag(tasks []domain.Task) string {
+
+	hash := sha256.New()
+	for i := range tasks {
+		_, _ = hash.Write([]byte(caldavETag(tasks[i])))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+// caldavCalendarData renders the task as a calendar object resource.
+func caldavCalendarData(task domain.Task) ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	enc := ical.NewEncoder(&buf, ical.EncoderParam{})
+
+	if err := enc.Encode(task); err != nil {
+		return nil, common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// caldavPrivileges of the collection, the calendar is writable.
+const caldavPrivileges = `<d:privilege><d:read/></d:privilege>` +
+	`<d:privilege><d:write/></d:privilege>` +
+	`<d:privilege><d:write-content/></d:privilege>` +
+	`<d:privilege><d:bind/></d:privilege>` +
+	`<d:privilege><d:unbind/></d:privilege>`
+
+// caldavRootResource is the principal and the calendar home.
+func caldavRootResource() davResource {
+	return davResource{
+		href: CalDAVRoot,
+		props: map[xml.Name]string{
+			davPropResourceType:         `<d:collection/><d:principal/>`,
+			davPropDisplayName:          "gogolook",
+			davPropCurrentUserPrincipal: davHref(CalDAVRoot),
+			davPropPrincipalURL:         davHref(CalDAVRoot),
+			calPropCalendarHomeSet:      davHref(CalDAVRoot),
+		},
+	}
+}
+
+// caldavCollectionResource .
+func caldavCollectionResource(tasks []domain.Task) davResource {
+
+	ctag := caldavCTag(tasks)
+
+	return davResource{
+		href: CalDAVCollection,
+		props: map[xml.Name]string{
+			davPropResourceType:          `<d:collection/><c:calendar/>`,
+			davPropDisplayName:           "Tasks",
+			davPropGetETag:               davText(`"` + ctag + `"`),
+			davPropCurrentUserPrincipal:  davHref(CalDAVRoot),
+			davPropCurrentUserPrivileges: caldavPrivileges,
+			davPropSupportedReportSet: `<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>` +
+				`<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>`,
+			calPropSupportedComponentSet: `<c:comp name="VTODO"/>`,
+			calPropSupportedCalendarData: `<c:calendar-data content-type="text/calendar" version="2.0"/>`,
+			csPropGetCTag:                davText(ctag),
+		},
+	}
+}
+
+// caldavTaskResource renders the calendar-data only when it is requested.
+func caldavTaskResource(task domain.Task, names []xml.Name, allProp bool) (davResource, error) {
+
+	resource := davResource{
+		href: caldavHref(task),
+		props: map[xml.Name]string{
+			davPropResourceType:    "",
+			davPropGetETag:         davText(caldavETag(task)),
+			davPropGetContentType:  davText(caldavContentType),
+			davPropGetLastModified: davText(caldavLastModified(task).UTC().Format(http.TimeFormat)),
+		},
+	}
+
+	for _, name := range names {
+		if name != calPropCalendarData || allProp {
+			continue
+		}
+
+		data, err := caldavCalendarData(task)
+		if err != nil {
+			return davResource{}, err
+		}
+		resource.props[calPropCalendarData] = davText(string(data))
+	}
+
+	return resource, nil
+}
+
+// caldavQueryMatcher returns the filter of calendar-query, only VTODO matches and
+// the time-range is compared with the due time, the recurring tasks always match.
+func caldavQueryMatcher(req calReport) (func(task domain.Task) bool, error) {
+
+	matchAll := func(domain.Task) bool { return true }
+
+	if req.Filter == nil {
+		return matchAll, nil
+	}
+
+	calendar := req.Filter.CompFilter
+	if calendar.Name != "" && calendar.Name != "VCALENDAR" {
+		return func(domain.Task) bool { return false }, nil
+	}
+
+	if len(calendar.CompFilters) == 0 {
+		return matchAll, nil
+	}
+
+	var todo *calCompFilter
+	for i := range calendar.CompFilters {
+		if calendar.CompFilters[i].Name == "VTODO" {
+			todo = &calendar.CompFilters[i]
+		}
+	}
+
+	if todo == nil {
+		return func(domain.Task) bool { return false }, nil
+	}
+
+	if todo.TimeRange == nil {
+		return matchAll, nil
+	}
+
+	parse := func(s string) (time.Time, error) {
+		if s == "" {
+			return time.Time{}, nil
+		}
+		return time.Parse("20060102T150405Z", s)
+	}
+
+	start, err := parse(todo.TimeRange.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start of time-range %q", todo.TimeRange.Start)
+	}
+
+	end, err := parse(todo.TimeRange.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end of time-range %q", todo.TimeRange.End)
+	}
+
+	return func(task domain.Task) bool {
+		// RFC 4791 9.9, a VTODO without due time matches any time range
+		if !task.DueAt.Valid || task.IsRecurring() {
+			return true
+		}
+		if !start.IsZero() && task.DueAt.Time.Before(start) {
+			return false
+		}
+		if !end.IsZero() && !task.DueAt.Time.Before(end) {
+			return false
+		}
+		return true
+	}, nil
+}
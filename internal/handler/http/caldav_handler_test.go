@@ -0,0 +1,90 @@
+// Package http provides
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/application/task/mocks"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// TestCalDAVPut_Create .
+func TestCalDAVPut_Create(t *testing.T) {
+	t.Parallel()
+
+	notFound := common.NewError(common.ErrCodeResourceNotFound, domain.ErrNotFoundTask)
+
+	tests := []struct {
+		name       string
+		uid        string
+		setupMock  func(repo *mocks.MockRepository)
+		wantStatus int
+	}{
+		{
+			name: "new uid of client",
+			uid:  "a1b2@example.com",
+			setupMock: func(repo *mocks.MockRepository) {
+				repo.EXPECT().ListTasksByExternalIDs(gomock.Any(), []string{"a1b2@example.com"}).Return(nil, nil)
+				repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, param domain.Task) (*domain.Task, error) {
+					assert.Equal(t, "a1b2@example.com", param.ExternalID)
+					param.ID = 7
+					return &param, nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "uid of task not exist",
+			uid:  "task-5@gogolook",
+			setupMock: func(repo *mocks.MockRepository) {
+				repo.EXPECT().GetTaskByID(gomock.Any(), int64(5)).Return(nil, notFound)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "uid of task named by external id",
+			uid:  "task-5@gogolook",
+			setupMock: func(repo *mocks.MockRepository) {
+				repo.EXPECT().GetTaskByID(gomock.Any(), int64(5)).Return(&domain.Task{ID: 5, ExternalID: "a1b2@example.com"}, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockRepository(gomock.NewController(t))
+			tt.setupMock(repo)
+
+			app := &application.Application{
+				TaskService: task.NewService(task.ServiceParam{PostgresRepo: repo}),
+			}
+
+			handler := gin.New()
+			handler.PUT(CalDAVCollection+":resource", CalDAVPut(app))
+
+			body := strings.Join([]string{
+				"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//test//EN",
+				"BEGIN:VTODO", "UID:" + tt.uid, "SUMMARY:繳房租", "END:VTODO",
+				"END:VCALENDAR", "",
+			}, "\r\n")
+
+			req := httptest.NewRequest(http.MethodPut, CalDAVCollection+tt.uid+".ics", strings.NewReader(body))
+			req.Header.Set("Content-Type", caldavContentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
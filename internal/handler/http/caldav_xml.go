@@ -0,0 +1,236 @@
+// Package http provides
+package http
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// xml namespaces of CalDAV
+const (
+	nsDAV            = "DAV:"
+	nsCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// davNamespacePrefixes are declared on the multistatus element.
+var davNamespacePrefixes = map[string]string{
+	nsDAV:            "d",
+	nsCalDAV:         "c",
+	nsCalendarServer: "cs",
+}
+
+// dav properties
+var (
+	davPropResourceType          = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	davPropDisplayName           = xml.Name{Space: nsDAV, Local: "displayname"}
+	davPropGetETag               = xml.Name{Space: nsDAV, Local: "getetag"}
+	davPropGetContentType        = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	davPropGetLastModified       = xml.Name{Space: nsDAV, Local: "getlastmodified"}
+	davPropCurrentUserPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	davPropPrincipalURL          = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	davPropCurrentUserPrivileges = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	davPropSupportedReportSet    = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	calPropCalendarHomeSet       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	calPropSupportedComponentSet = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	calPropSupportedCalendarData = xml.Name{Space: nsCalDAV, Local: "supported-calendar-data"}
+	calPropCalendarData          = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	csPropGetCTag                = xml.Name{Space: nsCalendarServer, Local: "getctag"}
+)
+
+// davPropNames is the requested properties, e.g. <d:prop><d:getetag/></d:prop>.
+type davPropNames struct {
+	Props []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// names .
+func (p *davPropNames) names() []xml.Name {
+	if p == nil {
+		return nil
+	}
+	names := make([]xml.Name, len(p.Props))
+	for i := range p.Props {
+		names[i] = p.Props[i].XMLName
+	}
+	return names
+}
+
+// davPropfind is the body of PROPFIND, an empty body means allprop.
+type davPropfind struct {
+	XMLName  xml.Name      `xml:"DAV: propfind"`
+	AllProp  *struct{}     `xml:"DAV: allprop"`
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+}
+
+// calReport is the body of REPORT, calendar-query or calendar-multiget.
+type calReport struct {
+	XMLName xml.Name
+	AllProp *struct{}     `xml:"DAV: allprop"`
+	Prop    *davPropNames `xml:"DAV: prop"`
+	Hrefs   []string      `xml:"DAV: href"`
+	Filter  *struct {
+		CompFilter calCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// calCompFilter .
+type calCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []calCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	TimeRange   *struct {
+		Start string `xml:"start,attr"`
+		End   string `xml:"end,attr"`
+	} `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+}
+
+// decodeDAVBody decodes the xml body, an empty body is not an error.
+func decodeDAVBody(r io.Reader, v any) (empty bool, err error) {
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return true, nil
+	}
+
+	return false, xml.Unmarshal(body, v)
+}
+
+// davResource is a response of multistatus, the property values are xml fragments.
+type davResource struct {
+	href  string
+	props map[xml.Name]string
+}
+
+// multistatus writes the 207 Multi-Status body.
+type multistatus struct {
+	buf bytes.Buffer
+}
+
+// newMultistatus .
+func newMultistatus() *multistatus {
+
+	ms := &multistatus{}
+
+	ms.buf.WriteString(xml.Header)
+	ms.buf.WriteString(`<d:multistatus`)
+
+	prefixes := make([]string, 0, len(davNamespacePrefixes))
+	for ns := range davNamespacePrefixes {
+		prefixes = append(prefixes, ns)
+	}
+	sort.Strings(prefixes)
+
+	for _, ns := range prefixes {
+		fmt.Fprintf(&ms.buf, ` xmlns:%s="%s"`, davNamespacePrefixes[ns], ns)
+	}
+	ms.buf.WriteString(`>`)
+
+	return ms
+}
+
+// addResource writes the requested properties of resource, allProp writes all of them
+// and the unknown properties are reported as 404 Not Found.
+func (ms *multistatus) addResource(resource davResource, names []xml.Name, allProp bool) {
+
+	if allProp {
+		names = names[:0:0]
+		for name := range resource.props {
+			// calendar-data is only returned when it is requested
+			if name != calPropCalendarData {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].Space+names[i].Local < names[j].Space+names[j].Local
+		})
+	}
+
+	var found, missing []xml.Name
+	for _, name := range names {
+		if _, ok := resource.props[name]; ok {
+			found = append(found, name)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+
+	ms.buf.WriteString(`<d:response><d:href>`)
+	_ = xml.EscapeText(&ms.buf, []byte(resource.href))
+	ms.buf.WriteString(`</d:href>`)
+
+	ms.writePropstat(found, resource.props, http.StatusOK)
+	ms.writePropstat(missing, nil, http.StatusNotFound)
+
+	ms.buf.WriteString(`</d:response>`)
+}
+
+// addStatus writes a response of status without properties, e.g. 404 of multiget.
+func (ms *multistatus) addStatus(href string, status int) {
+
+	ms.buf.WriteString(`<d:response><d:href>`)
+	_ = xml.EscapeText(&ms.buf, []byte(href))
+	fmt.Fprintf(&ms.buf, `</d:href><d:status>HTTP/1.1 %d %s</d:status></d:response>`, status, http.StatusText(status))
+}
+
+// writePropstat .
+func (ms *multistatus) writePropstat(names []xml.Name, values map[xml.Name]string, status int) {
+
+	if len(names) == 0 {
+		return
+	}
+
+	ms.buf.WriteString(`<d:propstat><d:prop>`)
+	for _, name := range names {
+		writeDAVElement(&ms.buf, name, values[name])
+	}
+	fmt.Fprintf(&ms.buf, `</d:prop><d:status>HTTP/1.1 %d %s</d:status></d:propstat>`, status, http.StatusText(status))
+}
+
+// bytes returns the body, it should be called once.
+func (ms *multistatus) bytes() []byte {
+	ms.buf.WriteString(`</d:multistatus>`)
+	return ms.buf.Bytes()
+}
+
+// writeDAVElement writes <prefix:local>inner</prefix:local>, the unknown namespace is declared inline.
+func writeDAVElement(buf *bytes.Buffer, name xml.Name, inner string) {
+
+	tag, decl := name.Local, ""
+	if prefix, ok := davNamespacePrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		var ns strings.Builder
+		_ = xml.EscapeText(&ns, []byte(name.Space))
+		decl = fmt.Sprintf(` xmlns:x="%s"`, ns.String())
+	}
+
+	if inner == "" {
+		fmt.Fprintf(buf, `<%s%s/>`, tag, decl)
+		return
+	}
+	fmt.Fprintf(buf, `<%s%s>%s</%s>`, tag, decl, inner, tag)
+}
+
+// davText escapes the text content.
+func davText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// davHref .
+func davHref(href string) string {
+	return "<d:href>" + davText(href) + "</d:href>"
+}
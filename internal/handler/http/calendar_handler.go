@@ -0,0 +1,283 @@
+// Package http provides
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/ical"
+	"gopkg.in/guregu/null.v4"
+)
+
+// CalendarFeedResponse .
+type CalendarFeedResponse struct {
+	// 訂閱ID
+	ID int64 `json:"id"`
+	// 使用者, 由 API key 識別
+	User string `json:"user"`
+	// 日曆名稱
+	Name string `json:"name"`
+	// 任務狀態篩選
+	Status null.Bool `json:"status" swaggertype:"boolean"`
+	// 任務名稱關鍵字篩選
+	FilterName string `json:"filter_name"`
+	// 建立時間
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newCalendarFeedResponse .
+func newCalendarFeedResponse(feed domain.CalendarFeed) CalendarFeedResponse {
+	return CalendarFeedResponse{
+		ID:         feed.ID,
+		User:       feed.User,
+		Name:       feed.Name,
+		Status:     feed.Param.Status,
+		FilterName: feed.Param.Name,
+		CreatedAt:  feed.CreatedAt,
+	}
+}
+
+// CalendarFeedCreatedResponse .
+type CalendarFeedCreatedResponse struct {
+	CalendarFeedResponse
+	// 訂閱token, 只會在建立時回傳
+	Token string `json:"token"`
+	// 訂閱網址
+	URL string `json:"url"`
+	// 訂閱網址 (webcal)
+	WebcalURL string `json:"webcal_url"`
+}
+
+// @Summary 以 iCalendar (VTODO) 匯出任務
+// @Router /tasks.ics [GET]
+// @Produce text/calendar
+// @Tags Calendar
+// @Param status query bool false "任務狀態"
+// @Param name query string false "任務名稱關鍵字"
+// @Success 200 {string} string "iCalendar"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func ExportTasksICS(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req TaskFilterRequest
+		err := c.ShouldBindQuery(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		tasks, err := app.TaskService.ListTasks(ctx, req.toParam())
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithCalendar(c, "tasks", tasks)
+	}
+}
+
+// @Summary 訂閱日曆 (calendar client 使用)
+// @Description 以網址中的 token 驗證, 不需要 API key
+// @Router /calendar/feeds/:token/tasks.ics [GET]
+// @Produce text/calendar
+// @Tags Calendar
+// @Param token path string true "訂閱token"
+// @Success 200 {string} string "iCalendar"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+// @Security none
+func SubscribeCalendarFeed(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		// the token is the credential of the url, it should not leak by the referer
+		c.Header("Referrer-Policy", "no-referrer")
+
+		feed, tasks, err := app.CalendarService.ListFeedTasks(ctx, c.Param("token"))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		name := feed.Name
+		if name == "" {
+			name = "tasks"
+		}
+
+		responseWithCalendar(c, name, tasks)
+	}
+}
+
+// @Summary 取得使用者的日曆訂閱列表
+// @Router /calendar/feeds [GET]
+// @Produce json
+// @Tags Calendar
+// @Success 200 {array} http.CalendarFeedResponse "日曆訂閱列表"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func ListCalendarFeeds(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		feeds, err := app.CalendarService.ListFeeds(ctx, calendarFeedUser(c))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		response := make([]CalendarFeedResponse, len(feeds))
+
+		for i := range feeds {
+			response[i] = newCalendarFeedResponse(feeds[i])
+		}
+
+		responseWithJSON(c, http.StatusOK, response)
+	}
+}
+
+// @Summary 建立使用者的日曆訂閱
+// @Description 回傳含有秘密token的訂閱網址, token 只會在建立時回傳一次
+// @Router /calendar/feeds [POST]
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Tags Calendar
+// @Param Idempotency-Key header string false "重試時帶相同的 key, 回傳第一次請求的回應 (回應標頭 Idempotent-Replayed: true)"
+// @Param request body Request true "日曆訂閱"
+// @Success 201 {object} http.CalendarFeedCreatedResponse "日曆訂閱"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 409 {object} ErrResponse "相同 Idempotency-Key 的請求處理中"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CreateCalendarFeed(app *application.Application) func(c *gin.Context) {
+
+	// Request .
+	type Request struct {
+		// 日曆名稱
+		Name string `form:"name" json:"name"`
+		// 任務狀態篩選
+		Status *bool `form:"status" json:"status"`
+		// 任務名稱關鍵字篩選
+		FilterName string `form:"filter_name" json:"filter_name"`
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req Request
+		err := c.ShouldBind(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		feed, token, err := app.CalendarService.CreateFeed(ctx, domain.CalendarFeed{
+			User: calendarFeedUser(c),
+			Name: req.Name,
+			Param: domain.TaskParam{
+				Status: null.BoolFromPtr(req.Status),
+				Name:   req.FilterName,
+			},
+		})
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		path := fmt.Sprintf("/calendar/feeds/%s/tasks.ics", token)
+
+		responseWithJSON(c, http.StatusCreated, CalendarFeedCreatedResponse{
+			CalendarFeedResponse: newCalendarFeedResponse(*feed),
+			Token:                token,
+			URL:                  fmt.Sprintf("%s://%s%s", requestScheme(c), c.Request.Host, path),
+			WebcalURL:            fmt.Sprintf("webcal://%s%s", c.Request.Host, path),
+		})
+	}
+}
+
+// @Summary 刪除使用者的日曆訂閱
+// @Router /calendar/feeds/:id [DELETE]
+// @Produce json
+// @Tags Calendar
+// @Param id path int true "訂閱ID"
+// @Success 200 {string} string "" No Content
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func DeleteCalendarFeed(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		feedID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		err = app.CalendarService.DeleteFeed(ctx, calendarFeedUser(c), int64(feedID))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithNoContent(c, http.StatusOK)
+	}
+}
+
+// anonymousFeedUser owns the calendar feeds when the auth is disabled.
+const anonymousFeedUser = "anonymous"
+
+// calendarFeedUser is the authenticated principal, the same as the principal of rate limit,
+// so that the feeds of an API key can not be listed or deleted by the others.
+func calendarFeedUser(c *gin.Context) string {
+
+	if principal := c.GetString(principalContextKey); principal != "" {
+		return principal
+	}
+
+	return anonymousFeedUser
+}
+
+// responseWithCalendar renders the tasks as a VCALENDAR, it is rendered into buffer first
+// so that an error can still be responded as ErrResponse.
+func responseWithCalendar(c *gin.Context, name string, tasks []domain.Task) {
+
+	var buf bytes.Buffer
+
+	enc := ical.NewEncoder(&buf, ical.EncoderParam{Name: name})
+
+	for i := range tasks {
+		if err := enc.Encode(tasks[i]); err != nil {
+			responseWithError(c, common.NewError(common.ErrCodeInternalProcess, err))
+			return
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		responseWithError(c, common.NewError(common.ErrCodeInternalProcess, err))
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+	c.Data(http.StatusOK, ical.ContentType, buf.Bytes())
+}
+
+// requestScheme .
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,40 @@
+// Package http provides
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tingchima/gogolook/infra/ratelimit"
+)
+
+// TestCalendarFeedUser .
+func TestCalendarFeedUser(t *testing.T) {
+	t.Parallel()
+
+	echo := func(c *gin.Context) { c.String(http.StatusOK, calendarFeedUser(c)) }
+
+	authed := gin.New()
+	authed.Use(APIKeyAuth([]string{"key-1"}))
+	authed.GET("/calendar/feeds", echo)
+
+	do := func(handler *gin.Engine, user, key string) string {
+		req := httptest.NewRequest(http.MethodGet, "/calendar/feeds?user=mallory", nil)
+		req.SetBasicAuth(user, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// the user is derived from the API key, neither the query nor the user of basic auth
+	assert.Equal(t, ratelimit.APIKeyPrincipal("key-1"), do(authed, "alice", "key-1"))
+	assert.Equal(t, ratelimit.APIKeyPrincipal("key-1"), do(authed, "bob", "key-1"))
+
+	anonymous := gin.New()
+	anonymous.GET("/calendar/feeds", echo)
+
+	assert.Equal(t, anonymousFeedUser, do(anonymous, "alice", ""))
+}
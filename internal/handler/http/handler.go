@@ -13,10 +13,76 @@ func RegisterHandlers(handler *gin.Engine, app *application.Application) {
 	{
 		handler.GET("/tasks", ListTasks(app))
 
+		handler.GET("/tasks/stream", StreamTasks(app))
+
+		handler.GET("/tasks.ics", ExportTasksICS(app))
+
+		handler.GET("/tasks/export", ExportTasks(app))
+
+		handler.POST("/tasks/import", ImportTasks(app))
+
 		handler.POST("/task", CreateTask(app))
 
 		handler.PUT("/task/:id", UpdateTask(app))
 
 		handler.DELETE("/task/:id", DeleteTask(app))
 	}
+
+	// recurring task handlers
+	{
+		handler.GET("/task/:id/occurrences", PreviewTaskOccurrences(app))
+
+		handler.PUT("/task/:id/recurrence", SetTaskRecurrence(app))
+
+		handler.POST("/task/:id/recurrence/skip", SkipTaskOccurrence(app))
+
+		handler.DELETE("/task/:id/recurrence", StopTaskRecurrence(app))
+	}
+
+	// reminder handlers
+	{
+		handler.GET("/task/:id/reminders", ListReminders(app))
+
+		handler.POST("/task/:id/reminders", CreateReminder(app))
+
+		handler.DELETE("/task/:id/reminders/:reminder_id", DeleteReminder(app))
+	}
+
+	// calendar handlers
+	{
+		handler.GET("/calendar/feeds", ListCalendarFeeds(app))
+
+		handler.POST("/calendar/feeds", CreateCalendarFeed(app))
+
+		handler.DELETE("/calendar/feeds/:id", DeleteCalendarFeed(app))
+
+		handler.GET(CalendarFeedPath, SubscribeCalendarFeed(app))
+	}
+}
+
+// RegisterCalDAVHandlers .
+func RegisterCalDAVHandlers(handler *gin.Engine, app *application.Application) {
+
+	// caldav handlers
+	{
+		handler.GET("/.well-known/caldav", CalDAVWellKnown(app))
+
+		handler.Handle(MethodPropfind, "/.well-known/caldav", CalDAVWellKnown(app))
+
+		for _, path := range []string{CalDAVRoot, CalDAVCollection, CalDAVCollection + ":resource"} {
+			handler.OPTIONS(path, CalDAVOptions(app))
+
+			handler.Handle(MethodPropfind, path, CalDAVPropfind(app))
+		}
+
+		handler.Handle(MethodReport, CalDAVCollection, CalDAVReport(app))
+
+		handler.GET(CalDAVCollection+":resource", CalDAVGet(app))
+
+		handler.HEAD(CalDAVCollection+":resource", CalDAVGet(app))
+
+		handler.PUT(CalDAVCollection+":resource", CalDAVPut(app))
+
+		handler.DELETE(CalDAVCollection+":resource", CalDAVDelete(app))
+	}
 }
@@ -0,0 +1,62 @@
+// Package http provides
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/infra/health"
+)
+
+// health check paths
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+)
+
+// HealthResponse .
+type HealthResponse struct {
+	Status string `json:"status"` // ok
+}
+
+// RegisterHealthHandlers registers the probes, they should be registered before the auth middleware
+// since the orchestrators can not send the API key.
+func RegisterHealthHandlers(handler *gin.Engine, checker *health.Checker) {
+
+	handler.GET(HealthzPath, Healthz())
+
+	handler.GET(ReadyzPath, Readyz(checker))
+}
+
+// @Summary 存活檢查
+// @Router /healthz [GET]
+// @Produce json
+// @Tags Health
+// @Success 200 {object} http.HealthResponse "程序存活"
+// @Security none
+func Healthz() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		responseWithJSON(c, http.StatusOK, HealthResponse{Status: "ok"})
+	}
+}
+
+// @Summary 就緒檢查
+// @Router /readyz [GET]
+// @Produce json
+// @Tags Health
+// @Success 200 {object} health.Report "各元件狀態"
+// @Failure 503 {object} health.Report "未就緒或關閉中"
+// @Security none
+func Readyz(checker *health.Checker) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		report := checker.Check(c.Request.Context())
+
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+
+		responseWithJSON(c, status, report)
+	}
+}
@@ -0,0 +1,181 @@
+// Package http provides
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/infra/idempotency"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// IdempotencyKeyHeader .
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to true on the stored response of retry.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength .
+const maxIdempotencyKeyLength = 255
+
+// ErrInvalidIdempotencyKey .
+var ErrInvalidIdempotencyKey = errors.New("the idempotency key should be 1 to 255 printable characters")
+
+// IdempotencyParam .
+type IdempotencyParam struct {
+	Store idempotency.Store
+	// 保留回應的時間, 到期後可重新使用此 key
+	TTL time.Duration
+	// 處理中的 key 於此時間後視為中斷, 應大於請求的處理時間
+	LockTimeout time.Duration
+}
+
+// Idempotency replays the response of the POST requests which are retried with the same Idempotency-Key,
+// the keys are scoped by principal. The server errors are not stored so that they can be retried,
+// and the requests are processed without the key if the store fails. The key is only completed or
+// released by the request holding its lock, a request outliving the lock timeout can not overwrite the retry.
+func Idempotency(param IdempotencyParam) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		if !validIdempotencyKey(key) {
+			err := ErrInvalidIdempotencyKey
+			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		storeKey := requestPrincipal(c) + "|" + key
+
+		resp, lockToken, err := param.Store.Begin(ctx, storeKey, fingerprint, param.LockTimeout)
+		switch {
+		case errors.Is(err, idempotency.ErrInFlight):
+			responseWithError(c, common.NewError(common.ErrCodeResourceAlreadyExisted, err, common.WithMsg(err.Error())))
+			return
+		case errors.Is(err, idempotency.ErrFingerprintMismatch):
+			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+			return
+		case err != nil:
+			slog.WarnContext(ctx, "begin idempotency key fail", "err", err)
+			c.Next()
+			return
+		case resp != nil:
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(resp.StatusCode, resp.ContentType, resp.Body)
+			c.Abort()
+			return
+		}
+
+		// the response should be kept even if the client has gone
+		ctx = context.WithoutCancel(ctx)
+
+		// the key is released unless the response is stored, including when the handler panics
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := param.Store.Release(ctx, storeKey, lockToken); err != nil {
+				slog.WarnContext(ctx, "release idempotency key fail", "err", err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		err = param.Store.Complete(ctx, storeKey, lockToken, idempotency.Response{
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}, param.TTL)
+		if err != nil {
+			slog.WarnContext(ctx, "save idempotency key fail", "err", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// validIdempotencyKey .
+func validIdempotencyKey(key string) bool {
+
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestFingerprint hashes the method, url and body, the body is restored for the handler.
+// The body is hashed up to the import limit, the larger body is rejected by the handler.
+func requestFingerprint(c *gin.Context) (string, error) {
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxImportBodySize+1))
+	if err != nil {
+		return "", err
+	}
+
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+
+	h := sha256.New()
+	_, _ = io.WriteString(h, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+	_, _ = io.WriteString(h, c.ContentType()+"\n")
+	_, _ = h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// responseRecorder keeps a copy of the response body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write .
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString .
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,68 @@
+// Package http provides
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tingchima/gogolook/infra/idempotency"
+)
+
+// TestIdempotency .
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+
+	handler := gin.New()
+	handler.Use(Recovery(), Idempotency(IdempotencyParam{
+		Store:       idempotency.NewMemoryStore(),
+		TTL:         time.Hour,
+		LockTimeout: time.Minute,
+	}))
+	handler.POST("/task", func(c *gin.Context) {
+		calls++
+		switch calls {
+		case 1:
+			panic("boom")
+		case 2:
+			c.Status(http.StatusServiceUnavailable)
+		default:
+			c.JSON(http.StatusCreated, gin.H{"id": calls})
+		}
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/task", strings.NewReader(`{"name":"task"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the key is released when the handler panics
+	rec := post()
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// the server error is not stored
+	rec = post()
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = post()
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"id": 3}`, rec.Body.String())
+
+	// the retry gets the stored response
+	rec = post()
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+	assert.JSONEq(t, `{"id": 3}`, rec.Body.String())
+	assert.Equal(t, 3, calls)
+}
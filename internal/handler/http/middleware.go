@@ -0,0 +1,419 @@
+// Package http provides
+package http
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/infra/logger"
+	"github.com/tingchima/gogolook/infra/metrics"
+	"github.com/tingchima/gogolook/infra/ratelimit"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MetricsPath .
+const MetricsPath = "/metrics"
+
+// RequestIDHeader .
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength .
+const maxRequestIDLength = 128
+
+// sensitiveParams are the path params which are redacted in the access log.
+var sensitiveParams = []string{"token"}
+
+// APIKeyHeader .
+const APIKeyHeader = "X-API-Key"
+
+// CalendarFeedPath is authenticated by its own token, the calendar clients can not send the API key.
+const CalendarFeedPath = "/calendar/feeds/:token/tasks.ics"
+
+// ErrInvalidAPIKey .
+var ErrInvalidAPIKey = errors.New("invalid or missing api key")
+
+// ErrRateLimited .
+var ErrRateLimited = errors.New("too many requests, retry later")
+
+// ErrRateLimitUnavailable .
+var ErrRateLimitUnavailable = errors.New("the rate limit is unavailable, retry later")
+
+// APIKeyAuth accepts the API key from X-API-Key, "Authorization: Bearer <key>" or
+// the password of basic auth, which is used by the CalDAV clients.
+func APIKeyAuth(apiKeys []string) gin.HandlerFunc {
+
+	// compare the digests so that the length of keys does not leak by timing
+	digests := make([][sha256.Size]byte, len(apiKeys))
+	for i := range apiKeys {
+		digests[i] = sha256.Sum256([]byte(apiKeys[i]))
+	}
+
+	valid := func(key string) bool {
+		if key == "" {
+			return false
+		}
+
+		digest := sha256.Sum256([]byte(key))
+
+		ok := 0
+		for i := range digests {
+			ok |= subtle.ConstantTimeCompare(digest[:], digests[i][:])
+		}
+		return ok == 1
+	}
+
+	return func(c *gin.Context) {
+
+		if c.FullPath() == CalendarFeedPath {
+			c.Next()
+			return
+		}
+
+		if key := requestAPIKey(c); valid(key) {
+			c.Set(principalContextKey, ratelimit.APIKeyPrincipal(key))
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", `Basic realm="gogolook"`)
+
+		err := ErrInvalidAPIKey
+		responseWithError(c, common.NewError(common.ErrCodeUnauthorized, err, common.WithMsg(err.Error())))
+	}
+}
+
+// principalContextKey is the gin context key of the authenticated principal.
+const principalContextKey = "principal"
+
+// requestPrincipal falls back to the client IP if the request is not authenticated.
+func requestPrincipal(c *gin.Context) string {
+
+	if principal := c.GetString(principalContextKey); principal != "" {
+		return principal
+	}
+
+	return ratelimit.PrincipalIP + ":" + c.ClientIP()
+}
+
+// requestAPIKey .
+func requestAPIKey(c *gin.Context) string {
+
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	if _, password, ok := c.Request.BasicAuth(); ok {
+		return password
+	}
+
+	return ""
+}
+
+// RequestID uses the X-Request-ID of request if it is valid, otherwise generates one.
+// The ID is put into the context of request and echoed in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// validRequestID only accepts the printable ASCII characters, so that the ID can not break the logs.
+func validRequestID(requestID string) bool {
+
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID .
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AccessLog logs every request after it is handled, the errors of response are logged with their causes.
+func AccessLog(l *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case isProbe(c.FullPath()):
+			// the probes are sent every few seconds
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", accessLogPath(c)),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+
+		// the full chain of domain error is logged, the client only gets the client message
+		if err := c.Errors.Last(); err != nil {
+			var appErr *common.Error
+			if common.AsErr(err.Err, &appErr) {
+				attrs = append(attrs, slog.Any("err", appErr))
+			} else {
+				attrs = append(attrs, slog.String("err", err.Error()))
+			}
+		}
+
+		l.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// isProbe .
+func isProbe(route string) bool {
+	return route == HealthzPath || route == ReadyzPath || route == MetricsPath
+}
+
+// accessLogPath redacts the sensitive path params, e.g. the token of calendar feed.
+func accessLogPath(c *gin.Context) string {
+
+	path := c.Request.URL.Path
+
+	for _, name := range sensitiveParams {
+		if value := c.Param(name); value != "" {
+			path = strings.ReplaceAll(path, value, logger.RedactedValue)
+		}
+	}
+
+	return path
+}
+
+// Recovery responds internal error when the handler panics, the panic is logged with the stack
+// instead of dumping the request, which contains the credentials.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			// the client has gone, there is nothing to respond
+			if errors.Is(asError(r), http.ErrAbortHandler) {
+				panic(r)
+			}
+
+			slog.ErrorContext(c.Request.Context(), "handler panic",
+				"panic", fmt.Sprint(r),
+				"stack", string(debug.Stack()),
+			)
+
+			err := fmt.Errorf("panic: %v", r)
+			responseWithError(c, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg("internal error")))
+		}()
+
+		c.Next()
+	}
+}
+
+// MethodNotAllowed is the NoMethod handler of engine with HandleMethodNotAllowed,
+// the path is routed but the method is not.
+func MethodNotAllowed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		msg := fmt.Sprintf("the method %s is not allowed", c.Request.Method)
+		responseWithError(c, common.NewError(common.ErrCodeMethodNotAllowed, errors.New(msg), common.WithMsg(msg)))
+	}
+}
+
+// asError .
+func asError(r any) error {
+	err, _ := r.(error)
+	return err
+}
+
+// Metrics observes the duration of requests by route, and counts the error responses by ErrCode name.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		m.ObserveHTTPRequest(c.Request.Method, c.FullPath(), status, time.Since(start))
+
+		if name := c.GetString(errNameContextKey); name != "" {
+			m.IncError(name, status)
+		}
+	}
+}
+
+// tracer .
+var tracer = otel.Tracer("github.com/tingchima/gogolook/internal/handler/http")
+
+// Tracing starts the server span of request, it continues the trace of W3C traceparent header.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = metrics.UnmatchedRoute
+		}
+
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(accessLogPath(c)),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+				semconv.ClientAddress(c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+			span.SetAttributes(attribute.String(logger.RequestIDKey, requestID))
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+
+		if err := c.Errors.Last(); err != nil && status >= http.StatusInternalServerError {
+			span.RecordError(err.Err)
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		if name := c.GetString(errNameContextKey); name != "" {
+			span.SetAttributes(attribute.String("error.name", name))
+		}
+	}
+}
+
+// RateLimit limits the requests by route and principal, the principal is the API key
+// if the request is authenticated, otherwise the client IP. If the store fails, the reads
+// are allowed and the writes are rejected.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		route := c.Request.Method + " " + c.FullPath()
+
+		result, limited, err := limiter.Allow(c.Request.Context(), route, requestPrincipal(c))
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "rate limit fail", "err", err)
+
+			if !isSafeMethod(c.Request.Method) {
+				err := ErrRateLimitUnavailable
+				responseWithError(c, common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg(err.Error())))
+				return
+			}
+		}
+
+		if !limited {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+
+			err := ErrRateLimited
+			responseWithError(c, common.NewError(common.ErrCodeTooManyRequests, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// StickyPrimaryRead reads from the primary database within the replica lag after a write of
+// the principal, so that e.g. a GET right after a POST sees the new task. The window is also
+// started before the write, as the client may send the next request once the response is written.
+func StickyPrimaryRead(sticky *common.StickyPrimary) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		principal := requestPrincipal(c)
+
+		write := !isSafeMethod(c.Request.Method)
+		if write {
+			sticky.MarkWrite(principal)
+		}
+
+		c.Request = c.Request.WithContext(sticky.Context(c.Request.Context(), principal))
+
+		c.Next()
+
+		if write {
+			sticky.MarkWrite(principal)
+		}
+	}
+}
+
+// isSafeMethod .
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// ceilSeconds .
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,118 @@
+// Package http provides
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/infra/ratelimit"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// failingStore .
+type failingStore struct{}
+
+// Take .
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("conn refused")
+}
+
+// newRateLimitedHandler allows one request per principal.
+func newRateLimitedHandler(t *testing.T, store ratelimit.Store) *gin.Engine {
+
+	limiter, err := ratelimit.NewLimiter(store, []ratelimit.Rule{
+		{Route: ratelimit.AnyRoute, Limit: ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1}},
+	})
+	require.NoError(t, err)
+
+	handler := gin.New()
+	handler.Use(APIKeyAuth([]string{"key-1", "key-2"}), RateLimit(limiter))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	handler.GET("/tasks", ok)
+	handler.POST("/task", ok)
+
+	return handler
+}
+
+// TestRateLimit_Principal .
+func TestRateLimit_Principal(t *testing.T) {
+	t.Parallel()
+
+	handler := newRateLimitedHandler(t, ratelimit.NewMemoryStore())
+
+	do := func(user, key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.SetBasicAuth(user, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("alice", "key-1"))
+	// the user of basic auth is not verified, the key shares one bucket
+	assert.Equal(t, http.StatusTooManyRequests, do("bob", "key-1"))
+	assert.Equal(t, http.StatusTooManyRequests, do("", "key-1"))
+
+	// the other keys have their own buckets
+	assert.Equal(t, http.StatusOK, do("alice", "key-2"))
+}
+
+// TestRateLimit_StoreFailure .
+func TestRateLimit_StoreFailure(t *testing.T) {
+	t.Parallel()
+
+	handler := newRateLimitedHandler(t, failingStore{})
+
+	do := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(APIKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// the reads are allowed, the writes are rejected
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/tasks"))
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/task"))
+}
+
+// TestStickyPrimaryRead .
+func TestStickyPrimaryRead(t *testing.T) {
+	t.Parallel()
+
+	handler := gin.New()
+	handler.Use(APIKeyAuth([]string{"key-1", "key-2"}), StickyPrimaryRead(common.NewStickyPrimary(time.Hour)))
+
+	primary := func(c *gin.Context) {
+		if common.IsPrimaryRead(c.Request.Context()) {
+			c.String(http.StatusOK, "primary")
+			return
+		}
+		c.String(http.StatusOK, "replica")
+	}
+	handler.GET("/tasks", primary)
+	handler.POST("/tasks", primary)
+
+	do := func(method, key string) string {
+		req := httptest.NewRequest(method, "/tasks", nil)
+		req.Header.Set(APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "replica", do(http.MethodGet, "key-1"))
+	assert.Equal(t, "primary", do(http.MethodPost, "key-1"))
+
+	// the reads after the write of the same principal
+	assert.Equal(t, "primary", do(http.MethodGet, "key-1"))
+	assert.Equal(t, "replica", do(http.MethodGet, "key-2"))
+}
@@ -0,0 +1,58 @@
+// Package http provides
+package http
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggest/swgui/v5emb"
+)
+
+// api document paths
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs/"
+)
+
+// openAPISpec is the OpenAPI 3 document of the routes of RegisterHandlers and RegisterHealthHandlers,
+// it is generated from the annotations of handlers by make openapi.
+//
+// The CalDAV and document routes are not listed in the tags, they are served for the clients.
+//
+// @Title gogolook
+// @Version 1.0.0
+// @Description 任務管理 API, 除了 ErrResponse 之外的 JSON 回應都包在 result 欄位中
+// @Tag Task
+// @Tag Reminder
+// @Tag Calendar
+// @Tag Health
+// @SecurityScheme ApiKeyHeader apiKey header X-API-Key
+// @SecurityScheme BearerAuth http bearer
+// @SecurityScheme BasicAuth http basic "API key 作為密碼, 供 CalDAV client 使用"
+// @Failure 401 {object} ErrResponse "API key 錯誤或未提供"
+// @Failure 429 {object} ErrResponse "請求過於頻繁, 依 Retry-After 標頭重試"
+//
+//go:generate go run ../../openapi/openapigen -out openapi.json
+//go:embed openapi.json
+var openAPISpec []byte
+
+// RegisterOpenAPIHandlers registers the document and the Swagger UI, they are not authenticated,
+// the API key can be filled in the Swagger UI.
+func RegisterOpenAPIHandlers(handler *gin.Engine) {
+
+	handler.GET(OpenAPIPath, OpenAPISpec())
+
+	handler.GET(DocsPath+"*any", gin.WrapH(v5emb.New("gogolook", OpenAPIPath, DocsPath)))
+}
+
+// @Summary OpenAPI 3 文件
+// @Router /openapi.json [GET]
+// @Produce json
+// @Tags Docs
+// @Success 200 {object} object "OpenAPI 3 文件"
+func OpenAPISpec() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openAPISpec)
+	}
+}
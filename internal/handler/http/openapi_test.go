@@ -0,0 +1,156 @@
+// Package http provides
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/infra/health"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/openapi"
+)
+
+// openAPIDocument is the part of document which is checked.
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	Schemas struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// ginPathParam .
+var ginPathParam = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+// TestOpenAPI_Generated fails if the annotations of handlers are changed without running make openapi.
+func TestOpenAPI_Generated(t *testing.T) {
+	t.Parallel()
+
+	doc, err := openapi.Generate(".")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(doc), string(openAPISpec), "openapi.json is outdated, run make openapi")
+}
+
+// TestOpenAPI_Routes fails if the routes and the document drift apart.
+func TestOpenAPI_Routes(t *testing.T) {
+	t.Parallel()
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	handler := gin.New()
+	RegisterHealthHandlers(handler, health.NewChecker(time.Second))
+	RegisterHandlers(handler, &application.Application{})
+
+	var routes []string
+	for _, route := range handler.Routes() {
+		routes = append(routes, route.Method+" "+ginPathParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+
+	var documented []string
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	assert.ElementsMatch(t, routes, documented)
+}
+
+// TestOpenAPI_Schemas fails if the fields of responses and their schemas drift apart.
+func TestOpenAPI_Schemas(t *testing.T) {
+	t.Parallel()
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+
+	responses := map[string]any{
+		"TaskResponse":                TaskResponse{},
+		"ErrResponse":                 ErrResponse{},
+		"TaskEventResponse":           TaskEventResponse{},
+		"TaskImportResponse":          TaskImportResponse{},
+		"TaskImportRowResponse":       TaskImportRowResponse{},
+		"ReminderResponse":            ReminderResponse{},
+		"CalendarFeedResponse":        CalendarFeedResponse{},
+		"CalendarFeedCreatedResponse": CalendarFeedCreatedResponse{},
+		"HealthResponse":              HealthResponse{},
+		"HealthReport":                health.Report{},
+		"HealthComponentStatus":       health.ComponentStatus{},
+	}
+
+	for name, resp := range responses {
+		schema, ok := doc.Schemas.Schemas[name]
+		if !assert.True(t, ok, name) {
+			continue
+		}
+
+		var properties []string
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		assert.ElementsMatch(t, jsonFields(reflect.TypeOf(resp)), properties, name)
+	}
+}
+
+// jsonFields returns the json names of the fields, the fields of embedded struct are included.
+func jsonFields(typ reflect.Type) []string {
+
+	var fields []string
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		if field.Anonymous {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fields = append(fields, name)
+	}
+
+	return fields
+}
+
+// TestRegisterOpenAPIHandlers .
+func TestRegisterOpenAPIHandlers(t *testing.T) {
+	t.Parallel()
+
+	handler := gin.New()
+	RegisterOpenAPIHandlers(handler)
+
+	tests := []struct {
+		path        string
+		contentType string
+	}{
+		{path: OpenAPIPath, contentType: "application/json"},
+		{path: DocsPath, contentType: "text/html"},
+		{path: DocsPath + "swagger-ui-bundle.js", contentType: "javascript"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, tt.path)
+		assert.Contains(t, w.Header().Get("Content-Type"), tt.contentType, tt.path)
+	}
+}
@@ -0,0 +1,181 @@
+// Package http provides
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// ReminderResponse .
+type ReminderResponse struct {
+	// 提醒ID
+	ID int64 `json:"id"`
+	// 任務ID
+	TaskID int64 `json:"task_id"`
+	// 提醒時間 (絕對時間)
+	RemindAt null.Time `json:"remind_at" swaggertype:"string" format:"date-time"`
+	// 相對任務到期時間的秒數, 負值為到期前
+	OffsetSeconds null.Int `json:"offset_seconds" swaggertype:"integer"`
+	// 通知管道: log, webhook, smtp
+	Channel string `json:"channel" enums:"log,webhook,smtp"`
+	// 通知對象
+	Recipient string `json:"recipient"`
+	// 送出時間
+	SentAt null.Time `json:"sent_at" swaggertype:"string" format:"date-time"`
+	// 送出失敗次數
+	Attempts int `json:"attempts"`
+	// 最後一次送出失敗的原因
+	LastError string `json:"last_error,omitempty"`
+}
+
+// newReminderResponse .
+func newReminderResponse(reminder domain.Reminder) ReminderResponse {
+	return ReminderResponse{
+		ID:            reminder.ID,
+		TaskID:        reminder.TaskID,
+		RemindAt:      reminder.RemindAt,
+		OffsetSeconds: reminder.Offset,
+		Channel:       string(reminder.Channel),
+		Recipient:     reminder.Recipient,
+		SentAt:        reminder.SentAt,
+		Attempts:      reminder.Attempts,
+		LastError:     reminder.LastError,
+	}
+}
+
+// @Summary 取得任務的提醒列表
+// @Router /task/:id/reminders [GET]
+// @Produce json
+// @Tags Reminder
+// @Param id path int true "任務ID"
+// @Success 200 {array} http.ReminderResponse "提醒列表"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func ListReminders(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		reminders, err := app.ReminderService.ListReminders(ctx, int64(taskID))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		response := make([]ReminderResponse, len(reminders))
+
+		for i := range reminders {
+			response[i] = newReminderResponse(reminders[i])
+		}
+
+		responseWithJSON(c, http.StatusOK, response)
+	}
+}
+
+// @Summary 建立任務的提醒
+// @Router /task/:id/reminders [POST]
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Tags Reminder
+// @Param id path int true "任務ID"
+// @Param Idempotency-Key header string false "重試時帶相同的 key, 回傳第一次請求的回應 (回應標頭 Idempotent-Replayed: true)"
+// @Param request body Request true "提醒內容"
+// @Success 201 {object} http.ReminderResponse "提醒內容"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 409 {object} ErrResponse "相同 Idempotency-Key 的請求處理中"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func CreateReminder(app *application.Application) func(c *gin.Context) {
+
+	// Request .
+	type Request struct {
+		// 提醒時間 (RFC 3339), 與 offset_seconds 擇一
+		RemindAt *time.Time `form:"remind_at" json:"remind_at" time_format:"2006-01-02T15:04:05Z07:00"`
+		// 相對任務到期時間的秒數, 負值為到期前, 與 remind_at 擇一
+		OffsetSeconds *int64 `form:"offset_seconds" json:"offset_seconds"`
+		// 通知管道: log, webhook, smtp
+		Channel string `form:"channel" json:"channel" binding:"required,oneof=log webhook smtp"`
+		// 通知對象, webhook url 或 email
+		Recipient string `form:"recipient" json:"recipient"`
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req Request
+		err := c.ShouldBind(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		createdReminder, err := app.ReminderService.CreateReminder(ctx, domain.Reminder{
+			TaskID:    int64(taskID),
+			RemindAt:  null.TimeFromPtr(req.RemindAt),
+			Offset:    null.IntFromPtr(req.OffsetSeconds),
+			Channel:   domain.ReminderChannel(req.Channel),
+			Recipient: req.Recipient,
+		})
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithJSON(c, http.StatusCreated, newReminderResponse(*createdReminder))
+	}
+}
+
+// @Summary 刪除任務的提醒
+// @Router /task/:id/reminders/:reminder_id [DELETE]
+// @Produce json
+// @Tags Reminder
+// @Param id path int true "任務ID"
+// @Param reminder_id path int true "提醒ID"
+// @Success 200 {string} string "" No Content
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func DeleteReminder(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		reminderID, err := GetPathInt(c, "reminder_id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		err = app.ReminderService.DeleteReminder(ctx, int64(taskID), int64(reminderID))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithNoContent(c, http.StatusOK)
+	}
+}
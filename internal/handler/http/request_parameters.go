@@ -2,14 +2,41 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/taskio"
 )
 
+func init() {
+	// the validation errors are responded with the json or form names of fields instead of the Go names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+// requestFieldName .
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
 func GetPathInt(c *gin.Context, name string) (int, error) {
 	strVal := c.Params.ByName(name)
 	if strVal == "" {
@@ -25,3 +52,101 @@ func GetPathInt(c *gin.Context, name string) (int, error) {
 
 	return intVal, nil
 }
+
+// invalidParameterError is INVALID_PARAMETER of the binding or parsing error, the errors of gin binding
+// contain the Go types and fields of request, so the message is rebuilt from the error and the raw error
+// is only kept as the cause.
+func invalidParameterError(err error) error {
+	return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(parameterMessage(err)))
+}
+
+// parameterMessage .
+func parameterMessage(err error) string {
+
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+		syntaxErr      *json.SyntaxError
+		numErr         *strconv.NumError
+		timeErr        *time.ParseError
+	)
+
+	switch {
+	// the errors of this service only describe the input
+	case errors.Is(err, taskio.ErrUnsupportedFormat),
+		errors.Is(err, taskio.ErrTooManyRows),
+		errors.Is(err, taskio.ErrInvalidFile),
+		errors.Is(err, ErrImportFormatRequired):
+		return err.Error()
+
+	case errors.As(err, &validationErrs):
+		msgs := make([]string, len(validationErrs))
+		for i := range validationErrs {
+			msgs[i] = validationMessage(validationErrs[i])
+		}
+		return strings.Join(msgs, "; ")
+
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("the request body should be %s", jsonTypeName(typeErr.Type))
+		}
+		return fmt.Sprintf("%s should be %s", typeErr.Field, jsonTypeName(typeErr.Type))
+
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("the request body is not valid json at offset %d", syntaxErr.Offset)
+
+	case errors.As(err, &timeErr):
+		return fmt.Sprintf("invalid time %q, should be RFC 3339", timeErr.Value)
+
+	case errors.As(err, &numErr):
+		if numErr.Func == "ParseBool" {
+			return fmt.Sprintf("invalid boolean %q", numErr.Num)
+		}
+		return fmt.Sprintf("invalid number %q", numErr.Num)
+
+	case errors.Is(err, io.EOF):
+		return "the request body is empty"
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "the request body is incomplete"
+
+	case errors.Is(err, http.ErrMissingFile):
+		return "the file is required"
+	}
+
+	return "the request parameters are invalid"
+}
+
+// validationMessage describes the failed rule of field, the field is named by requestFieldName.
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fieldErr.Field())
+	case "oneof":
+		return fmt.Sprintf("%s should be one of %s", fieldErr.Field(), fieldErr.Param())
+	case "min":
+		return fmt.Sprintf("%s should be at least %s", fieldErr.Field(), fieldErr.Param())
+	case "max":
+		return fmt.Sprintf("%s should be at most %s", fieldErr.Field(), fieldErr.Param())
+	}
+	return fmt.Sprintf("%s is invalid", fieldErr.Field())
+}
+
+// jsonTypeName .
+func jsonTypeName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "valid"
+}
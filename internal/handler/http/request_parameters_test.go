@@ -0,0 +1,109 @@
+// Package http provides
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInvalidParameterError fails if the binding errors leak the Go types and fields of request.
+func TestInvalidParameterError(t *testing.T) {
+	t.Parallel()
+
+	type Request struct {
+		Name     string     `form:"name" json:"name" binding:"required"`
+		Priority int        `form:"priority" json:"priority" binding:"min=0,max=9"`
+		Channel  string     `form:"channel" json:"channel" binding:"omitempty,oneof=log webhook"`
+		Status   *bool      `form:"status"`
+		DueAt    *time.Time `form:"due_at" json:"due_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	}
+
+	handler := gin.New()
+	handler.POST("/bind", func(c *gin.Context) {
+		var req Request
+		err := c.ShouldBind(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name        string
+		query       string
+		body        string
+		wantMsg     string
+		wantDetails []any
+	}{
+		{
+			name:        "required",
+			body:        `{"priority":1}`,
+			wantMsg:     "name is required",
+			wantDetails: []any{"name is required"},
+		},
+		{
+			name:        "rules",
+			body:        `{"name":"a","priority":10,"channel":"sms"}`,
+			wantMsg:     "priority should be at most 9; channel should be one of log webhook",
+			wantDetails: []any{"priority should be at most 9", "channel should be one of log webhook"},
+		},
+		{
+			name:    "json type",
+			body:    `{"name":1}`,
+			wantMsg: "name should be a string",
+		},
+		{
+			name:    "json syntax",
+			body:    `{"name":]`,
+			wantMsg: "the request body is not valid json at offset 9",
+		},
+		{
+			name:    "json time",
+			body:    `{"name":"a","due_at":"tomorrow"}`,
+			wantMsg: `invalid time "tomorrow", should be RFC 3339`,
+		},
+		{
+			name:    "empty body",
+			wantMsg: "the request body is empty",
+		},
+		{
+			name:    "query boolean",
+			query:   "?status=maybe",
+			body:    `{"name":"a"}`,
+			wantMsg: `invalid boolean "maybe"`,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/bind"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.query != "" {
+			// the query is bound by the form binding
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Body = http.NoBody
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, tt.name)
+
+		var resp ErrResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), tt.name)
+
+		assert.Equal(t, "INVALID_PARAMETER", resp.Name, tt.name)
+		assert.Equal(t, tt.wantMsg, resp.Message, tt.name)
+		assert.Equal(t, tt.wantDetails, resp.Details, tt.name)
+		assert.NotContains(t, w.Body.String(), "Request", tt.name)
+		assert.NotContains(t, w.Body.String(), "Go ", tt.name)
+	}
+}
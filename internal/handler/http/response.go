@@ -2,8 +2,13 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/tingchima/gogolook/infra/logger"
 	"github.com/tingchima/gogolook/internal/domain/common"
 )
 
@@ -14,11 +19,19 @@ type List struct {
 	NextPage  int   `json:"next_page"`
 }
 
+// errNameContextKey is the gin context key of the ErrCode name of error response.
+const errNameContextKey = "err_name"
+
 // ErrResponse .
 type ErrResponse struct {
-	Name    string `json:"name"`              // 錯誤名稱
-	Message string `json:"message"`           // 錯誤訊息
-	Details []any  `json:"details,omitempty"` // 錯誤細節
+	// 錯誤名稱
+	Name string `json:"name" enums:"INVALID_PARAMETER,UNAUTHORIZED,ACCESS_NOT_ALLOWED,RESOURCE_NOT_FOUND,METHOD_NOT_ALLOWED,RESOURCE_ALREADY_EXISTED,PRECONDITION_FAILED,REQUEST_TOO_LARGE,UNPROCESSABLE_ENTITY,TOO_MANY_REQUESTS,INTERNAL_PROCESS,SERVICE_UNAVAILABLE"`
+	// 錯誤訊息
+	Message string `json:"message"`
+	// 錯誤細節
+	Details []any `json:"details,omitempty"`
+	// 請求 ID, 同回應標頭 X-Request-ID
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // responseToList .
@@ -52,7 +65,14 @@ func responseWithNoContent(c *gin.Context, code int) {
 // responseWithError .
 func responseWithError(c *gin.Context, err error) {
 	_ = c.Error(err)
-	c.AbortWithStatusJSON(responseError(err))
+
+	statusCode, errResp := responseError(err)
+	errResp.RequestID = logger.RequestIDFromContext(c.Request.Context())
+
+	// for the error metrics
+	c.Set(errNameContextKey, errResp.Name)
+
+	c.AbortWithStatusJSON(statusCode, errResp)
 }
 
 func responseError(err error) (statusCode int, errResp ErrResponse) {
@@ -61,7 +81,7 @@ func responseError(err error) (statusCode int, errResp ErrResponse) {
 	_ = common.AsErr(err, &appErr)
 
 	if appErr == nil {
-		appErr = common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		appErr = common.NewError(common.ErrCodeInternalProcess, err)
 	}
 
 	causeErr := appErr.CauseErr()
@@ -72,7 +92,7 @@ func responseError(err error) (statusCode int, errResp ErrResponse) {
 
 		errDetails := make([]any, len(fieldsErrs))
 		for i := range fieldsErrs {
-			errDetails[i] = fieldsErrs[i].Error()
+			errDetails[i] = validationMessage(fieldsErrs[i])
 		}
 
 		// append error detail messages
@@ -89,3 +109,16 @@ func responseError(err error) (statusCode int, errResp ErrResponse) {
 
 	return
 }
+
+// requestBodyError is REQUEST_TOO_LARGE if the body exceeds the limit of http.MaxBytesReader,
+// otherwise INVALID_PARAMETER.
+func requestBodyError(err error) error {
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		msg := fmt.Sprintf("the request body is too large, at most %d bytes", maxBytesErr.Limit)
+		return common.NewError(common.ErrCodeRequestTooLarge, err, common.WithMsg(msg))
+	}
+
+	return invalidParameterError(err)
+}
@@ -2,13 +2,12 @@
 package http
 
 import (
-	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tingchima/gogolook/internal/application"
 	"github.com/tingchima/gogolook/internal/domain"
-	"github.com/tingchima/gogolook/internal/domain/common"
 	"gopkg.in/guregu/null.v4"
 )
 
@@ -20,13 +19,55 @@ type TaskResponse struct {
 	Name string `json:"name"`
 	// 任務狀態
 	Status bool `json:"status"`
+	// 到期時間
+	DueAt null.Time `json:"due_at" swaggertype:"string" format:"date-time"`
+	// 重複規則 (RFC 5545 RRULE)
+	RRule string `json:"rrule,omitempty"`
+	// 重複規則的時區 (IANA)
+	Timezone string `json:"timezone,omitempty"`
+	// 優先順序, 0 為未指定, 1 最高, 9 最低
+	Priority int `json:"priority" minimum:"0" maximum:"9"`
+	// 外部ID, 匯入時用來比對任務
+	ExternalID string `json:"external_id,omitempty"`
+}
+
+// newTaskResponse .
+func newTaskResponse(task domain.Task) TaskResponse {
+	return TaskResponse{
+		ID:         task.ID,
+		Name:       task.Name,
+		Status:     task.Status,
+		DueAt:      task.DueAt,
+		RRule:      task.RRule,
+		Timezone:   task.Timezone,
+		Priority:   task.Priority,
+		ExternalID: task.ExternalID,
+	}
+}
+
+// TaskFilterRequest .
+type TaskFilterRequest struct {
+	// 任務狀態
+	Status *bool `form:"status"`
+	// 任務名稱關鍵字
+	Name string `form:"name"`
+}
+
+// toParam .
+func (r TaskFilterRequest) toParam() domain.TaskParam {
+	return domain.TaskParam{
+		Status: null.BoolFromPtr(r.Status),
+		Name:   r.Name,
+	}
 }
 
 // @Summary 取得任務列表
 // @Router /tasks [GET]
 // @Produce json
 // @Tags Task
-// @Success 200 {object} List{data=[]http.TaskResponse} "任務列表"
+// @Param status query bool false "任務狀態"
+// @Param name query string false "任務名稱關鍵字"
+// @Success 200 {array} http.TaskResponse "任務列表"
 // @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
 // @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
 func ListTasks(app *application.Application) func(c *gin.Context) {
@@ -34,7 +75,14 @@ func ListTasks(app *application.Application) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 
-		tasks, err := app.TaskService.ListTasks(ctx, domain.TaskParam{})
+		var req TaskFilterRequest
+		err := c.ShouldBindQuery(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		tasks, err := app.TaskService.ListTasks(ctx, req.toParam())
 		if err != nil {
 			responseWithError(c, err)
 			return
@@ -43,11 +91,7 @@ func ListTasks(app *application.Application) func(c *gin.Context) {
 		response := make([]TaskResponse, len(tasks))
 
 		for i := range tasks {
-			response[i] = TaskResponse{
-				ID:     tasks[i].ID,
-				Name:   tasks[i].Name,
-				Status: tasks[i].Status,
-			}
+			response[i] = newTaskResponse(tasks[i])
 		}
 
 		responseWithJSON(c, http.StatusOK, response)
@@ -56,10 +100,14 @@ func ListTasks(app *application.Application) func(c *gin.Context) {
 
 // @Summary 建立任務
 // @Router /task [POST]
+// @Accept json,x-www-form-urlencoded
 // @Produce json
 // @Tags Task
-// @Success 201 {object} http.TaskResponse "任務內容"
+// @Param Idempotency-Key header string false "重試時帶相同的 key, 回傳第一次請求的回應 (回應標頭 Idempotent-Replayed: true)"
+// @Param request body Request true "任務內容"
+// @Success 200 {object} http.TaskResponse "任務內容"
 // @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 409 {object} ErrResponse "相同 Idempotency-Key 的請求處理中"
 // @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
 func CreateTask(app *application.Application) func(c *gin.Context) {
 
@@ -67,6 +115,14 @@ func CreateTask(app *application.Application) func(c *gin.Context) {
 	type Request struct {
 		// 任務名稱
 		Name string `form:"name" json:"name" binding:"required"`
+		// 到期時間 (RFC 3339)
+		DueAt *time.Time `form:"due_at" json:"due_at" time_format:"2006-01-02T15:04:05Z07:00"`
+		// 重複規則 (RFC 5545 RRULE), 例如 FREQ=MONTHLY;BYMONTHDAY=1
+		RRule string `form:"rrule" json:"rrule"`
+		// 重複規則的時區 (IANA), 例如 Asia/Taipei
+		Timezone string `form:"timezone" json:"timezone"`
+		// 優先順序, 0 為未指定, 1 最高, 9 最低
+		Priority int `form:"priority" json:"priority" binding:"min=0,max=9"`
 	}
 
 	return func(c *gin.Context) {
@@ -75,32 +131,35 @@ func CreateTask(app *application.Application) func(c *gin.Context) {
 		var req Request
 		err := c.ShouldBind(&req)
 		if err != nil {
-			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+			responseWithError(c, invalidParameterError(err))
 			return
 		}
 
-		createdTask, err := app.TaskService.CreateTask(ctx, domain.Task{Name: req.Name})
+		createdTask, err := app.TaskService.CreateTask(ctx, domain.Task{
+			Name:     req.Name,
+			DueAt:    null.TimeFromPtr(req.DueAt),
+			RRule:    req.RRule,
+			Timezone: req.Timezone,
+			Priority: req.Priority,
+		})
 		if err != nil {
-			fmt.Println(err.Error())
 			responseWithError(c, err)
 			return
 		}
 
-		response := TaskResponse{
-			ID:     createdTask.ID,
-			Name:   createdTask.Name,
-			Status: createdTask.Status,
-		}
+		response := newTaskResponse(*createdTask)
 
 		responseWithJSON(c, http.StatusOK, response)
 	}
 }
 
-// @Summary 聊天室訊息輸入中
+// @Summary 修改任務
 // @Router /task/:id [PUT]
+// @Accept json,x-www-form-urlencoded
 // @Produce json
 // @Tags Task
 // @Param id path int true "任務ID"
+// @Param request body Request true "任務內容"
 // @Success 200 {object} http.TaskResponse "任務內容"
 // @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
 // @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
@@ -114,7 +173,7 @@ func UpdateTask(app *application.Application) func(c *gin.Context) {
 		// 任務名稱
 		Name string `form:"name" json:"name" binding:"required"`
 		// 任務狀態
-		Status null.Bool `form:"status" json:"status" binding:"required"`
+		Status null.Bool `form:"status" json:"status" binding:"required" swaggertype:"boolean"`
 	}
 
 	return func(c *gin.Context) {
@@ -123,7 +182,7 @@ func UpdateTask(app *application.Application) func(c *gin.Context) {
 		var req Request
 		err := c.ShouldBind(&req)
 		if err != nil {
-			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(err.Error())))
+			responseWithError(c, invalidParameterError(err))
 			return
 		}
 
@@ -143,17 +202,13 @@ func UpdateTask(app *application.Application) func(c *gin.Context) {
 			return
 		}
 
-		response := TaskResponse{
-			ID:     updatedTask.ID,
-			Name:   updatedTask.Name,
-			Status: updatedTask.Status,
-		}
+		response := newTaskResponse(*updatedTask)
 
 		responseWithJSON(c, http.StatusOK, response)
 	}
 }
 
-// @Summary 聊天室訊息輸入中
+// @Summary 刪除任務
 // @Router /task/:id [DELETE]
 // @Produce json
 // @Tags Task
@@ -0,0 +1,165 @@
+// Package http provides
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/internal/application"
+)
+
+// @Summary 設定任務的重複規則
+// @Router /task/:id/recurrence [PUT]
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Tags Task
+// @Param id path int true "任務ID"
+// @Param request body Request true "重複規則"
+// @Success 200 {object} http.TaskResponse "任務內容"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func SetTaskRecurrence(app *application.Application) func(c *gin.Context) {
+
+	// Request .
+	type Request struct {
+		// 系列起始的到期時間 (RFC 3339)
+		DueAt time.Time `form:"due_at" json:"due_at" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+		// 重複規則 (RFC 5545 RRULE), 例如 FREQ=MONTHLY;BYMONTHDAY=1
+		RRule string `form:"rrule" json:"rrule" binding:"required"`
+		// 重複規則的時區 (IANA), 例如 Asia/Taipei
+		Timezone string `form:"timezone" json:"timezone"`
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req Request
+		err := c.ShouldBind(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		updatedTask, err := app.TaskService.SetRecurrence(ctx, int64(taskID), req.DueAt, req.RRule, req.Timezone)
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithJSON(c, http.StatusOK, newTaskResponse(*updatedTask))
+	}
+}
+
+// @Summary 預覽重複任務接下來的發生時間
+// @Router /task/:id/occurrences [GET]
+// @Produce json
+// @Tags Task
+// @Param id path int true "任務ID"
+// @Param count query int false "筆數, 預設 5, 最多 100"
+// @Success 200 {array} time.Time "發生時間 (RFC 3339, 任務時區)"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func PreviewTaskOccurrences(app *application.Application) func(c *gin.Context) {
+
+	// Request .
+	type Request struct {
+		// 筆數
+		Count int `form:"count" binding:"min=0"`
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req Request
+		err := c.ShouldBindQuery(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		occurrences, err := app.TaskService.PreviewOccurrences(ctx, int64(taskID), req.Count)
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithJSON(c, http.StatusOK, occurrences)
+	}
+}
+
+// @Summary 跳過重複任務目前的發生時間
+// @Router /task/:id/recurrence/skip [POST]
+// @Produce json
+// @Tags Task
+// @Param id path int true "任務ID"
+// @Param Idempotency-Key header string false "重試時帶相同的 key, 回傳第一次請求的回應 (回應標頭 Idempotent-Replayed: true)"
+// @Success 200 {object} http.TaskResponse "任務內容"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 409 {object} ErrResponse "相同 Idempotency-Key 的請求處理中"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func SkipTaskOccurrence(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		updatedTask, err := app.TaskService.SkipOccurrence(ctx, int64(taskID))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithJSON(c, http.StatusOK, newTaskResponse(*updatedTask))
+	}
+}
+
+// @Summary 停止重複任務
+// @Router /task/:id/recurrence [DELETE]
+// @Produce json
+// @Tags Task
+// @Param id path int true "任務ID"
+// @Success 200 {object} http.TaskResponse "任務內容"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 404 {object} ErrResponse "{"code":"400404","message":"User not found"}" "找不到此資源"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func StopTaskRecurrence(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		taskID, err := GetPathInt(c, "id")
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		updatedTask, err := app.TaskService.StopRecurrence(ctx, int64(taskID))
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		responseWithJSON(c, http.StatusOK, newTaskResponse(*updatedTask))
+	}
+}
@@ -0,0 +1,144 @@
+// Package http provides
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+const (
+	// streamKeepAliveInterval keeps the idle connection from being closed by proxies.
+	streamKeepAliveInterval = 15 * time.Second
+
+	// streamRetry is the reconnection time in milliseconds suggested to clients.
+	streamRetry = 3000
+
+	// streamEventReset tells the client that some events can not be replayed,
+	// it should reload the task list.
+	streamEventReset = "reset"
+)
+
+// TaskEventResponse .
+type TaskEventResponse struct {
+	// 事件類型: created, updated, deleted, removed (修改後不再符合篩選條件)
+	Type string `json:"type" enums:"created,updated,deleted,removed"`
+	// 任務內容, 刪除事件只會帶有任務ID
+	Task TaskResponse `json:"task"`
+	// 發生時間
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// @Summary 訂閱任務異動事件 (Server-Sent Events)
+// @Router /tasks/stream [GET]
+// @Produce text/event-stream
+// @Tags Task
+// @Param status query bool false "任務狀態"
+// @Param name query string false "任務名稱關鍵字"
+// @Param Last-Event-ID header int false "最後收到的事件ID, 用於斷線後續傳"
+// @Success 200 {object} http.TaskEventResponse "任務異動事件, 每個事件的 data 為 TaskEventResponse"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func StreamTasks(app *application.Application) func(c *gin.Context) {
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req TaskFilterRequest
+		err := c.ShouldBindQuery(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		lastEventID, err := getLastEventID(c)
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		sub, replay, complete := app.TaskService.SubscribeTaskEvents(req.toParam(), lastEventID)
+		defer sub.Close()
+
+		c.Header("Content-Type", sse.ContentType)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		// the retry field without data does not dispatch an event on the client
+		_, _ = fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry)
+
+		if !complete {
+			c.Render(-1, sse.Event{Event: streamEventReset, Data: "some events are missed, please reload the task list"})
+		}
+
+		for i := range replay {
+			renderTaskEvent(c, replay[i])
+		}
+		c.Writer.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-sub.C:
+				if !ok {
+					// broker is closed or the client is too slow
+					return
+				}
+				renderTaskEvent(c, event)
+
+			case <-keepAlive.C:
+				_, _ = fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			}
+
+			c.Writer.Flush()
+		}
+	}
+}
+
+// renderTaskEvent .
+func renderTaskEvent(c *gin.Context, event domain.TaskEvent) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: string(event.Type),
+		Data: TaskEventResponse{
+			Type:       string(event.Type),
+			Task:       newTaskResponse(event.Task),
+			OccurredAt: event.OccurredAt,
+		},
+	})
+}
+
+// getLastEventID reads the Last-Event-ID header sent by EventSource on reconnection,
+// or the last_event_id query for clients which can not set header.
+func getLastEventID(c *gin.Context) (int64, error) {
+	strVal := c.GetHeader("Last-Event-ID")
+	if strVal == "" {
+		strVal = c.Query("last_event_id")
+	}
+	if strVal == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(strVal, 10, 64)
+	if err != nil || id < 0 {
+		msg := "the Last-Event-ID value is invalid"
+		return 0, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg))
+	}
+
+	return id, nil
+}
@@ -0,0 +1,158 @@
+// Package http provides
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/application/task/mocks"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// sseBlock is the fields of a server-sent block, the comments are skipped.
+type sseBlock map[string]string
+
+// sseStream .
+type sseStream struct {
+	reader *bufio.Reader
+	close  func()
+}
+
+// openStream sends the stream request, it is closed when the test finishes.
+func openStream(t *testing.T, url string, lastEventID string) *sseStream {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	stream := &sseStream{
+		reader: bufio.NewReader(resp.Body),
+		close: func() {
+			cancel()
+			_ = resp.Body.Close()
+		},
+	}
+	t.Cleanup(stream.close)
+
+	return stream
+}
+
+// next reads the next block.
+func (s *sseStream) next(t *testing.T) sseBlock {
+
+	block := sseBlock{}
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(block) > 0 {
+				return block
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		block[field] = strings.TrimPrefix(value, " ")
+	}
+}
+
+// TestStreamTasks .
+func TestStreamTasks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	repo := mocks.NewMockRepository(gomock.NewController(t))
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(txRepo task.Repository) error, _ ...task.TxOption) error {
+			return fn(repo)
+		},
+	).AnyTimes()
+	repo.EXPECT().LockTaskByID(gomock.Any(), int64(1)).Return(&domain.Task{ID: 1, Name: "繳房租"}, nil)
+	repo.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, param domain.Task) (*domain.Task, error) {
+		return &param, nil
+	})
+	repo.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Return(&domain.Task{ID: 2, Name: "繳水費"}, nil)
+
+	app := &application.Application{
+		TaskService: task.NewService(task.ServiceParam{PostgresRepo: repo}),
+	}
+
+	handler := gin.New()
+	handler.GET("/tasks/stream", StreamTasks(app))
+
+	// registered before the streams, so the streams are closed first
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	url := server.URL + "/tasks/stream?status=false"
+
+	stream := openStream(t, url, "")
+
+	// the retry hint is not an event, it has no data
+	assert.Equal(t, sseBlock{"retry": "3000"}, stream.next(t))
+
+	// the completed task is moved out of the filter
+	_, err := app.TaskService.UpdateTask(ctx, domain.Task{ID: 1, Name: "繳房租", Status: true})
+	require.NoError(t, err)
+
+	block := stream.next(t)
+	assert.Equal(t, "1", block["id"])
+	assert.Equal(t, string(domain.TaskEventRemoved), block["event"])
+
+	var event TaskEventResponse
+	require.NoError(t, json.Unmarshal([]byte(block["data"]), &event))
+	assert.Equal(t, string(domain.TaskEventRemoved), event.Type)
+	assert.Equal(t, int64(1), event.Task.ID)
+
+	stream.close()
+
+	// the events after Last-Event-ID are replayed on reconnection
+	_, err = app.TaskService.CreateTask(ctx, domain.Task{Name: "繳水費"})
+	require.NoError(t, err)
+
+	resumed := openStream(t, url, "1")
+	assert.Equal(t, sseBlock{"retry": "3000"}, resumed.next(t))
+
+	block = resumed.next(t)
+	assert.Equal(t, "2", block["id"])
+	assert.Equal(t, string(domain.TaskEventCreated), block["event"])
+
+	// the events after an unknown id can not be replayed
+	reset := openStream(t, url, "10")
+	assert.Equal(t, sseBlock{"retry": "3000"}, reset.next(t))
+	assert.Equal(t, streamEventReset, reset.next(t)["event"])
+
+	// the invalid Last-Event-ID
+	req := httptest.NewRequest(http.MethodGet, "/tasks/stream", nil)
+	req.Header.Set("Last-Event-ID", "-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,292 @@
+// Package http provides
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tingchima/gogolook/internal/application"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"github.com/tingchima/gogolook/internal/taskio"
+)
+
+// MaxImportBodySize is the maximum size of the import file.
+const MaxImportBodySize = 10 << 20
+
+// ErrImportFormatRequired is returned when the format can not be decided by the query, the filename or the content type.
+var ErrImportFormatRequired = errors.New("the format is required, should be csv, json, ndjson, todotxt or markdown")
+
+// TaskImportResponse .
+type TaskImportResponse struct {
+	// 是否為試算
+	DryRun bool `json:"dry_run"`
+	// 新增的任務數量
+	Created int `json:"created"`
+	// 更新的任務數量
+	Updated int `json:"updated"`
+	// 每一列的匯入結果
+	Rows []TaskImportRowResponse `json:"rows"`
+}
+
+// TaskImportRowResponse .
+type TaskImportRowResponse struct {
+	// 資料列序號, 從 1 開始
+	Row int `json:"row"`
+	// 動作: create, update
+	Action string `json:"action" enums:"create,update"`
+	// 任務內容, 試算時新增的任務沒有ID
+	Task TaskResponse `json:"task"`
+}
+
+// exportWriter commits the response headers at the first write,
+// so that an error before any task is written can still be responded as ErrResponse.
+type exportWriter struct {
+	c        *gin.Context
+	format   taskio.Format
+	filename string
+	started  bool
+}
+
+// Write .
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.format.ContentType())
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// @Summary 匯出任務
+// @Router /tasks/export [GET]
+// @Produce text/csv,application/json,application/x-ndjson,text/plain,text/markdown
+// @Tags Task
+// @Param format query string false "格式: csv, json, ndjson, todotxt, markdown, 預設 csv"
+// @Param status query bool false "任務狀態"
+// @Param name query string false "任務名稱關鍵字"
+// @Success 200 {file} file "匯出檔案"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func ExportTasks(app *application.Application) func(c *gin.Context) {
+
+	// Request .
+	type Request struct {
+		TaskFilterRequest
+		// 格式: csv, json, ndjson, todotxt, markdown
+		Format string `form:"format"`
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req Request
+		err := c.ShouldBindQuery(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		if req.Format == "" {
+			req.Format = string(taskio.FormatCSV)
+		}
+
+		format, err := taskio.ParseFormat(req.Format)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		w := &exportWriter{
+			c:        c,
+			format:   format,
+			filename: fmt.Sprintf("tasks-%s.%s", time.Now().UTC().Format("20060102150405"), format.Extension()),
+		}
+
+		enc, err := taskio.NewEncoder(format, w)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		err = app.TaskService.ExportTasks(ctx, req.toParam(), enc.Encode)
+		if err == nil {
+			err = enc.Close()
+		}
+		if err != nil {
+			if w.started {
+				// the response has been partially written, the client sees a truncated file
+				_ = c.Error(err)
+				c.Abort()
+				return
+			}
+			responseWithError(c, err)
+			return
+		}
+	}
+}
+
+// @Summary 匯入任務
+// @Description 上傳 csv, json 或 ndjson 檔案 (multipart 欄位 file 或整個 body), 有 external_id 且已存在的任務會被更新, 其餘新增; 任一列錯誤則全部不匯入
+// @Router /tasks/import [POST]
+// @Accept text/csv,application/json,application/x-ndjson,text/plain,text/markdown,multipart/form-data
+// @Produce json
+// @Tags Task
+// @Param format query string false "格式: csv, json, ndjson, todotxt, markdown, 預設依 Content-Type 或檔名判斷"
+// @Param dry_run query bool false "只驗證, 不寫入"
+// @Param Idempotency-Key header string false "重試時帶相同的 key, 回傳第一次請求的回應 (回應標頭 Idempotent-Replayed: true)"
+// @Param file formData file false "匯入檔案"
+// @Success 200 {object} http.TaskImportResponse "匯入結果"
+// @Failure 400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"
+// @Failure 409 {object} ErrResponse "相同 Idempotency-Key 的請求處理中"
+// @Failure 500 {object} ErrResponse "{"code":"500000","message":"Internal server error"}" "伺服器內部錯誤"
+func ImportTasks(app *application.Application) func(c *gin.Context) {
+
+	// Request .
+	type Request struct {
+		// 格式: csv, json, ndjson, todotxt, markdown
+		Format string `form:"format"`
+		// 只驗證, 不寫入
+		DryRun bool `form:"dry_run"`
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req Request
+		err := c.ShouldBindQuery(&req)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBodySize)
+
+		body, filename, err := importFile(c)
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+		defer body.Close()
+
+		format, err := importFormat(req.Format, c.ContentType(), filename)
+		if err != nil {
+			responseWithError(c, invalidParameterError(err))
+			return
+		}
+
+		rows, rowErrs, err := taskio.Decode(format, body, task.MaxImportRows)
+		if err != nil {
+			responseWithError(c, importBodyError(err))
+			return
+		}
+
+		if len(rowErrs) > 0 {
+			details := make([]any, len(rowErrs))
+			for i := range rowErrs {
+				details[i] = rowErrs[i].Error()
+			}
+
+			msg := "some rows are invalid"
+			responseWithError(c, common.NewError(common.ErrCodeInvalidParameter, errors.New(msg), common.WithMsg(msg), common.WithDetail(details)))
+			return
+		}
+
+		results, err := app.TaskService.ImportTasks(ctx, rows, req.DryRun)
+		if err != nil {
+			responseWithError(c, err)
+			return
+		}
+
+		response := TaskImportResponse{
+			DryRun: req.DryRun,
+			Rows:   make([]TaskImportRowResponse, len(results)),
+		}
+
+		for i := range results {
+			response.Rows[i] = TaskImportRowResponse{
+				Row:    results[i].Row,
+				Action: string(results[i].Action),
+				Task:   newTaskResponse(results[i].Task),
+			}
+
+			if results[i].Action == domain.TaskImportCreate {
+				response.Created++
+			} else {
+				response.Updated++
+			}
+		}
+
+		responseWithJSON(c, http.StatusOK, response)
+	}
+}
+
+// importFile returns the uploaded file of multipart form, or the request body.
+func importFile(c *gin.Context) (io.ReadCloser, string, error) {
+
+	if c.ContentType() != gin.MIMEMultipartPOSTForm {
+		return c.Request.Body, "", nil
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, "", importBodyError(err)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, "", common.NewError(common.ErrCodeInternalProcess, err)
+	}
+
+	return file, header.Filename, nil
+}
+
+// importFormat decides the format by the query, then the filename and the content type.
+func importFormat(format string, contentType string, filename string) (taskio.Format, error) {
+
+	if format != "" {
+		return taskio.ParseFormat(format)
+	}
+
+	if ext := strings.TrimPrefix(filepath.Ext(filename), "."); ext != "" {
+		return taskio.ParseFormat(ext)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return taskio.FormatCSV, nil
+	case "application/json":
+		return taskio.FormatJSON, nil
+	case "application/x-ndjson", "application/jsonl":
+		return taskio.FormatNDJSON, nil
+	case "text/plain":
+		return taskio.FormatTodoTxt, nil
+	case "text/markdown":
+		return taskio.FormatMarkdown, nil
+	}
+
+	return "", ErrImportFormatRequired
+}
+
+// importBodyError .
+func importBodyError(err error) error {
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		msg := fmt.Sprintf("the import file is too large, at most %d bytes", maxBytesErr.Limit)
+		return common.NewError(common.ErrCodeRequestTooLarge, err, common.WithMsg(msg))
+	}
+
+	return invalidParameterError(err)
+}
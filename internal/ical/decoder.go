@@ -0,0 +1,309 @@
+// Package ical provides the iCalendar (RFC 5545) encoding of tasks.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// maxCalendarSize is the maximum size of a calendar object to be decoded.
+const maxCalendarSize = 1 << 20
+
+var (
+	ErrNoVTodo       = errors.New("the calendar should contain a VTODO component")
+	ErrMultipleVTodo = errors.New("the calendar should contain only one VTODO component")
+)
+
+// Property is a content line, the value is not unescaped.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component .
+type Component struct {
+	Name       string
+	Props      []Property
+	Components []*Component
+}
+
+// Prop returns the first property of name.
+func (c *Component) Prop(name string) (Property, bool) {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return c.Props[i], true
+		}
+	}
+	return Property{}, false
+}
+
+// Parse reads an iCalendar object, the folded lines are unfolded.
+func Parse(r io.Reader) (*Component, error) {
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxCalendarSize))
+	scanner.Buffer(make([]byte, 0, 4096), maxCalendarSize)
+
+	var (
+		lines []string
+		stack []*Component
+		root  *Component
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lines {
+		prop, err := parseContentLine(lines[i])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			} else if root != nil {
+				return nil, errors.New("the calendar should have only one root component")
+			} else {
+				root = component
+			}
+			stack = append(stack, component)
+
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: the property %s is outside of component", i+1, prop.Name)
+			}
+			component := stack[len(stack)-1]
+			component.Props = append(component.Props, prop)
+		}
+	}
+
+	if root == nil || len(stack) > 0 {
+		return nil, errors.New("the calendar is incomplete")
+	}
+
+	return root, nil
+}
+
+// parseContentLine parses "name *(;param=value):value", the param values may be quoted.
+func parseContentLine(line string) (Property, error) {
+
+	prop := Property{Params: make(map[string]string)}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return prop, fmt.Errorf("invalid content line %q", line)
+	}
+	prop.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		line = line[i+1:]
+
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return prop, fmt.Errorf("invalid parameter of %s", prop.Name)
+		}
+		name := strings.ToUpper(line[:eq])
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				return prop, fmt.Errorf("unterminated quoted parameter of %s", prop.Name)
+			}
+			value = line[1 : end+1]
+			line = line[end+2:]
+			i = 0
+		} else {
+			i = strings.IndexAny(line, ";:")
+			if i < 0 {
+				return prop, fmt.Errorf("invalid parameter of %s", prop.Name)
+			}
+			value = line[:i]
+			line = line[i:]
+			i = 0
+		}
+		prop.Params[name] = value
+
+		if line == "" {
+			return prop, fmt.Errorf("the value of %s is missing", prop.Name)
+		}
+	}
+
+	if line[i] != ':' {
+		return prop, fmt.Errorf("invalid content line of %s", prop.Name)
+	}
+	prop.Value = line[i+1:]
+
+	return prop, nil
+}
+
+// unescapeText .
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// DecodeTask reads a calendar object of a single VTODO, the overridden occurrences
+// (RECURRENCE-ID) are not supported and ignored. The UID of VTODO is returned.
+func DecodeTask(r io.Reader) (task domain.Task, uid string, err error) {
+
+	root, err := Parse(r)
+	if err != nil {
+		return domain.Task{}, "", err
+	}
+
+	if root.Name != "VCALENDAR" {
+		return domain.Task{}, "", errors.New("the root component should be VCALENDAR")
+	}
+
+	var todo *Component
+	for _, component := range root.Components {
+		if component.Name != "VTODO" {
+			continue
+		}
+		if _, ok := component.Prop("RECURRENCE-ID"); ok {
+			continue
+		}
+		if todo != nil {
+			return domain.Task{}, "", ErrMultipleVTodo
+		}
+		todo = component
+	}
+
+	if todo == nil {
+		return domain.Task{}, "", ErrNoVTodo
+	}
+
+	return decodeVTodo(todo)
+}
+
+// decodeVTodo .
+func decodeVTodo(todo *Component) (task domain.Task, uid string, err error) {
+
+	prop, ok := todo.Prop("UID")
+	if !ok || strings.TrimSpace(prop.Value) == "" {
+		return domain.Task{}, "", errors.New("the UID of VTODO is required")
+	}
+	uid = unescapeText(strings.TrimSpace(prop.Value))
+
+	if prop, ok := todo.Prop("SUMMARY"); ok {
+		task.Name = unescapeText(prop.Value)
+	}
+
+	if prop, ok := todo.Prop("STATUS"); ok && strings.EqualFold(prop.Value, "COMPLETED") {
+		task.Status = true
+	}
+	if _, ok := todo.Prop("COMPLETED"); ok {
+		task.Status = true
+	}
+
+	if prop, ok := todo.Prop("PRIORITY"); ok {
+		task.Priority, err = strconv.Atoi(strings.TrimSpace(prop.Value))
+		if err != nil {
+			return domain.Task{}, "", fmt.Errorf("invalid PRIORITY %q", prop.Value)
+		}
+	}
+
+	if prop, ok := todo.Prop("RRULE"); ok {
+		task.RRule = strings.TrimSpace(prop.Value)
+	}
+
+	// the due time of recurring task is the start of series if DUE is omitted
+	dueProp, ok := todo.Prop("DUE")
+	if !ok && task.IsRecurring() {
+		dueProp, ok = todo.Prop("DTSTART")
+	}
+
+	if ok {
+		dueAt, timezone, err := parseDateTime(dueProp)
+		if err != nil {
+			return domain.Task{}, "", err
+		}
+		task.DueAt = null.TimeFrom(dueAt)
+		task.Timezone = timezone
+	}
+
+	return task, uid, nil
+}
+
+// parseDateTime parses DATE-TIME in UTC, with TZID or floating, and DATE.
+// The floating time without TZID is regarded as UTC.
+func parseDateTime(prop Property) (t time.Time, timezone string, err error) {
+
+	loc := time.UTC
+
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		loc, err = time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("unsupported TZID %q of %s, should be an IANA timezone", tzid, prop.Name)
+		}
+		if loc != time.UTC {
+			timezone = loc.String()
+		}
+	}
+
+	value := strings.TrimSpace(prop.Value)
+
+	switch {
+	case strings.EqualFold(prop.Params["VALUE"], "DATE") || len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse(formatDateTimeUTC, value)
+		timezone = ""
+	default:
+		t, err = time.ParseInLocation(formatDateTimeLocal, value, loc)
+	}
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid %s %q", prop.Name, prop.Value)
+	}
+
+	return t, timezone, nil
+}
@@ -0,0 +1,165 @@
+// Package ical provides the iCalendar (RFC 5545) encoding of tasks.
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestDecodeTask_RoundTrip .
+func TestDecodeTask_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tasks := []domain.Task{
+		{
+			ID:        1,
+			Name:      strings.Repeat("pay rent; water, power\nand gas 電費 ", 5),
+			Priority:  1,
+			DueAt:     null.TimeFrom(time.Date(2024, 2, 1, 1, 0, 0, 0, time.UTC)),
+			RRule:     "FREQ=MONTHLY;BYMONTHDAY=1",
+			Timezone:  "America/New_York",
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:         2,
+			ExternalID: "abc@example.com",
+			Name:       "done",
+			Status:     true,
+			DueAt:      null.TimeFrom(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:   3,
+			Name: "someday",
+		},
+	}
+
+	for _, task := range tasks {
+		var buf bytes.Buffer
+
+		enc := NewEncoder(&buf, EncoderParam{})
+		require.NoError(t, enc.Encode(task))
+		require.NoError(t, enc.Close())
+
+		got, uid, err := DecodeTask(&buf)
+		require.NoError(t, err)
+
+		assert.Equal(t, TaskUID(task), uid)
+		assert.Equal(t, task.Name, got.Name)
+		assert.Equal(t, task.Status, got.Status)
+		assert.Equal(t, task.Priority, got.Priority)
+		assert.Equal(t, task.RRule, got.RRule)
+		assert.Equal(t, task.Timezone, got.Timezone)
+		assert.Equal(t, task.DueAt.Valid, got.DueAt.Valid)
+		assert.True(t, task.DueAt.Time.Equal(got.DueAt.Time))
+	}
+}
+
+// TestDecodeTask .
+func TestDecodeTask(t *testing.T) {
+	t.Parallel()
+
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+
+	calendar := func(lines ...string) string {
+		return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR", ""), "\r\n")
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    domain.Task
+		wantUID string
+		wantErr error
+	}{
+		{
+			name: "tzid",
+			data: calendar("BEGIN:VTODO", "UID:1", "SUMMARY:a", `DUE;TZID="Asia/Taipei":20240105T090000`, "END:VTODO"),
+			want: domain.Task{
+				Name:     "a",
+				DueAt:    null.TimeFrom(time.Date(2024, 1, 5, 9, 0, 0, 0, taipei)),
+				Timezone: "Asia/Taipei",
+			},
+			wantUID: "1",
+		},
+		{
+			name:    "date",
+			data:    calendar("BEGIN:VTODO", "UID:2", "SUMMARY:b", "DUE;VALUE=DATE:20240105", "STATUS:COMPLETED", "END:VTODO"),
+			want:    domain.Task{Name: "b", Status: true, DueAt: null.TimeFrom(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))},
+			wantUID: "2",
+		},
+		{
+			name: "recurring without due",
+			data: calendar("BEGIN:VTODO", "UID:3", "SUMMARY:c", "DTSTART:20240105T090000Z", "RRULE:FREQ=DAILY", "END:VTODO"),
+			want: domain.Task{
+				Name:  "c",
+				DueAt: null.TimeFrom(time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)),
+				RRule: "FREQ=DAILY",
+			},
+			wantUID: "3",
+		},
+		{
+			name:    "folded with overridden occurrence",
+			data:    calendar("BEGIN:VTODO", "UID:4", "SUMMARY:long", " er\\, text", "END:VTODO", "BEGIN:VTODO", "UID:4", "RECURRENCE-ID:20240105T090000Z", "END:VTODO"),
+			want:    domain.Task{Name: "longer, text"},
+			wantUID: "4",
+		},
+		{
+			name:    "no vtodo",
+			data:    calendar("BEGIN:VEVENT", "UID:5", "END:VEVENT"),
+			wantErr: ErrNoVTodo,
+		},
+		{
+			name:    "multiple vtodo",
+			data:    calendar("BEGIN:VTODO", "UID:6", "END:VTODO", "BEGIN:VTODO", "UID:7", "END:VTODO"),
+			wantErr: ErrMultipleVTodo,
+		},
+		{
+			name:    "unsupported tzid",
+			data:    calendar("BEGIN:VTODO", "UID:8", "DUE;TZID=Custom Zone:20240105T090000", "END:VTODO"),
+			wantErr: errAny,
+		},
+		{
+			name:    "incomplete",
+			data:    "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:9\r\n",
+			wantErr: errAny,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, uid, err := DecodeTask(strings.NewReader(tt.data))
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				if tt.wantErr != errAny {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUID, uid)
+			assert.Equal(t, tt.want.Name, got.Name)
+			assert.Equal(t, tt.want.Status, got.Status)
+			assert.Equal(t, tt.want.RRule, got.RRule)
+			assert.Equal(t, tt.want.Timezone, got.Timezone)
+			assert.Equal(t, tt.want.DueAt.Valid, got.DueAt.Valid)
+			assert.True(t, tt.want.DueAt.Time.Equal(got.DueAt.Time))
+		})
+	}
+}
+
+// errAny matches any error in the tests.
+var errAny = errors.New("any error")
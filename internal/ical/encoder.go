@@ -0,0 +1,213 @@
+// Package ical provides the iCalendar (RFC 5545) encoding of tasks.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// UIDDomain is the domain part of the UID of tasks.
+const UIDDomain = "gogolook"
+
+// TaskUID returns the globally unique identifier of the task,
+// the tasks created by calendar clients keep their UID as the external ID.
+func TaskUID(task domain.Task) string {
+	if task.ExternalID != "" {
+		return task.ExternalID
+	}
+	return fmt.Sprintf("task-%d@%s", task.ID, UIDDomain)
+}
+
+// EncoderParam .
+type EncoderParam struct {
+	// 日曆名稱 (X-WR-CALNAME), 空值則不輸出
+	Name string
+	// Now is the time of DTSTAMP when the task has never been modified, default is time.Now.
+	Now time.Time
+}
+
+// Encoder writes a VCALENDAR of VTODO components, the tasks are written one by one
+// and END:VCALENDAR is written by Close.
+type Encoder struct {
+	lw        *lineWriter
+	param     EncoderParam
+	started   bool
+	timezones map[string]bool
+}
+
+// NewEncoder .
+func NewEncoder(w io.Writer, param EncoderParam) *Encoder {
+
+	if param.Now.IsZero() {
+		param.Now = time.Now()
+	}
+
+	return &Encoder{
+		lw:        newLineWriter(w),
+		param:     param,
+		timezones: make(map[string]bool),
+	}
+}
+
+// Encode writes the VTODO of the task, the VTIMEZONE of the task is written before it
+// if it has not been written.
+func (e *Encoder) Encode(task domain.Task) error {
+
+	e.writeHeader()
+
+	loc := time.UTC
+	if task.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(task.Timezone)
+		if err != nil {
+			return fmt.Errorf("load timezone %q of task %d: %w", task.Timezone, task.ID, err)
+		}
+
+		if !e.timezones[task.Timezone] {
+			e.timezones[task.Timezone] = true
+			e.lw.writeVTimezone(loc, e.param.Now.In(loc).Year())
+		}
+	}
+
+	e.writeVTodo(task, loc)
+
+	return e.lw.err
+}
+
+// Close .
+func (e *Encoder) Close() error {
+
+	e.writeHeader()
+	e.lw.writeLine("END", "VCALENDAR")
+
+	return e.lw.flush()
+}
+
+// writeHeader .
+func (e *Encoder) writeHeader() {
+
+	if e.started {
+		return
+	}
+	e.started = true
+
+	e.lw.writeLine("BEGIN", "VCALENDAR")
+	e.lw.writeLine("VERSION", "2.0")
+	e.lw.writeLine("PRODID", ProdID)
+	e.lw.writeLine("CALSCALE", "GREGORIAN")
+	if e.param.Name != "" {
+		e.lw.writeLine("X-WR-CALNAME", escapeText(e.param.Name))
+	}
+}
+
+// writeVTodo .
+func (e *Encoder) writeVTodo(task domain.Task, loc *time.Location) {
+
+	lastModified := task.UpdatedAt
+	if lastModified.IsZero() {
+		lastModified = task.CreatedAt
+	}
+
+	dtStamp := lastModified
+	if dtStamp.IsZero() {
+		dtStamp = e.param.Now
+	}
+
+	e.lw.writeLine("BEGIN", "VTODO")
+	e.lw.writeLine("UID", escapeText(TaskUID(task)))
+	e.lw.writeLine("DTSTAMP", formatUTC(dtStamp))
+	if !task.CreatedAt.IsZero() {
+		e.lw.writeLine("CREATED", formatUTC(task.CreatedAt))
+	}
+	if !lastModified.IsZero() {
+		e.lw.writeLine("LAST-MODIFIED", formatUTC(lastModified))
+	}
+	e.lw.writeLine("SUMMARY", escapeText(task.Name))
+
+	if task.Status {
+		e.lw.writeLine("STATUS", "COMPLETED")
+		e.lw.writeLine("PERCENT-COMPLETE", "100")
+		if !lastModified.IsZero() {
+			e.lw.writeLine("COMPLETED", formatUTC(lastModified))
+		}
+	} else {
+		e.lw.writeLine("STATUS", "NEEDS-ACTION")
+	}
+
+	if task.Priority != domain.TaskPriorityUndefined {
+		e.lw.writeLine("PRIORITY", strconv.Itoa(task.Priority))
+	}
+
+	if task.DueAt.Valid {
+		// the recurrence is expanded from DTSTART, which is the due time of the current occurrence
+		if task.IsRecurring() {
+			e.writeDateTime("DTSTART", task.DueAt.Time, loc)
+		}
+		e.writeDateTime("DUE", task.DueAt.Time, loc)
+	}
+
+	if task.IsRecurring() {
+		e.lw.writeLine("RRULE", occurrenceRRule(task, loc))
+	}
+
+	e.lw.writeLine("END", "VTODO")
+}
+
+// occurrenceRRule returns the rule expanded from the current occurrence, DTSTART. The COUNT
+// of task counts from the series start, so it is reduced by the past occurrences, e.g.
+// COUNT=5 is written as COUNT=3 on the 3rd occurrence. The rule is kept if it can not be parsed.
+func occurrenceRRule(task domain.Task, loc *time.Location) string {
+
+	if !task.SeriesStart.Valid || !task.SeriesStart.Time.Before(task.DueAt.Time) {
+		return task.RRule
+	}
+
+	rule := strings.TrimPrefix(strings.TrimSpace(task.RRule), "RRULE:")
+
+	option, err := rrule.StrToROptionInLocation(rule, loc)
+	if err != nil || option.Count <= 0 {
+		return task.RRule
+	}
+	option.Dtstart = task.SeriesStart.Time.In(loc)
+
+	series, err := rrule.NewRRule(*option)
+	if err != nil {
+		return task.RRule
+	}
+
+	past := 0
+	next := series.Iterator()
+	for occurrence, ok := next(); ok && occurrence.Before(task.DueAt.Time); occurrence, ok = next() {
+		past++
+	}
+
+	// the last occurrence is kept even if the series has been exhausted
+	remaining := max(option.Count-past, 1)
+
+	parts := strings.Split(rule, ";")
+	for i := range parts {
+		if key, _, ok := strings.Cut(parts[i], "="); ok && strings.EqualFold(key, "COUNT") {
+			parts[i] = "COUNT=" + strconv.Itoa(remaining)
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// writeDateTime writes in UTC, or the local time with TZID for the tasks with timezone,
+// so that the recurrence follows the daylight saving time.
+func (e *Encoder) writeDateTime(name string, t time.Time, loc *time.Location) {
+
+	if loc == time.UTC {
+		e.lw.writeLine(name, formatUTC(t))
+		return
+	}
+
+	e.lw.writeLine(fmt.Sprintf("%s;TZID=%s", name, loc.String()), formatLocal(t, loc))
+}
@@ -0,0 +1,159 @@
+// Package ical provides the iCalendar (RFC 5545) encoding of tasks.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestEncoder .
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf, EncoderParam{Name: "任務", Now: now})
+
+	err := enc.Encode(domain.Task{
+		ID:        1,
+		Name:      "pay rent; water, power\nand gas",
+		Priority:  1,
+		DueAt:     null.TimeFrom(time.Date(2024, 2, 1, 1, 0, 0, 0, time.UTC)),
+		RRule:     "FREQ=MONTHLY;BYMONTHDAY=1",
+		Timezone:  "America/New_York",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	err = enc.Encode(domain.Task{
+		ID:        2,
+		Name:      "done",
+		Status:    true,
+		DueAt:     null.TimeFrom(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, enc.Close())
+
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+
+	expected := []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:" + ProdID + "\r\n",
+		"X-WR-CALNAME:任務\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:America/New_York\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20230312T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20231105T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU\r\n",
+		"UID:task-1@gogolook\r\n",
+		`SUMMARY:pay rent\; water\, power\nand gas` + "\r\n",
+		"STATUS:NEEDS-ACTION\r\nPRIORITY:1\r\n",
+		"DTSTART;TZID=America/New_York:20240131T200000\r\nDUE;TZID=America/New_York:20240131T200000\r\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1\r\n",
+		"UID:task-2@gogolook\r\nDTSTAMP:20240103T000000Z\r\n",
+		"STATUS:COMPLETED\r\nPERCENT-COMPLETE:100\r\nCOMPLETED:20240103T000000Z\r\nDUE:20240102T030405Z\r\n",
+		"END:VCALENDAR\r\n",
+	}
+
+	for _, s := range expected {
+		assert.Contains(t, out, s)
+	}
+
+	assert.Equal(t, 1, strings.Count(out, "BEGIN:VTIMEZONE"))
+}
+
+// TestEncoder_RRuleCount .
+func TestEncoder_RRuleCount(t *testing.T) {
+	t.Parallel()
+
+	seriesStart := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		dueAt    time.Time
+		rrule    string
+		expected string
+	}{
+		{
+			name:     "first occurrence",
+			dueAt:    seriesStart,
+			rrule:    "FREQ=DAILY;COUNT=5",
+			expected: "RRULE:FREQ=DAILY;COUNT=5\r\n",
+		},
+		{
+			name:     "third occurrence",
+			dueAt:    seriesStart.AddDate(0, 0, 2),
+			rrule:    "FREQ=DAILY;COUNT=5",
+			expected: "RRULE:FREQ=DAILY;COUNT=3\r\n",
+		},
+		{
+			name:     "third occurrence with interval",
+			dueAt:    seriesStart.AddDate(0, 0, 4),
+			rrule:    "FREQ=DAILY;INTERVAL=2;COUNT=5",
+			expected: "RRULE:FREQ=DAILY;INTERVAL=2;COUNT=3\r\n",
+		},
+		{
+			name:     "until",
+			dueAt:    seriesStart.AddDate(0, 0, 2),
+			rrule:    "FREQ=DAILY;UNTIL=20240105T090000Z",
+			expected: "RRULE:FREQ=DAILY;UNTIL=20240105T090000Z\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		enc := NewEncoder(&buf, EncoderParam{Now: seriesStart})
+
+		err := enc.Encode(domain.Task{
+			ID:          1,
+			Name:        "water plants",
+			DueAt:       null.TimeFrom(tt.dueAt),
+			SeriesStart: null.TimeFrom(seriesStart),
+			RRule:       tt.rrule,
+		})
+		require.NoError(t, err)
+		require.NoError(t, enc.Close())
+
+		assert.Contains(t, buf.String(), "DTSTART:"+tt.dueAt.Format("20060102T150405Z")+"\r\n", tt.name)
+		assert.Contains(t, buf.String(), tt.expected, tt.name)
+	}
+}
+
+// TestLineWriter_Fold .
+func TestLineWriter_Fold(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	lw := newLineWriter(&buf)
+	lw.writeLine("SUMMARY", strings.Repeat("任務", 40))
+	require.NoError(t, lw.flush())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	require.Greater(t, len(lines), 1)
+
+	var unfolded strings.Builder
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		if i > 0 {
+			require.True(t, strings.HasPrefix(line, " "))
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("任務", 40), unfolded.String())
+}
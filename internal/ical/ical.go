@@ -0,0 +1,117 @@
+// Package ical provides the iCalendar (RFC 5545) encoding of tasks.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType .
+const ContentType = "text/calendar; charset=utf-8"
+
+// ProdID is the product identifier of the generated calendars.
+const ProdID = "-//gogolook//task//EN"
+
+// maxLineOctets is the maximum length of a content line, excluding CRLF.
+const maxLineOctets = 75
+
+// date-time formats
+const (
+	formatDateTimeUTC   = "20060102T150405Z"
+	formatDateTimeLocal = "20060102T150405"
+)
+
+// lineWriter writes the content lines, the long lines are folded.
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// newLineWriter .
+func newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{w: bufio.NewWriter(w)}
+}
+
+// writeLine writes "name:value" or "name;params:value", the value should have been escaped.
+func (lw *lineWriter) writeLine(name string, value string) {
+	if lw.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+
+	// fold at octet boundaries without splitting a UTF-8 sequence,
+	// the continuation lines start with a space which counts as an octet
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		lw.write(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+
+	lw.write(line + "\r\n")
+}
+
+// write .
+func (lw *lineWriter) write(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = lw.w.WriteString(s)
+}
+
+// flush .
+func (lw *lineWriter) flush() error {
+	if lw.err != nil {
+		return lw.err
+	}
+	return lw.w.Flush()
+}
+
+// escapeText escapes the TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// formatUTC formats the date-time in UTC, e.g. 20240101T090000Z.
+func formatUTC(t time.Time) string {
+	return t.UTC().Format(formatDateTimeUTC)
+}
+
+// formatLocal formats the date-time in loc without the UTC designator, used with TZID.
+func formatLocal(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(formatDateTimeLocal)
+}
+
+// formatOffset formats the UTC offset in seconds, e.g. +0800.
+func formatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+
+	hours, minutes, seconds := offset/3600, offset%3600/60, offset%60
+	if seconds != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, hours, minutes)
+}
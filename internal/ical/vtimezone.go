@@ -0,0 +1,127 @@
+// Package ical provides the iCalendar (RFC 5545) encoding of tasks.
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// observance is the STANDARD or DAYLIGHT sub-component of VTIMEZONE.
+type observance struct {
+	daylight   bool
+	name       string
+	offsetFrom int
+	offsetTo   int
+	// the onset in the local time before the transition
+	start time.Time
+	// the yearly rule of the onset, empty for a fixed offset
+	rrule string
+}
+
+// buildObservances derives the observances from the transitions of loc in year,
+// the transitions are assumed to repeat yearly on the same weekday of month.
+func buildObservances(loc *time.Location, year int) []observance {
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(1, 0, 0)
+
+	var observances []observance
+
+	for t := from; t.Before(to); {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || !end.Before(to) {
+			break
+		}
+
+		name, offsetTo := end.Zone()
+		_, offsetFrom := end.Add(-time.Second).Zone()
+
+		local := end.In(time.FixedZone("", offsetFrom))
+
+		weekdayRule, n := nthWeekday(local)
+		start := weekdayDate(year-1, local.Month(), local.Weekday(), n,
+			local.Hour(), local.Minute(), local.Second())
+
+		observances = append(observances, observance{
+			daylight:   end.IsDST(),
+			name:       name,
+			offsetFrom: offsetFrom,
+			offsetTo:   offsetTo,
+			start:      start,
+			rrule:      fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%s", local.Month(), weekdayRule),
+		})
+
+		t = end
+	}
+
+	if len(observances) == 0 {
+		name, offset := from.Zone()
+
+		observances = append(observances, observance{
+			name:       name,
+			offsetFrom: offset,
+			offsetTo:   offset,
+			start:      time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	return observances
+}
+
+// weekdays of BYDAY
+var weekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// nthWeekday returns the BYDAY of t, e.g. 2SU, or -1SU for the last weekday of month.
+func nthWeekday(t time.Time) (string, int) {
+
+	n := (t.Day()-1)/7 + 1
+
+	lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if t.Day()+7 > lastDay {
+		n = -1
+	}
+
+	return fmt.Sprintf("%d%s", n, weekdays[t.Weekday()]), n
+}
+
+// weekdayDate returns the date of the nth weekday of month, n is -1 for the last one.
+func weekdayDate(year int, month time.Month, weekday time.Weekday, n int, hour, min, sec int) time.Time {
+
+	if n < 0 {
+		last := time.Date(year, month+1, 0, hour, min, sec, 0, time.UTC)
+		diff := (int(last.Weekday()) - int(weekday) + 7) % 7
+		return last.AddDate(0, 0, -diff)
+	}
+
+	first := time.Date(year, month, 1, hour, min, sec, 0, time.UTC)
+	diff := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, diff+(n-1)*7)
+}
+
+// writeVTimezone .
+func (lw *lineWriter) writeVTimezone(loc *time.Location, year int) {
+
+	lw.writeLine("BEGIN", "VTIMEZONE")
+	lw.writeLine("TZID", escapeText(loc.String()))
+
+	for _, o := range buildObservances(loc, year) {
+		component := "STANDARD"
+		if o.daylight {
+			component = "DAYLIGHT"
+		}
+
+		lw.writeLine("BEGIN", component)
+		lw.writeLine("DTSTART", o.start.Format(formatDateTimeLocal))
+		lw.writeLine("TZOFFSETFROM", formatOffset(o.offsetFrom))
+		lw.writeLine("TZOFFSETTO", formatOffset(o.offsetTo))
+		if o.rrule != "" {
+			lw.writeLine("RRULE", o.rrule)
+		}
+		if o.name != "" {
+			lw.writeLine("TZNAME", escapeText(o.name))
+		}
+		lw.writeLine("END", component)
+	}
+
+	lw.writeLine("END", "VTIMEZONE")
+}
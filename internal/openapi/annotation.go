@@ -0,0 +1,168 @@
+// Package openapi provides
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// annotation is a line of comment starting with @.
+type annotation struct {
+	name  string
+	value string
+}
+
+// paramAnnotation is @Param name in type required "description".
+type paramAnnotation struct {
+	name        string
+	in          string
+	typ         string
+	required    bool
+	description string
+}
+
+// responseAnnotation is @Success or @Failure code {kind} type "description".
+type responseAnnotation struct {
+	code        int
+	kind        string
+	typ         string
+	description string
+	// 描述為空字串時沒有回應內容
+	content bool
+}
+
+// annotations returns the annotation lines of comment.
+func annotations(group *ast.CommentGroup) []annotation {
+
+	var lines []annotation
+
+	for _, comment := range group.List {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if !strings.HasPrefix(text, "@") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(text, " ")
+		lines = append(lines, annotation{name: name, value: strings.TrimSpace(value)})
+	}
+
+	return lines
+}
+
+// hasAnnotation .
+func hasAnnotation(lines []annotation, name string) bool {
+	for _, line := range lines {
+		if line.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseParam .
+func parseParam(value string) (paramAnnotation, error) {
+
+	fields := strings.Fields(value)
+	if len(fields) < 4 {
+		return paramAnnotation{}, fmt.Errorf("invalid @Param %q", value)
+	}
+
+	required, err := strconv.ParseBool(fields[3])
+	if err != nil {
+		return paramAnnotation{}, fmt.Errorf("invalid required of @Param %q", value)
+	}
+
+	return paramAnnotation{
+		name:        fields[0],
+		in:          fields[1],
+		typ:         fields[2],
+		required:    required,
+		description: quotedTail(value, 4),
+	}, nil
+}
+
+// parseResponse parses the response, the description is the last quoted string since the swag examples
+// before it contain quotes, e.g. {object} ErrResponse "{"code":"400400"}" "參數錯誤".
+// The response has no content if the description is empty, the text after it is used instead,
+// e.g. {string} string "" No Content.
+func parseResponse(value string) (responseAnnotation, error) {
+
+	fields := strings.Fields(value)
+	if len(fields) < 3 {
+		return responseAnnotation{}, fmt.Errorf("invalid response %q", value)
+	}
+
+	code, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return responseAnnotation{}, fmt.Errorf("invalid code of response %q", value)
+	}
+
+	if !strings.HasPrefix(fields[1], "{") || !strings.HasSuffix(fields[1], "}") {
+		return responseAnnotation{}, fmt.Errorf("invalid kind of response %q", value)
+	}
+
+	resp := responseAnnotation{
+		code: code,
+		kind: strings.Trim(fields[1], "{}"),
+		typ:  fields[2],
+	}
+
+	rest := tail(value, 3)
+
+	if strings.HasSuffix(rest, `"`) && len(rest) > 1 {
+		start := strings.LastIndex(rest[:len(rest)-1], `"`)
+		if start < 0 {
+			return responseAnnotation{}, fmt.Errorf("invalid description of response %q", value)
+		}
+		resp.description = rest[start+1 : len(rest)-1]
+	}
+
+	if resp.description == "" {
+		resp.description = strings.TrimSpace(strings.TrimPrefix(rest, `""`))
+		return resp, nil
+	}
+
+	resp.content = true
+
+	return resp, nil
+}
+
+// tail returns the value after n fields.
+func tail(value string, n int) string {
+
+	for i := 0; i < n; i++ {
+		value = strings.TrimSpace(value)
+		end := strings.IndexAny(value, " \t")
+		if end < 0 {
+			return ""
+		}
+		value = value[end:]
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// quotedTail returns the unquoted value after n fields.
+func quotedTail(value string, n int) string {
+	return unquote(tail(value, n))
+}
+
+// unquote .
+func unquote(value string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`)
+}
+
+// splitList .
+func splitList(value string) []string {
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,78 @@
+// Package openapi provides
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseResponse .
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value string
+		want  responseAnnotation
+	}{
+		{
+			name:  "object",
+			value: `200 {object} http.TaskResponse "任務內容"`,
+			want:  responseAnnotation{code: 200, kind: "object", typ: "http.TaskResponse", description: "任務內容", content: true},
+		},
+		{
+			name:  "example before description",
+			value: `400 {object} ErrResponse "{"code":"400400","message":"Wrong parameter format or invalid"}" "參數錯誤"`,
+			want:  responseAnnotation{code: 400, kind: "object", typ: "ErrResponse", description: "參數錯誤", content: true},
+		},
+		{
+			name:  "no content",
+			value: `200 {string} string "" No Content`,
+			want:  responseAnnotation{code: 200, kind: "string", typ: "string", description: "No Content"},
+		},
+		{
+			name:  "empty description",
+			value: `301 {string} string ""`,
+			want:  responseAnnotation{code: 301, kind: "string", typ: "string"},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := parseResponse(tt.value)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+
+	for _, value := range []string{`200 {object}`, `ok {object} X "a"`, `200 object X "a"`} {
+		_, err := parseResponse(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// TestParseParam .
+func TestParseParam(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseParam(`Idempotency-Key header string false "重試時帶相同的 key, 回傳第一次請求的回應"`)
+	require.NoError(t, err)
+	assert.Equal(t, paramAnnotation{
+		name:        "Idempotency-Key",
+		in:          "header",
+		typ:         "string",
+		description: "重試時帶相同的 key, 回傳第一次請求的回應",
+	}, got)
+
+	_, err = parseParam(`id path int yes "任務ID"`)
+	assert.Error(t, err)
+}
+
+// TestOpenAPIPath .
+func TestOpenAPIPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/task/{id}/reminders/{reminder_id}", openAPIPath("/task/:id/reminders/:reminder_id"))
+	assert.Equal(t, "/calendar/feeds/{token}/tasks.ics", openAPIPath("/calendar/feeds/:token/tasks.ics"))
+	assert.Equal(t, "/tasks", openAPIPath("/tasks"))
+}
@@ -0,0 +1,205 @@
+// Package openapi provides
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Document is the part of OpenAPI 3 document which is generated.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Paths      *orderedMap           `json:"paths"`
+	Components Components            `json:"components"`
+}
+
+// Info .
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag .
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement .
+type SecurityRequirement map[string][]string
+
+// SecurityScheme .
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components .
+type Components struct {
+	SecuritySchemes *orderedMap `json:"securitySchemes,omitempty"`
+	Schemas         *orderedMap `json:"schemas,omitempty"`
+}
+
+// Operation .
+type Operation struct {
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Parameters  []Parameter  `json:"parameters,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+	Responses   *orderedMap  `json:"responses"`
+	// nil 為使用全域的驗證方式, 空陣列為不需要驗證
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter .
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody .
+type RequestBody struct {
+	Required bool        `json:"required"`
+	Content  *orderedMap `json:"content"`
+}
+
+// Response .
+type Response struct {
+	Description string      `json:"description"`
+	Content     *orderedMap `json:"content,omitempty"`
+}
+
+// MediaType .
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema .
+type Schema struct {
+	Ref                  string      `json:"$ref,omitempty"`
+	AllOf                []*Schema   `json:"allOf,omitempty"`
+	Type                 string      `json:"type,omitempty"`
+	Format               string      `json:"format,omitempty"`
+	Nullable             bool        `json:"nullable,omitempty"`
+	Description          string      `json:"description,omitempty"`
+	Enum                 []string    `json:"enum,omitempty"`
+	Minimum              *float64    `json:"minimum,omitempty"`
+	Maximum              *float64    `json:"maximum,omitempty"`
+	Required             []string    `json:"required,omitempty"`
+	Properties           *orderedMap `json:"properties,omitempty"`
+	AdditionalProperties *Schema     `json:"additionalProperties,omitempty"`
+	Items                *Schema     `json:"items,omitempty"`
+}
+
+// orderedMap is a JSON object which keeps the order of keys, so that the document is stable and
+// the properties are in the order of struct fields.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+// newOrderedMap .
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: map[string]any{}}
+}
+
+// Set .
+func (m *orderedMap) Set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get .
+func (m *orderedMap) Get(key string) (any, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Len .
+func (m *orderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Sort sorts the keys by less.
+func (m *orderedMap) Sort(less func(a, b string) bool) {
+	sort.SliceStable(m.keys, func(i, j int) bool {
+		return less(m.keys[i], m.keys[j])
+	})
+}
+
+// MarshalJSON .
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		b, err := marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+
+		b, err = marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// marshal encodes v without escaping HTML, the document is not embedded in HTML.
+func marshal(v any) ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Marshal encodes the document with indentation.
+func (d *Document) Marshal() ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,566 @@
+// Package openapi provides the generator of the OpenAPI 3 document from the swag style annotations of handlers,
+// the schemas are generated from the Go types so that the document can not drift from the code.
+//
+// The general annotations are in the comment containing @Title:
+//
+//	@Title gogolook
+//	@Version 1.0.0
+//	@Description ...
+//	@Tag Task                                          only the operations of the listed tags are documented
+//	@SecurityScheme ApiKeyHeader apiKey header X-API-Key
+//	@SecurityScheme BasicAuth http basic "description"  the schemes are the alternatives of global security
+//	@Failure 401 {object} ErrResponse "description"     added to the operations requiring security
+//
+// The operation annotations are in the doc comment of handler:
+//
+//	@Summary, @Description, @Tags Task, @Accept json,x-www-form-urlencoded, @Produce json
+//	@Router /task/:id [PUT]
+//	@Param id path int true "description"              in: path, query, header, body, formData
+//	@Param request body Request true "description"     the types declared in the handler are named as <handler><type>
+//	@Success 200 {object} http.TaskResponse "description"
+//	@Success 200 {string} string "" No Content         an empty description means no content
+//	@Failure 400 {object} ErrResponse "{example}" "description"
+//	@Security none                                     no security
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the JSON responses are wrapped in the result field by the handlers, except the error responses
+const (
+	resultField   = "result"
+	errResponse   = "ErrResponse"
+	mimeJSON      = "application/json"
+	mimeMultipart = "multipart/form-data"
+)
+
+// mimeAliases are the short names of swag.
+var mimeAliases = map[string]string{
+	"json":                  mimeJSON,
+	"xml":                   "application/xml",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"mpfd":                  mimeMultipart,
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"octet-stream":          "application/octet-stream",
+}
+
+// methodOrder is the order of operations in a path.
+var methodOrder = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// generator .
+type generator struct {
+	module  *module
+	schemas *orderedMap
+}
+
+// Generate generates the document of the handlers in dir.
+func Generate(dir string) ([]byte, error) {
+
+	m, err := newModule(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := m.load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{module: m, schemas: newOrderedMap()}
+
+	doc, err := g.document(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Marshal()
+}
+
+// general is the general annotations.
+type general struct {
+	doc      Document
+	tags     map[string]bool
+	failures []responseAnnotation
+}
+
+// document .
+func (g *generator) document(pkg *goPackage) (*Document, error) {
+
+	info, err := g.general(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := info.doc
+	doc.Paths = newOrderedMap()
+
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Doc == nil {
+				continue
+			}
+
+			lines := annotations(funcDecl.Doc)
+			if !hasAnnotation(lines, "@Router") {
+				continue
+			}
+
+			sc := scope{pkg: pkg, file: file, local: localTypes(funcDecl), localPrefix: funcDecl.Name.Name}
+
+			path, method, op, err := g.operation(lines, info, sc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", funcDecl.Name.Name, err)
+			}
+			if op == nil {
+				continue
+			}
+
+			item, ok := doc.Paths.Get(path)
+			if !ok {
+				item = newOrderedMap()
+				doc.Paths.Set(path, item)
+			}
+
+			if _, ok := item.(*orderedMap).Get(method); ok {
+				return nil, fmt.Errorf("%s: duplicate operation %s %s", funcDecl.Name.Name, method, path)
+			}
+			item.(*orderedMap).Set(method, op)
+		}
+	}
+
+	doc.Paths.Sort(func(a, b string) bool { return a < b })
+	for _, path := range doc.Paths.keys {
+		item, _ := doc.Paths.Get(path)
+		item.(*orderedMap).Sort(func(a, b string) bool {
+			return methodIndex(a) < methodIndex(b)
+		})
+	}
+
+	g.schemas.Sort(func(a, b string) bool { return a < b })
+	doc.Components.Schemas = g.schemas
+
+	return &doc, nil
+}
+
+// general parses the comment containing @Title.
+func (g *generator) general(pkg *goPackage) (*general, error) {
+
+	for _, file := range pkg.files {
+		for _, group := range file.Comments {
+			lines := annotations(group)
+			if !hasAnnotation(lines, "@Title") {
+				continue
+			}
+
+			info := &general{
+				doc:  Document{OpenAPI: "3.0.3"},
+				tags: map[string]bool{},
+			}
+
+			for _, line := range lines {
+				err := info.parse(line)
+				if err != nil {
+					return nil, fmt.Errorf("general annotations: %w", err)
+				}
+			}
+
+			return info, nil
+		}
+	}
+
+	return nil, fmt.Errorf("general annotations (@Title) not found in package %s", pkg.name)
+}
+
+// parse .
+func (info *general) parse(line annotation) error {
+
+	switch line.name {
+	case "@Title":
+		info.doc.Info.Title = line.value
+
+	case "@Version":
+		info.doc.Info.Version = line.value
+
+	case "@Description":
+		info.doc.Info.Description = joinDescription(info.doc.Info.Description, line.value)
+
+	case "@Tag":
+		name, description, _ := strings.Cut(line.value, " ")
+		info.doc.Tags = append(info.doc.Tags, Tag{Name: name, Description: unquote(description)})
+		info.tags[name] = true
+
+	case "@SecurityScheme":
+		fields := strings.Fields(line.value)
+		if len(fields) < 3 {
+			return fmt.Errorf("invalid @SecurityScheme %q", line.value)
+		}
+
+		scheme := SecurityScheme{Type: fields[1]}
+		description := ""
+		switch scheme.Type {
+		case "apiKey":
+			if len(fields) < 4 {
+				return fmt.Errorf("invalid @SecurityScheme %q", line.value)
+			}
+			scheme.In, scheme.Name = fields[2], fields[3]
+			description = quotedTail(line.value, 4)
+		case "http":
+			scheme.Scheme = fields[2]
+			description = quotedTail(line.value, 3)
+		default:
+			return fmt.Errorf("unsupported security scheme type %q", scheme.Type)
+		}
+		scheme.Description = description
+
+		if info.doc.Components.SecuritySchemes == nil {
+			info.doc.Components.SecuritySchemes = newOrderedMap()
+		}
+		info.doc.Components.SecuritySchemes.Set(fields[0], scheme)
+		info.doc.Security = append(info.doc.Security, SecurityRequirement{fields[0]: {}})
+
+	case "@Failure":
+		resp, err := parseResponse(line.value)
+		if err != nil {
+			return err
+		}
+		info.failures = append(info.failures, resp)
+
+	default:
+		return fmt.Errorf("unknown annotation %s", line.name)
+	}
+
+	return nil
+}
+
+// operation returns nil if the operation is not in the tags of document.
+func (g *generator) operation(lines []annotation, info *general, sc scope) (string, string, *Operation, error) {
+
+	op := &Operation{Responses: newOrderedMap()}
+
+	var (
+		path, method string
+		accepts      []string
+		produces     []string
+		params       []paramAnnotation
+		responses    []responseAnnotation
+		noSecurity   bool
+	)
+
+	for _, line := range lines {
+		switch line.name {
+		case "@Summary":
+			op.Summary = line.value
+
+		case "@Description":
+			op.Description = joinDescription(op.Description, line.value)
+
+		case "@Tags":
+			op.Tags = splitList(line.value)
+
+		case "@Accept":
+			accepts = mimeTypes(line.value)
+
+		case "@Produce":
+			produces = mimeTypes(line.value)
+
+		case "@Router":
+			fields := strings.Fields(line.value)
+			if len(fields) != 2 {
+				return "", "", nil, fmt.Errorf("invalid @Router %q", line.value)
+			}
+			path = openAPIPath(fields[0])
+			method = strings.ToLower(strings.Trim(fields[1], "[]"))
+
+		case "@Param":
+			param, err := parseParam(line.value)
+			if err != nil {
+				return "", "", nil, err
+			}
+			params = append(params, param)
+
+		case "@Success", "@Failure":
+			resp, err := parseResponse(line.value)
+			if err != nil {
+				return "", "", nil, err
+			}
+			responses = append(responses, resp)
+
+		case "@Security":
+			if line.value != "none" {
+				return "", "", nil, fmt.Errorf("unsupported @Security %q, the schemes are global", line.value)
+			}
+			noSecurity = true
+
+		default:
+			return "", "", nil, fmt.Errorf("unknown annotation %s", line.name)
+		}
+	}
+
+	documented := false
+	for _, tag := range op.Tags {
+		documented = documented || info.tags[tag]
+	}
+	if !documented {
+		return "", "", nil, nil
+	}
+
+	if methodIndex(method) == len(methodOrder) {
+		return "", "", nil, fmt.Errorf("unsupported method %q", method)
+	}
+
+	if noSecurity {
+		op.Security = &[]SecurityRequirement{}
+	} else {
+		for _, failure := range info.failures {
+			if !hasResponse(responses, failure.code) {
+				responses = append(responses, failure)
+			}
+		}
+	}
+
+	err := g.parameters(op, params, accepts, sc)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if len(produces) == 0 {
+		produces = []string{mimeJSON}
+	}
+
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].code < responses[j].code
+	})
+
+	for _, resp := range responses {
+		response, err := g.response(resp, produces, sc)
+		if err != nil {
+			return "", "", nil, err
+		}
+		op.Responses.Set(strconv.Itoa(resp.code), response)
+	}
+
+	return path, method, op, nil
+}
+
+// parameters adds the parameters and the request body.
+func (g *generator) parameters(op *Operation, params []paramAnnotation, accepts []string, sc scope) error {
+
+	var (
+		body     *paramAnnotation
+		formData []paramAnnotation
+	)
+
+	for i, param := range params {
+		switch param.in {
+		case "body":
+			body = &params[i]
+			continue
+		case "formData":
+			formData = append(formData, param)
+			continue
+		case "path", "query", "header":
+		default:
+			return fmt.Errorf("unsupported parameter in %q", param.in)
+		}
+
+		schema, err := g.schemaOfString(param.typ, sc)
+		if err != nil {
+			return err
+		}
+
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        param.name,
+			In:          param.in,
+			Description: param.description,
+			Required:    param.required || param.in == "path",
+			Schema:      schema,
+		})
+	}
+
+	if len(accepts) == 0 {
+		if body == nil && len(formData) == 0 {
+			return nil
+		}
+		accepts = []string{mimeJSON}
+	}
+
+	op.RequestBody = &RequestBody{Required: true, Content: newOrderedMap()}
+	if body != nil {
+		op.RequestBody.Required = body.required
+	}
+
+	for _, accept := range accepts {
+		var (
+			schema *Schema
+			err    error
+		)
+
+		switch {
+		case accept == mimeMultipart:
+			schema = &Schema{Type: "object", Properties: newOrderedMap()}
+			for _, param := range formData {
+				property, err := g.schemaOfString(param.typ, sc)
+				if err != nil {
+					return err
+				}
+				property.Description = param.description
+				schema.Properties.Set(param.name, property)
+				if param.required {
+					schema.Required = append(schema.Required, param.name)
+				}
+			}
+
+		case body != nil:
+			schema, err = g.schemaOfString(body.typ, sc)
+			if err != nil {
+				return err
+			}
+
+		default:
+			// the raw body, e.g. the uploaded file
+			schema = &Schema{Type: "string", Format: "binary"}
+		}
+
+		op.RequestBody.Content.Set(accept, MediaType{Schema: schema})
+	}
+
+	return nil
+}
+
+// response .
+func (g *generator) response(resp responseAnnotation, produces []string, sc scope) (*Response, error) {
+
+	response := &Response{Description: resp.description}
+	if response.Description == "" {
+		response.Description = http.StatusText(resp.code)
+	}
+
+	if !resp.content {
+		return response, nil
+	}
+
+	var (
+		schema *Schema
+		err    error
+	)
+
+	switch resp.kind {
+	case "object", "string":
+		schema, err = g.schemaOfString(resp.typ, sc)
+	case "array":
+		schema, err = g.schemaOfString("[]"+resp.typ, sc)
+	case "file":
+		schema = &Schema{Type: "string", Format: "binary"}
+	default:
+		err = fmt.Errorf("unsupported response kind {%s}", resp.kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Content = newOrderedMap()
+
+	if typeName(resp.typ) == errResponse {
+		response.Content.Set(mimeJSON, MediaType{Schema: schema})
+		return response, nil
+	}
+
+	for _, produce := range produces {
+		if produce == mimeJSON && resp.kind != "file" {
+			wrapped := &Schema{Type: "object", Properties: newOrderedMap()}
+			wrapped.Properties.Set(resultField, schema)
+			response.Content.Set(produce, MediaType{Schema: wrapped})
+			continue
+		}
+		response.Content.Set(produce, MediaType{Schema: schema})
+	}
+
+	return response, nil
+}
+
+// localTypes returns the types declared in the function.
+func localTypes(funcDecl *ast.FuncDecl) map[string]*ast.TypeSpec {
+
+	types := map[string]*ast.TypeSpec{}
+
+	if funcDecl.Body == nil {
+		return types
+	}
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		if spec, ok := node.(*ast.TypeSpec); ok {
+			types[spec.Name.Name] = spec
+		}
+		return true
+	})
+
+	return types
+}
+
+// openAPIPath converts the path parameters of gin to OpenAPI, e.g. /task/:id to /task/{id}.
+func openAPIPath(path string) string {
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// mimeTypes .
+func mimeTypes(value string) []string {
+
+	var mimes []string
+	for _, mime := range splitList(value) {
+		if alias, ok := mimeAliases[mime]; ok {
+			mime = alias
+		}
+		mimes = append(mimes, mime)
+	}
+
+	return mimes
+}
+
+// methodIndex returns len(methodOrder) for the methods which are not supported by OpenAPI.
+func methodIndex(method string) int {
+	for i, m := range methodOrder {
+		if m == method {
+			return i
+		}
+	}
+	return len(methodOrder)
+}
+
+// hasResponse .
+func hasResponse(responses []responseAnnotation, code int) bool {
+	for _, resp := range responses {
+		if resp.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the type name without the package, e.g. http.ErrResponse to ErrResponse.
+func typeName(typ string) string {
+	return typ[strings.LastIndex(typ, ".")+1:]
+}
+
+// joinDescription joins the lines of multiple @Description.
+func joinDescription(description, line string) string {
+	if description == "" {
+		return line
+	}
+	return description + "\n" + line
+}
@@ -0,0 +1,29 @@
+// Package main provides the command generating the OpenAPI 3 document, it is run by go generate in the handler package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tingchima/gogolook/internal/openapi"
+)
+
+func main() {
+
+	dir := flag.String("dir", ".", "the directory of handler package")
+	out := flag.String("out", "openapi.json", "the output file")
+	flag.Parse()
+
+	doc, err := openapi.Generate(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "generate openapi:", err)
+		os.Exit(1)
+	}
+
+	err = os.WriteFile(*out, doc, 0o644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "write openapi:", err)
+		os.Exit(1)
+	}
+}
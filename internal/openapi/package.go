@@ -0,0 +1,180 @@
+// Package openapi provides
+package openapi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goPackage is a parsed package of the module.
+type goPackage struct {
+	name  string
+	files []*ast.File
+	// 套件層級的型別
+	types map[string]typeDecl
+}
+
+// typeDecl is a type declaration and the file declaring it, the imports of file are needed to resolve its fields.
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *ast.File
+}
+
+// module resolves the import paths of the module to directories.
+type module struct {
+	path     string
+	dir      string
+	fset     *token.FileSet
+	packages map[string]*goPackage
+}
+
+// newModule finds the go.mod in dir or its parents.
+func newModule(dir string) (*module, error) {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+		if err == nil {
+			return &module{
+				path:     modulePath,
+				dir:      root,
+				fset:     token.NewFileSet(),
+				packages: map[string]*goPackage{},
+			}, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if filepath.Dir(root) == root {
+			return nil, fmt.Errorf("go.mod not found in %s or its parents", dir)
+		}
+	}
+}
+
+// readModulePath .
+func readModulePath(name string) (string, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if modulePath, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(modulePath), `"`), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("module directive not found in %s", name)
+}
+
+// load parses the non-test go files of dir.
+func (m *module) load(dir string) (*goPackage, error) {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if pkg, ok := m.packages[dir]; ok {
+		return pkg, nil
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	pkg := &goPackage{types: map[string]typeDecl{}}
+
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(m.fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		pkg.name = file.Name.Name
+		pkg.files = append(pkg.files, file)
+
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				pkg.types[typeSpec.Name.Name] = typeDecl{spec: typeSpec, file: file}
+			}
+		}
+	}
+
+	if len(pkg.files) == 0 {
+		return nil, fmt.Errorf("no go files in %s", dir)
+	}
+
+	m.packages[dir] = pkg
+
+	return pkg, nil
+}
+
+// importPath returns the import path of the package named name in file.
+func importPath(file *ast.File, name string) (string, bool) {
+
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return importPath, true
+			}
+			continue
+		}
+
+		if path.Base(importPath) == name {
+			return importPath, true
+		}
+	}
+
+	return "", false
+}
+
+// loadImport loads the package of import path, only the packages of module can be loaded.
+func (m *module) loadImport(importPath string) (*goPackage, error) {
+
+	rel, ok := strings.CutPrefix(importPath, m.path+"/")
+	if !ok {
+		return nil, fmt.Errorf("package %s is not in module %s", importPath, m.path)
+	}
+
+	return m.load(filepath.Join(m.dir, filepath.FromSlash(rel)))
+}
@@ -0,0 +1,427 @@
+// Package openapi provides
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// scope resolves the type names of a file, the types declared in the handler function are resolved first.
+type scope struct {
+	pkg  *goPackage
+	file *ast.File
+	// schema 名稱的前綴, 其他套件的型別加上套件名稱, 例如 health.Report 為 HealthReport
+	prefix string
+	// 函式內宣告的型別, schema 名稱加上函式名稱作為前綴
+	local       map[string]*ast.TypeSpec
+	localPrefix string
+}
+
+// basicSchemas are the schemas of the builtin types and the annotation types.
+var basicSchemas = map[string]Schema{
+	"string":  {Type: "string"},
+	"bool":    {Type: "boolean"},
+	"int":     {Type: "integer"},
+	"int8":    {Type: "integer"},
+	"int16":   {Type: "integer"},
+	"int32":   {Type: "integer", Format: "int32"},
+	"int64":   {Type: "integer", Format: "int64"},
+	"uint":    {Type: "integer"},
+	"uint8":   {Type: "integer"},
+	"uint16":  {Type: "integer"},
+	"uint32":  {Type: "integer", Format: "int32"},
+	"uint64":  {Type: "integer", Format: "int64"},
+	"float32": {Type: "number", Format: "float"},
+	"float64": {Type: "number", Format: "double"},
+	"number":  {Type: "number"},
+	"integer": {Type: "integer"},
+	"boolean": {Type: "boolean"},
+	"object":  {Type: "object"},
+	"file":    {Type: "string", Format: "binary"},
+	"any":     {},
+}
+
+// schemaOfString parses the type of annotation, e.g. http.TaskResponse, time.Time.
+func (g *generator) schemaOfString(typ string, sc scope) (*Schema, error) {
+
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, fmt.Errorf("parse type %q: %w", typ, err)
+	}
+
+	return g.schemaOf(expr, sc)
+}
+
+// schemaOf returns the schema of type expression, the struct types are referenced from the components.
+func (g *generator) schemaOf(expr ast.Expr, sc scope) (*Schema, error) {
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return g.schemaOfIdent(expr.Name, sc)
+
+	case *ast.SelectorExpr:
+		return g.schemaOfSelector(expr, sc)
+
+	case *ast.StarExpr:
+		schema, err := g.schemaOf(expr.X, sc)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(schema), nil
+
+	case *ast.ArrayType:
+		items, err := g.schemaOf(expr.Elt, sc)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+
+	case *ast.MapType:
+		values, err := g.schemaOf(expr.Value, sc)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+
+	case *ast.InterfaceType:
+		return &Schema{}, nil
+
+	case *ast.StructType:
+		return g.structSchema(expr, sc)
+	}
+
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// schemaOfIdent .
+func (g *generator) schemaOfIdent(name string, sc scope) (*Schema, error) {
+
+	if spec, ok := sc.local[name]; ok {
+		return g.namedSchema(sc.localPrefix+name, spec, sc)
+	}
+
+	if decl, ok := sc.pkg.types[name]; ok {
+		return g.namedSchema(sc.prefix+name, decl.spec, scope{pkg: sc.pkg, file: decl.file, prefix: sc.prefix})
+	}
+
+	if schema, ok := basicSchemas[name]; ok {
+		return &schema, nil
+	}
+
+	return nil, fmt.Errorf("unknown type %s", name)
+}
+
+// schemaOfSelector resolves the types of other packages, the name of current package is allowed as the annotations of swag.
+func (g *generator) schemaOfSelector(expr *ast.SelectorExpr, sc scope) (*Schema, error) {
+
+	pkgIdent, ok := expr.X.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", expr.X)
+	}
+
+	if pkgIdent.Name == sc.pkg.name {
+		return g.schemaOfIdent(expr.Sel.Name, scope{pkg: sc.pkg, file: sc.file, prefix: sc.prefix})
+	}
+
+	if pkgIdent.Name == "time" {
+		switch expr.Sel.Name {
+		case "Time":
+			return &Schema{Type: "string", Format: "date-time"}, nil
+		case "Duration":
+			return &Schema{Type: "integer", Format: "int64"}, nil
+		}
+	}
+
+	importPath, ok := importPath(sc.file, pkgIdent.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown package %s", pkgIdent.Name)
+	}
+
+	pkg, err := g.module.loadImport(importPath)
+	if err != nil {
+		return nil, fmt.Errorf("type %s.%s: %w", pkgIdent.Name, expr.Sel.Name, err)
+	}
+
+	if _, ok := pkg.types[expr.Sel.Name]; !ok {
+		return nil, fmt.Errorf("unknown type %s.%s", pkgIdent.Name, expr.Sel.Name)
+	}
+
+	return g.schemaOfIdent(expr.Sel.Name, scope{pkg: pkg, prefix: exportedName(pkg.name)})
+}
+
+// namedSchema references the struct types from the components, the other named types are inlined.
+func (g *generator) namedSchema(name string, spec *ast.TypeSpec, sc scope) (*Schema, error) {
+
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return g.schemaOf(spec.Type, sc)
+	}
+
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if _, ok := g.schemas.Get(name); ok {
+		return ref, nil
+	}
+
+	// reserve the name for the recursive types
+	g.schemas.Set(name, &Schema{})
+
+	schema, err := g.structSchema(structType, sc)
+	if err != nil {
+		return nil, fmt.Errorf("type %s: %w", name, err)
+	}
+
+	g.schemas.Set(name, schema)
+
+	return ref, nil
+}
+
+// structSchema .
+func (g *generator) structSchema(structType *ast.StructType, sc scope) (*Schema, error) {
+
+	schema := &Schema{Type: "object", Properties: newOrderedMap()}
+
+	err := g.addFields(schema, structType, sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// addFields adds the fields as encoding/json, the fields of embedded struct are promoted.
+func (g *generator) addFields(schema *Schema, structType *ast.StructType, sc scope) error {
+
+	for _, field := range structType.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			value, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(value)
+		}
+
+		jsonName, jsonOpts, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			embedded, embeddedScope, err := g.resolveStruct(field.Type, sc)
+			if err != nil {
+				return err
+			}
+
+			err = g.addFields(schema, embedded, embeddedScope)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+
+			name := jsonName
+			if name == "" {
+				name = ident.Name
+			}
+
+			property, err := g.fieldSchema(field, tag, sc)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", ident.Name, err)
+			}
+
+			schema.Properties.Set(name, property)
+
+			if fieldRequired(tag, jsonOpts) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveStruct returns the struct type of embedded field.
+func (g *generator) resolveStruct(expr ast.Expr, sc scope) (*ast.StructType, scope, error) {
+
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil, sc, fmt.Errorf("unsupported embedded type %T", expr)
+	}
+
+	var spec *ast.TypeSpec
+	if local, ok := sc.local[ident.Name]; ok {
+		spec = local
+	} else if decl, ok := sc.pkg.types[ident.Name]; ok {
+		spec = decl.spec
+		sc = scope{pkg: sc.pkg, file: decl.file, prefix: sc.prefix}
+	}
+
+	if spec == nil {
+		return nil, sc, fmt.Errorf("unknown embedded type %s", ident.Name)
+	}
+
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, sc, fmt.Errorf("embedded type %s is not struct", ident.Name)
+	}
+
+	return structType, sc, nil
+}
+
+// fieldSchema applies the field comment and the tags of swag (swaggertype, format, enums, minimum, maximum)
+// and the validation of gin (binding oneof, min, max) to the schema of field type.
+func (g *generator) fieldSchema(field *ast.Field, tag reflect.StructTag, sc scope) (*Schema, error) {
+
+	var schema *Schema
+
+	if swaggerType := tag.Get("swaggertype"); swaggerType != "" {
+		// the types of gopkg.in/guregu/null are nullable
+		schema = &Schema{Type: swaggerType, Nullable: isNullType(field.Type)}
+	} else {
+		var err error
+		schema, err = g.schemaOf(field.Type, sc)
+		if err != nil {
+			return nil, err
+		}
+		// a copy, the basic schemas are shared
+		copied := *schema
+		schema = &copied
+	}
+
+	if format := tag.Get("format"); format != "" {
+		schema.Format = format
+	}
+
+	bindings := map[string]string{}
+	for _, binding := range strings.Split(tag.Get("binding"), ",") {
+		key, value, _ := strings.Cut(binding, "=")
+		bindings[key] = value
+	}
+
+	if enums := tag.Get("enums"); enums != "" {
+		schema.Enum = strings.Split(enums, ",")
+	} else if oneOf := bindings["oneof"]; oneOf != "" {
+		schema.Enum = strings.Fields(oneOf)
+	}
+
+	// min and max of gin are the length of strings and slices
+	numeric := schema.Type == "integer" || schema.Type == "number"
+
+	for _, bound := range []struct {
+		target **float64
+		tag    string
+		bind   string
+	}{
+		{target: &schema.Minimum, tag: tag.Get("minimum"), bind: bindings["min"]},
+		{target: &schema.Maximum, tag: tag.Get("maximum"), bind: bindings["max"]},
+	} {
+		value := bound.tag
+		if value == "" && numeric {
+			value = bound.bind
+		}
+		if value == "" {
+			continue
+		}
+
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bound %q: %w", value, err)
+		}
+		*bound.target = &f
+	}
+
+	description := commentText(field.Doc)
+	if description == "" {
+		description = commentText(field.Comment)
+	}
+
+	if schema.Ref != "" {
+		if description == "" && !schema.Nullable {
+			return schema, nil
+		}
+		return &Schema{AllOf: []*Schema{{Ref: schema.Ref}}, Nullable: schema.Nullable, Description: description}, nil
+	}
+
+	schema.Description = description
+
+	return schema, nil
+}
+
+// fieldRequired reports whether the field is required, the request fields (with form tag) are required
+// by the binding, and the response fields are always present unless omitempty.
+func fieldRequired(tag reflect.StructTag, jsonOpts string) bool {
+
+	for _, binding := range strings.Split(tag.Get("binding"), ",") {
+		if binding == "required" {
+			return true
+		}
+	}
+
+	if _, ok := tag.Lookup("form"); ok {
+		return false
+	}
+
+	for _, opt := range strings.Split(jsonOpts, ",") {
+		if opt == "omitempty" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nullable .
+func nullable(schema *Schema) *Schema {
+
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+
+	copied := *schema
+	copied.Nullable = true
+
+	return &copied
+}
+
+// isNullType .
+func isNullType(expr ast.Expr) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == "null"
+}
+
+// commentText joins the lines of comment.
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
+
+// exportedName .
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
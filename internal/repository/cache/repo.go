@@ -0,0 +1,214 @@
+// Package cache provides the in-process read-through cache of repositories.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"golang.org/x/sync/singleflight"
+)
+
+// default options
+const (
+	DefaultSize = 1024
+	DefaultTTL  = 30 * time.Second
+)
+
+// TaskRepositoryParam .
+type TaskRepositoryParam struct {
+	// 最多快取的查詢數量, 超過時移除最久未使用的
+	Size int
+	// 快取的有效時間, 其他 instance 的異動最晚於此時間後可見
+	TTL time.Duration
+	// 副本的延遲上限, 異動後此時間內由副本讀取的結果不快取, 沒有副本時為 0
+	ReplicaLag time.Duration
+}
+
+// TaskRepository caches the tasks, the task lists and the task count of next task.Repository.
+// Every write purges the cache, the other methods are passed through.
+//
+// A read racing with a write never stores the result read before the write: the generation is
+// bumped after every write, and a result is only stored if the generation has not changed since
+// the read began. The concurrent identical reads of the same generation share one query.
+//
+// The misses follow the read routing of ctx: the reads hinted by common.WithPrimaryRead, e.g. the
+// read-after-write of the writing request, are sent to the primary, the others to the replicas.
+// As a replica may not have applied a recent write, its results are not cached within ReplicaLag
+// after the write, otherwise the stale rows would be cached for the ttl.
+type TaskRepository struct {
+	next       task.Repository
+	replicaLag time.Duration
+	now        func() time.Time
+
+	mu         sync.Mutex
+	generation uint64
+	writtenAt  time.Time
+	entries    *lru
+
+	group singleflight.Group
+}
+
+// ensure TaskRepository implements task.Repository
+var _ task.Repository = (*TaskRepository)(nil)
+
+// NewTaskRepository .
+func NewTaskRepository(next task.Repository, param TaskRepositoryParam) *TaskRepository {
+
+	if param.Size <= 0 {
+		param.Size = DefaultSize
+	}
+	if param.TTL <= 0 {
+		param.TTL = DefaultTTL
+	}
+
+	return &TaskRepository{
+		next:       next,
+		replicaLag: param.ReplicaLag,
+		now:        time.Now,
+		entries:    newLRU(param.Size, param.TTL),
+	}
+}
+
+// Invalidate purges the cache, e.g. on the task events of other instances.
+func (r *TaskRepository) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.writtenAt = r.now()
+	r.entries.purge()
+}
+
+// load returns the cached value of key, otherwise it calls fn once for the concurrent callers
+// of the same read routing, and caches the result if no write happens meanwhile and the result
+// is read from the primary or after the replica lag. The errors are not cached.
+func (r *TaskRepository) load(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+
+	primary := common.IsPrimaryRead(ctx)
+
+	r.mu.Lock()
+	value, ok := r.entries.get(key)
+	generation := r.generation
+	cacheable := primary || r.now().Sub(r.writtenAt) >= r.replicaLag
+	r.mu.Unlock()
+
+	if ok {
+		return value, nil
+	}
+
+	// the generation is a part of flight key, so the reads after a write do not join the flight before it,
+	// and the primary reads do not join the replica ones
+	flightKey := key + "@" + strconv.FormatUint(generation, 10)
+	if primary {
+		flightKey += "/primary"
+	}
+
+	value, err, _ := r.group.Do(flightKey, func() (any, error) {
+
+		value, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if cacheable && r.generation == generation {
+			r.entries.set(key, value)
+		}
+
+		return value, nil
+	})
+
+	return value, err
+}
+
+// lru evicts the least recently used entry when it is full, the expired entries are removed on get.
+// It is not safe for concurrent use.
+type lru struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// lruEntry .
+type lruEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+// newLRU .
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get .
+func (c *lru) get(key string) (any, bool) {
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+// set .
+func (c *lru) set(key string, value any) {
+
+	expiresAt := c.now().Add(c.ttl)
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+// remove .
+func (c *lru) remove(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
+
+// purge .
+func (c *lru) purge() {
+	c.ll.Init()
+	c.items = make(map[string]*list.Element, c.size)
+}
+
+// len .
+func (c *lru) len() int {
+	return c.ll.Len()
+}
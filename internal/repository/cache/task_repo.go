@@ -0,0 +1,135 @@
+// Package cache provides the in-process read-through cache of repositories.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// cache keys
+const (
+	keyCountTasks = "count_tasks"
+)
+
+// listTasksKey .
+func listTasksKey(param domain.TaskParam) string {
+	status := "any"
+	if param.Status.Valid {
+		status = strconv.FormatBool(param.Status.Bool)
+	}
+	return fmt.Sprintf("list_tasks:%s:%q", status, param.Name)
+}
+
+// taskKey .
+func taskKey(id int64) string {
+	return "task:" + strconv.FormatInt(id, 10)
+}
+
+// ListTasks returns a copy of cached list, so that the callers can not modify the cache.
+func (r *TaskRepository) ListTasks(ctx context.Context, param domain.TaskParam) ([]domain.Task, error) {
+
+	value, err := r.load(ctx, listTasksKey(param), func() (any, error) {
+		return r.next.ListTasks(ctx, param)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := value.([]domain.Task)
+	if tasks == nil {
+		return nil, nil
+	}
+
+	return append(make([]domain.Task, 0, len(tasks)), tasks...), nil
+}
+
+// GetTaskByID .
+func (r *TaskRepository) GetTaskByID(ctx context.Context, id int64) (*domain.Task, error) {
+
+	value, err := r.load(ctx, taskKey(id), func() (any, error) {
+		task, err := r.next.GetTaskByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return *task, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task := value.(domain.Task)
+
+	return &task, nil
+}
+
+// CountTasks .
+func (r *TaskRepository) CountTasks(ctx context.Context) (domain.TaskCount, error) {
+
+	value, err := r.load(ctx, keyCountTasks, func() (any, error) {
+		return r.next.CountTasks(ctx)
+	})
+	if err != nil {
+		return domain.TaskCount{}, err
+	}
+
+	return value.(domain.TaskCount), nil
+}
+
+// CreateTask .
+func (r *TaskRepository) CreateTask(ctx context.Context, param domain.Task) (*domain.Task, error) {
+	defer r.Invalidate()
+
+	return r.next.CreateTask(ctx, param)
+}
+
+// UpdateTask .
+func (r *TaskRepository) UpdateTask(ctx context.Context, param domain.Task) (*domain.Task, error) {
+	defer r.Invalidate()
+
+	return r.next.UpdateTask(ctx, param)
+}
+
+// DeleteTaskByID .
+func (r *TaskRepository) DeleteTaskByID(ctx context.Context, id int64) error {
+	defer r.Invalidate()
+
+	return r.next.DeleteTaskByID(ctx, id)
+}
+
+// UpsertTasks .
+func (r *TaskRepository) UpsertTasks(ctx context.Context, params []domain.Task) ([]domain.TaskUpsertResult, error) {
+	defer r.Invalidate()
+
+	return r.next.UpsertTasks(ctx, params)
+}
+
+// WithTx purges the cache after the transaction, the txRepo is not cached so that fn reads its own writes.
+func (r *TaskRepository) WithTx(ctx context.Context, fn func(txRepo task.Repository) error, opts ...task.TxOption) error {
+	defer r.Invalidate()
+
+	return r.next.WithTx(ctx, fn, opts...)
+}
+
+// LockTaskByID is not cached, the locked row should be read from the transaction.
+func (r *TaskRepository) LockTaskByID(ctx context.Context, id int64) (*domain.Task, error) {
+	return r.next.LockTaskByID(ctx, id)
+}
+
+// IterateTasks is not cached, it is used to export all tasks.
+func (r *TaskRepository) IterateTasks(ctx context.Context, param domain.TaskParam, fn func(task domain.Task) error) error {
+	return r.next.IterateTasks(ctx, param, fn)
+}
+
+// ListTasksByExternalIDs is not cached, it is used to import tasks.
+func (r *TaskRepository) ListTasksByExternalIDs(ctx context.Context, externalIDs []string) ([]domain.Task, error) {
+	return r.next.ListTasksByExternalIDs(ctx, externalIDs)
+}
+
+// CopyOffsetReminders is passed through, the reminders are not cached.
+func (r *TaskRepository) CopyOffsetReminders(ctx context.Context, fromTaskID int64, toTaskID int64) error {
+	return r.next.CopyOffsetReminders(ctx, fromTaskID, toTaskID)
+}
@@ -0,0 +1,326 @@
+// Package cache provides the in-process read-through cache of repositories.
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/application/task"
+	"github.com/tingchima/gogolook/internal/application/task/mocks"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+// buildRepo .
+func buildRepo(t *testing.T, param TaskRepositoryParam) (*TaskRepository, *mocks.MockRepository) {
+
+	next := mocks.NewMockRepository(gomock.NewController(t))
+
+	return NewTaskRepository(next, param), next
+}
+
+// TestTaskRepository_Read .
+func TestTaskRepository_Read(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tasks := []domain.Task{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	undone := domain.TaskParam{Status: null.BoolFrom(false)}
+
+	tests := []struct {
+		name      string
+		setupMock func(next *mocks.MockRepository)
+		run       func(t *testing.T, r *TaskRepository)
+	}{
+		{
+			name: "list is cached by param",
+			setupMock: func(next *mocks.MockRepository) {
+				next.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{}).Return(tasks, nil).Times(1)
+				next.EXPECT().ListTasks(gomock.Any(), undone).Return(tasks[:1], nil).Times(1)
+			},
+			run: func(t *testing.T, r *TaskRepository) {
+				for i := 0; i < 2; i++ {
+					got, err := r.ListTasks(ctx, domain.TaskParam{})
+					require.NoError(t, err)
+					assert.Equal(t, tasks, got)
+
+					// the callers can not modify the cache
+					got[0].Name = "modified"
+
+					got, err = r.ListTasks(ctx, undone)
+					require.NoError(t, err)
+					assert.Len(t, got, 1)
+				}
+			},
+		},
+		{
+			name: "get and count are cached",
+			setupMock: func(next *mocks.MockRepository) {
+				next.EXPECT().GetTaskByID(gomock.Any(), int64(1)).Return(&domain.Task{ID: 1, Name: "a"}, nil).Times(1)
+				next.EXPECT().CountTasks(gomock.Any()).Return(domain.TaskCount{Open: 1}, nil).Times(1)
+			},
+			run: func(t *testing.T, r *TaskRepository) {
+				for i := 0; i < 2; i++ {
+					got, err := r.GetTaskByID(ctx, 1)
+					require.NoError(t, err)
+					assert.Equal(t, "a", got.Name)
+					got.Name = "modified"
+
+					count, err := r.CountTasks(ctx)
+					require.NoError(t, err)
+					assert.Equal(t, domain.TaskCount{Open: 1}, count)
+				}
+			},
+		},
+		{
+			name: "errors are not cached",
+			setupMock: func(next *mocks.MockRepository) {
+				next.EXPECT().GetTaskByID(gomock.Any(), int64(1)).Return(nil, errors.New("task not found")).Times(2)
+			},
+			run: func(t *testing.T, r *TaskRepository) {
+				for i := 0; i < 2; i++ {
+					_, err := r.GetTaskByID(ctx, 1)
+					assert.Error(t, err)
+				}
+			},
+		},
+		{
+			name: "every write purges the cache",
+			setupMock: func(next *mocks.MockRepository) {
+				next.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{}).Return(tasks, nil).Times(6)
+				next.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Return(&domain.Task{ID: 3}, nil)
+				next.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(&domain.Task{ID: 3}, nil)
+				next.EXPECT().DeleteTaskByID(gomock.Any(), int64(3)).Return(nil)
+				next.EXPECT().UpsertTasks(gomock.Any(), gomock.Any()).Return(nil, nil)
+				next.EXPECT().WithTx(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			run: func(t *testing.T, r *TaskRepository) {
+				writes := []func() error{
+					func() error { _, err := r.CreateTask(ctx, domain.Task{Name: "c"}); return err },
+					func() error { _, err := r.UpdateTask(ctx, domain.Task{ID: 3, Name: "c"}); return err },
+					func() error { return r.DeleteTaskByID(ctx, 3) },
+					func() error { _, err := r.UpsertTasks(ctx, nil); return err },
+					func() error { return r.WithTx(ctx, func(task.Repository) error { return nil }) },
+				}
+
+				_, err := r.ListTasks(ctx, domain.TaskParam{})
+				require.NoError(t, err)
+
+				for _, write := range writes {
+					require.NoError(t, write())
+
+					_, err = r.ListTasks(ctx, domain.TaskParam{})
+					require.NoError(t, err)
+				}
+			},
+		},
+		{
+			name: "failed write purges the cache",
+			setupMock: func(next *mocks.MockRepository) {
+				next.EXPECT().CountTasks(gomock.Any()).Return(domain.TaskCount{}, nil).Times(2)
+				next.EXPECT().DeleteTaskByID(gomock.Any(), int64(3)).Return(errors.New("conn reset"))
+			},
+			run: func(t *testing.T, r *TaskRepository) {
+				_, err := r.CountTasks(ctx)
+				require.NoError(t, err)
+
+				assert.Error(t, r.DeleteTaskByID(ctx, 3))
+
+				_, err = r.CountTasks(ctx)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, next := buildRepo(t, TaskRepositoryParam{})
+			tt.setupMock(next)
+
+			tt.run(t, r)
+		})
+	}
+}
+
+// TestTaskRepository_Singleflight .
+func TestTaskRepository_Singleflight(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	r, next := buildRepo(t, TaskRepositoryParam{})
+
+	release := make(chan struct{})
+	next.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{}).DoAndReturn(func(context.Context, domain.TaskParam) ([]domain.Task, error) {
+		<-release
+		return []domain.Task{{ID: 1}}, nil
+	}).Times(1)
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			got, err := r.ListTasks(ctx, domain.TaskParam{})
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+		}()
+	}
+
+	// wait for the callers to join the flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+}
+
+// TestTaskRepository_ConcurrentWrite .
+func TestTaskRepository_ConcurrentWrite(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	r, next := buildRepo(t, TaskRepositoryParam{})
+
+	stale := []domain.Task{{ID: 1, Name: "old"}}
+	fresh := []domain.Task{{ID: 1, Name: "new"}}
+
+	reading := make(chan struct{})
+	written := make(chan struct{})
+
+	// the read began before the write, and returns after it
+	gomock.InOrder(
+		next.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{}).DoAndReturn(func(context.Context, domain.TaskParam) ([]domain.Task, error) {
+			close(reading)
+			<-written
+			return stale, nil
+		}),
+		next.EXPECT().ListTasks(gomock.Any(), domain.TaskParam{}).Return(fresh, nil),
+	)
+	next.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(&fresh[0], nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		got, err := r.ListTasks(ctx, domain.TaskParam{})
+		assert.NoError(t, err)
+		assert.Equal(t, stale, got)
+	}()
+
+	<-reading
+	_, err := r.UpdateTask(ctx, fresh[0])
+	require.NoError(t, err)
+	close(written)
+	<-done
+
+	// the stale result is not cached
+	got, err := r.ListTasks(ctx, domain.TaskParam{})
+	require.NoError(t, err)
+	assert.Equal(t, fresh, got)
+}
+
+// TestTaskRepository_ReadRouting .
+func TestTaskRepository_ReadRouting(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	r, next := buildRepo(t, TaskRepositoryParam{ReplicaLag: time.Second})
+
+	now := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	var primaryReads, replicaReads int
+	next.EXPECT().GetTaskByID(gomock.Any(), int64(1)).DoAndReturn(func(ctx context.Context, id int64) (*domain.Task, error) {
+		if common.IsPrimaryRead(ctx) {
+			primaryReads++
+		} else {
+			replicaReads++
+		}
+		return &domain.Task{ID: id}, nil
+	}).AnyTimes()
+	next.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(&domain.Task{ID: 1}, nil).AnyTimes()
+
+	read := func(ctx context.Context) {
+		_, err := r.GetTaskByID(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	// the ordinary misses are read from the replicas
+	read(ctx)
+	read(ctx)
+	assert.Equal(t, 0, primaryReads)
+	assert.Equal(t, 1, replicaReads)
+
+	// the replica results are not cached right after a write
+	_, err := r.UpdateTask(ctx, domain.Task{ID: 1})
+	require.NoError(t, err)
+
+	read(ctx)
+	read(ctx)
+	assert.Equal(t, 0, primaryReads)
+	assert.Equal(t, 3, replicaReads)
+
+	// the read-after-write of the writing request is read from the primary and cached
+	read(common.WithPrimaryRead(ctx))
+	read(ctx)
+	assert.Equal(t, 1, primaryReads)
+	assert.Equal(t, 3, replicaReads)
+
+	// the replica results are cached after the replica lag
+	_, err = r.UpdateTask(ctx, domain.Task{ID: 1})
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+
+	read(ctx)
+	read(ctx)
+	assert.Equal(t, 1, primaryReads)
+	assert.Equal(t, 4, replicaReads)
+}
+
+// TestLRU .
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC)
+
+	c := newLRU(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set("a", 1)
+	c.set("b", 2)
+
+	// a is used recently, b is evicted
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	c.set("c", 3)
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.len())
+
+	// expired
+	now = now.Add(time.Minute)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.len())
+
+	c.purge()
+	assert.Equal(t, 0, c.len())
+}
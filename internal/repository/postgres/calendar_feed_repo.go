@@ -0,0 +1,223 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+var (
+	ErrNotFoundCalendarFeed = errors.New("calendar feed not found")
+)
+
+// repoCalendarFeed .
+type repoCalendarFeed struct {
+	ID           int64        `db:"id"`
+	User         string       `db:"user_name"`
+	Name         string       `db:"name"`
+	TokenHash    string       `db:"token_hash"`
+	FilterStatus sql.NullBool `db:"filter_status"`
+	FilterName   string       `db:"filter_name"`
+	CreatedAt    time.Time    `db:"created_at"`
+}
+
+// toCalendarFeed convert repo struct to domain struct
+func (row repoCalendarFeed) toCalendarFeed() domain.CalendarFeed {
+
+	return domain.CalendarFeed{
+		ID:        row.ID,
+		User:      row.User,
+		Name:      row.Name,
+		TokenHash: row.TokenHash,
+		Param: domain.TaskParam{
+			Status: null.NewBool(row.FilterStatus.Bool, row.FilterStatus.Valid),
+			Name:   row.FilterName,
+		},
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// table name
+const repoTableCalendarFeed = "calendar_feeds"
+
+type repoFieldNameCalendarFeed struct {
+	ID           string
+	User         string
+	Name         string
+	TokenHash    string
+	FilterStatus string
+	FilterName   string
+	CreatedAt    string
+}
+
+var repoFieldCalendarFeed = repoFieldNameCalendarFeed{
+	ID:           "id",
+	User:         "user_name",
+	Name:         "name",
+	TokenHash:    "token_hash",
+	FilterStatus: "filter_status",
+	FilterName:   "filter_name",
+	CreatedAt:    "created_at",
+}
+
+func (r *repoFieldNameCalendarFeed) fields() []string {
+	return []string{
+		r.ID,
+		r.User,
+		r.Name,
+		r.TokenHash,
+		r.FilterStatus,
+		r.FilterName,
+		r.CreatedAt,
+	}
+}
+
+// 列出使用者的日曆訂閱
+func (r *Postgres) ListCalendarFeeds(ctx context.Context, user string) ([]domain.CalendarFeed, error) {
+
+	ctx, end := r.instrument(ctx, "ListCalendarFeeds")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldCalendarFeed.User: user},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldCalendarFeed.fields()...).
+		From(repoTableCalendarFeed).
+		Where(where).
+		OrderBy(repoFieldCalendarFeed.ID).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoCalendarFeed
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	feeds := make([]domain.CalendarFeed, len(rows))
+
+	for i := range rows {
+		feeds[i] = rows[i].toCalendarFeed()
+	}
+
+	return feeds, nil
+}
+
+// 透過token hash取得日曆訂閱
+func (r *Postgres) GetCalendarFeedByTokenHash(ctx context.Context, tokenHash string) (*domain.CalendarFeed, error) {
+
+	ctx, end := r.instrument(ctx, "GetCalendarFeedByTokenHash")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldCalendarFeed.TokenHash: tokenHash},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldCalendarFeed.fields()...).
+		From(repoTableCalendarFeed).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var row repoCalendarFeed
+
+	err = r.getContext(ctx, &row, query, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrNotFoundCalendarFeed
+			return nil, common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+		}
+		return nil, dbError(err)
+	}
+
+	feed := row.toCalendarFeed()
+
+	return &feed, nil
+}
+
+// 建立日曆訂閱
+func (r *Postgres) CreateCalendarFeed(ctx context.Context, param domain.CalendarFeed) (*domain.CalendarFeed, error) {
+
+	ctx, end := r.instrument(ctx, "CreateCalendarFeed")
+	defer end()
+
+	query, args, err := r.stmtBuilder.Insert(repoTableCalendarFeed).
+		Columns(
+			repoFieldCalendarFeed.User,
+			repoFieldCalendarFeed.Name,
+			repoFieldCalendarFeed.TokenHash,
+			repoFieldCalendarFeed.FilterStatus,
+			repoFieldCalendarFeed.FilterName,
+		).
+		Values(
+			param.User,
+			param.Name,
+			param.TokenHash,
+			param.Param.Status,
+			param.Param.Name,
+		).
+		Suffix(fmt.Sprintf("returning %s", strings.Join(repoFieldCalendarFeed.fields(), ", "))).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var row repoCalendarFeed
+
+	err = r.conn().GetContext(ctx, &row, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	feed := row.toCalendarFeed()
+
+	return &feed, nil
+}
+
+// 刪除使用者的日曆訂閱, 訂閱網址隨即失效
+func (r *Postgres) DeleteCalendarFeed(ctx context.Context, user string, id int64) error {
+
+	ctx, end := r.instrument(ctx, "DeleteCalendarFeed")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldCalendarFeed.ID: id},
+		squirrel.Eq{repoFieldCalendarFeed.User: user},
+	}
+
+	query, args, err := r.stmtBuilder.Delete(repoTableCalendarFeed).Where(where).ToSql()
+	if err != nil {
+		return dbError(err)
+	}
+
+	result, err := r.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return dbError(err)
+	}
+
+	affects, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affects == 0 {
+		err := ErrNotFoundCalendarFeed
+		return common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/lib/pq"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// driver errors
+const (
+	pqCodeNotNullViolation    = "23502"
+	pqCodeForeignKeyViolation = "23503"
+	pqCodeUniqueViolation     = "23505"
+	pqCodeCheckViolation      = "23514"
+	pqCodeStringTruncation    = "22001"
+	pqCodeQueryCanceled       = "57014"
+
+	pqClassDataException = "22"
+)
+
+// constraintMsgs are the client messages of the named constraints in migrations.
+var constraintMsgs = map[string]string{
+	"tasks_external_id_key":         "the external_id already exists",
+	"tasks_priority_check":          "the priority should be between 0 and 9",
+	"reminders_task_id_fkey":        "the task does not exist",
+	"reminders_check":               "either remind_at or offset_seconds should be specified",
+	"calendar_feeds_token_hash_key": "the calendar feed token already exists",
+}
+
+// varcharLength matches the length of the type in message of string truncation, e.g. character varying(255).
+var varcharLength = regexp.MustCompile(`character varying\((\d+)\)`)
+
+// dbError translates the error of sql to the domain error. The client message never contains
+// the sql or the driver message, the cause is kept for the server log.
+func dbError(err error) error {
+
+	var appErr *common.Error
+	if common.AsErr(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg("the request timed out, please retry later"))
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		if isConnFailure(err) {
+			return common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg("the database is unavailable, please retry later"))
+		}
+
+		return common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg("internal error"))
+	}
+
+	switch pqErr.Code {
+	case pqCodeUniqueViolation:
+		return common.NewError(common.ErrCodeResourceAlreadyExisted, err, common.WithMsg(constraintMsg(pqErr, "the resource already exists")))
+
+	case pqCodeForeignKeyViolation:
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(constraintMsg(pqErr, "the referenced resource does not exist")))
+
+	case pqCodeCheckViolation:
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(constraintMsg(pqErr, "some fields are invalid")))
+
+	case pqCodeNotNullViolation:
+		msg := "some required fields are missing"
+		if pqErr.Column != "" {
+			msg = fmt.Sprintf("the %s is required", pqErr.Column)
+		}
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(msg))
+
+	case pqCodeStringTruncation:
+		msg := "some fields are too long"
+		if m := varcharLength.FindStringSubmatch(pqErr.Message); m != nil {
+			msg = fmt.Sprintf("some fields exceed the limit of %s characters", m[1])
+		}
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg(msg))
+
+	case pqCodeQueryCanceled:
+		return common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg("the request timed out, please retry later"))
+
+	case pqCodeSerializationFailure, pqCodeDeadlockDetected:
+		return common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg("the request conflicts with the concurrent requests, please retry later"))
+	}
+
+	if isConnFailure(err) {
+		return common.NewError(common.ErrCodeServiceUnavailable, err, common.WithMsg("the database is unavailable, please retry later"))
+	}
+
+	if pqErr.Code.Class() == pqClassDataException {
+		return common.NewError(common.ErrCodeInvalidParameter, err, common.WithMsg("some fields are invalid"))
+	}
+
+	return common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg("internal error"))
+}
+
+// constraintMsg returns the client message of the constraint violated by pqErr, or fallback
+// for the constraints which are not known.
+func constraintMsg(pqErr *pq.Error, fallback string) string {
+
+	if msg, ok := constraintMsgs[pqErr.Constraint]; ok {
+		return msg
+	}
+
+	return fallback
+}
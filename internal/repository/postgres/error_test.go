@@ -0,0 +1,118 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// TestDBError .
+func TestDBError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		code common.ErrCode
+		msg  string
+	}{
+		{
+			name: "unique violation of external id",
+			err:  &pq.Error{Code: pqCodeUniqueViolation, Constraint: "tasks_external_id_key", Message: `duplicate key value violates unique constraint "tasks_external_id_key"`},
+			code: common.ErrCodeResourceAlreadyExisted,
+			msg:  "the external_id already exists",
+		},
+		{
+			name: "unique violation of unknown constraint",
+			err:  &pq.Error{Code: pqCodeUniqueViolation, Constraint: "other_key"},
+			code: common.ErrCodeResourceAlreadyExisted,
+			msg:  "the resource already exists",
+		},
+		{
+			name: "foreign key violation",
+			err:  &pq.Error{Code: pqCodeForeignKeyViolation, Constraint: "reminders_task_id_fkey"},
+			code: common.ErrCodeInvalidParameter,
+			msg:  "the task does not exist",
+		},
+		{
+			name: "check violation",
+			err:  &pq.Error{Code: pqCodeCheckViolation, Constraint: "tasks_priority_check"},
+			code: common.ErrCodeInvalidParameter,
+			msg:  "the priority should be between 0 and 9",
+		},
+		{
+			name: "not null violation",
+			err:  &pq.Error{Code: pqCodeNotNullViolation, Column: "name"},
+			code: common.ErrCodeInvalidParameter,
+			msg:  "the name is required",
+		},
+		{
+			name: "string truncation",
+			err:  &pq.Error{Code: pqCodeStringTruncation, Message: "value too long for type character varying(255)"},
+			code: common.ErrCodeInvalidParameter,
+			msg:  "some fields exceed the limit of 255 characters",
+		},
+		{
+			name: "invalid text representation",
+			err:  &pq.Error{Code: "22P02", Message: `invalid input syntax for type integer: "x"`},
+			code: common.ErrCodeInvalidParameter,
+			msg:  "some fields are invalid",
+		},
+		{
+			name: "statement timeout",
+			err:  &pq.Error{Code: pqCodeQueryCanceled, Message: "canceling statement due to statement timeout"},
+			code: common.ErrCodeServiceUnavailable,
+			msg:  "the request timed out, please retry later",
+		},
+		{
+			name: "serialization failure",
+			err:  &pq.Error{Code: pqCodeSerializationFailure},
+			code: common.ErrCodeServiceUnavailable,
+			msg:  "the request conflicts with the concurrent requests, please retry later",
+		},
+		{
+			name: "connection failure",
+			err:  fmt.Errorf("select: %w", driver.ErrBadConn),
+			code: common.ErrCodeServiceUnavailable,
+			msg:  "the database is unavailable, please retry later",
+		},
+		{
+			name: "context deadline",
+			err:  context.DeadlineExceeded,
+			code: common.ErrCodeServiceUnavailable,
+			msg:  "the request timed out, please retry later",
+		},
+		{
+			name: "unknown error",
+			err:  &pq.Error{Code: "42P01", Message: `relation "tasks" does not exist`},
+			code: common.ErrCodeInternalProcess,
+			msg:  "internal error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dbError(tt.err)
+			assert.True(t, common.IsErrCode(err, tt.code))
+
+			var appErr *common.Error
+			require.True(t, common.AsErr(err, &appErr))
+			assert.Equal(t, tt.msg, appErr.ClientMsg())
+			// the driver error is kept for the server log
+			assert.True(t, errors.Is(appErr.CauseErr(), tt.err))
+		})
+	}
+
+	// the translated error is not translated again
+	notFound := common.NewError(common.ErrCodeResourceNotFound, domain.ErrNotFoundTask)
+	assert.Same(t, notFound, dbError(notFound))
+}
@@ -0,0 +1,416 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
+	"gopkg.in/guregu/null.v4"
+)
+
+var (
+	ErrNotFoundReminder = errors.New("reminder not found")
+)
+
+// repoReminder .
+type repoReminder struct {
+	ID        int64         `db:"id"`
+	TaskID    int64         `db:"task_id"`
+	RemindAt  sql.NullTime  `db:"remind_at"`
+	Offset    sql.NullInt64 `db:"offset_seconds"`
+	Channel   string        `db:"channel"`
+	Recipient string        `db:"recipient"`
+	SentAt    sql.NullTime  `db:"sent_at"`
+	Attempts  int           `db:"attempts"`
+	LastError string        `db:"last_error"`
+	RetryAt   sql.NullTime  `db:"retry_at"`
+	CreatedAt time.Time     `db:"created_at"`
+}
+
+// toReminder convert repo struct to domain struct
+func (row repoReminder) toReminder() domain.Reminder {
+
+	return domain.Reminder{
+		ID:        row.ID,
+		TaskID:    row.TaskID,
+		RemindAt:  null.NewTime(row.RemindAt.Time, row.RemindAt.Valid),
+		Offset:    null.NewInt(row.Offset.Int64, row.Offset.Valid),
+		Channel:   domain.ReminderChannel(row.Channel),
+		Recipient: row.Recipient,
+		SentAt:    null.NewTime(row.SentAt.Time, row.SentAt.Valid),
+		Attempts:  row.Attempts,
+		LastError: row.LastError,
+		RetryAt:   null.NewTime(row.RetryAt.Time, row.RetryAt.Valid),
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// repoDueReminder is the reminder joined with its task.
+type repoDueReminder struct {
+	repoReminder
+
+	TaskName   string       `db:"task_name"`
+	TaskStatus bool         `db:"task_status"`
+	TaskDueAt  sql.NullTime `db:"task_due_at"`
+	TaskRRule  string       `db:"task_rrule"`
+	TaskTZ     string       `db:"task_timezone"`
+	FireAt     time.Time    `db:"fire_at"`
+}
+
+// toDueReminder .
+func (row repoDueReminder) toDueReminder() domain.DueReminder {
+
+	return domain.DueReminder{
+		Reminder: row.toReminder(),
+		Task: domain.Task{
+			ID:       row.TaskID,
+			Name:     row.TaskName,
+			Status:   row.TaskStatus,
+			DueAt:    null.NewTime(row.TaskDueAt.Time, row.TaskDueAt.Valid),
+			RRule:    row.TaskRRule,
+			Timezone: row.TaskTZ,
+		},
+		FireAt: row.FireAt,
+	}
+}
+
+// table name
+const repoTableReminder = "reminders"
+
+type repoFieldNameReminder struct {
+	ID        string
+	TaskID    string
+	RemindAt  string
+	Offset    string
+	Channel   string
+	Recipient string
+	SentAt    string
+	Attempts  string
+	LastError string
+	RetryAt   string
+	CreatedAt string
+}
+
+var repoFieldReminder = repoFieldNameReminder{
+	ID:        "id",
+	TaskID:    "task_id",
+	RemindAt:  "remind_at",
+	Offset:    "offset_seconds",
+	Channel:   "channel",
+	Recipient: "recipient",
+	SentAt:    "sent_at",
+	Attempts:  "attempts",
+	LastError: "last_error",
+	RetryAt:   "retry_at",
+	CreatedAt: "created_at",
+}
+
+func (r *repoFieldNameReminder) fields() []string {
+	return []string{
+		r.ID,
+		r.TaskID,
+		r.RemindAt,
+		r.Offset,
+		r.Channel,
+		r.Recipient,
+		r.SentAt,
+		r.Attempts,
+		r.LastError,
+		r.RetryAt,
+		r.CreatedAt,
+	}
+}
+
+// 列出任務的提醒
+func (r *Postgres) ListRemindersByTaskID(ctx context.Context, taskID int64) ([]domain.Reminder, error) {
+
+	ctx, end := r.instrument(ctx, "ListRemindersByTaskID")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldReminder.TaskID: taskID},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldReminder.fields()...).
+		From(repoTableReminder).
+		Where(where).
+		OrderBy(repoFieldReminder.ID).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoReminder
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	reminders := make([]domain.Reminder, len(rows))
+
+	for i := range rows {
+		reminders[i] = rows[i].toReminder()
+	}
+
+	return reminders, nil
+}
+
+// 列出多個任務的提醒, 依任務ID與提醒ID排序
+func (r *Postgres) ListRemindersByTaskIDs(ctx context.Context, taskIDs []int64) ([]domain.Reminder, error) {
+
+	ctx, end := r.instrument(ctx, "ListRemindersByTaskIDs")
+	defer end()
+
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldReminder.TaskID: taskIDs},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldReminder.fields()...).
+		From(repoTableReminder).
+		Where(where).
+		OrderBy(repoFieldReminder.TaskID, repoFieldReminder.ID).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoReminder
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	reminders := make([]domain.Reminder, len(rows))
+
+	for i := range rows {
+		reminders[i] = rows[i].toReminder()
+	}
+
+	return reminders, nil
+}
+
+// 建立提醒
+func (r *Postgres) CreateReminder(ctx context.Context, param domain.Reminder) (*domain.Reminder, error) {
+
+	ctx, end := r.instrument(ctx, "CreateReminder")
+	defer end()
+
+	query, args, err := r.stmtBuilder.Insert(repoTableReminder).
+		Columns(
+			repoFieldReminder.TaskID,
+			repoFieldReminder.RemindAt,
+			repoFieldReminder.Offset,
+			repoFieldReminder.Channel,
+			repoFieldReminder.Recipient,
+		).
+		Values(
+			param.TaskID,
+			param.RemindAt,
+			param.Offset,
+			string(param.Channel),
+			param.Recipient,
+		).
+		Suffix(fmt.Sprintf("returning %s", strings.Join(repoFieldReminder.fields(), ", "))).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var row repoReminder
+
+	err = r.conn().GetContext(ctx, &row, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	reminder := row.toReminder()
+
+	return &reminder, nil
+}
+
+// 複製任務中相對到期時間的提醒至另一任務, 例如重複任務的下一次, 複製的提醒尚未送出
+func (r *Postgres) CopyOffsetReminders(ctx context.Context, fromTaskID int64, toTaskID int64) error {
+
+	ctx, end := r.instrument(ctx, "CopyOffsetReminders")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldReminder.TaskID: fromTaskID},
+		squirrel.NotEq{repoFieldReminder.Offset: nil},
+	}
+
+	reminders := r.stmtBuilder.Select().
+		Column(squirrel.Expr("CAST(? AS integer)", toTaskID)).
+		Columns(
+			repoFieldReminder.Offset,
+			repoFieldReminder.Channel,
+			repoFieldReminder.Recipient,
+		).
+		From(repoTableReminder).
+		Where(where).
+		OrderBy(repoFieldReminder.ID)
+
+	query, args, err := r.stmtBuilder.Insert(repoTableReminder).
+		Columns(
+			repoFieldReminder.TaskID,
+			repoFieldReminder.Offset,
+			repoFieldReminder.Channel,
+			repoFieldReminder.Recipient,
+		).
+		Select(reminders).
+		ToSql()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if _, err = r.conn().ExecContext(ctx, query, args...); err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// 刪除任務的提醒
+func (r *Postgres) DeleteReminder(ctx context.Context, taskID int64, id int64) error {
+
+	ctx, end := r.instrument(ctx, "DeleteReminder")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldReminder.ID: id},
+		squirrel.Eq{repoFieldReminder.TaskID: taskID},
+	}
+
+	query, args, err := r.stmtBuilder.Delete(repoTableReminder).Where(where).ToSql()
+	if err != nil {
+		return dbError(err)
+	}
+
+	result, err := r.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return dbError(err)
+	}
+
+	affects, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affects == 0 {
+		err := ErrNotFoundReminder
+		return common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+	}
+
+	return nil
+}
+
+// 鎖定到期的提醒並交由 handle 送出
+//
+// The reminders are locked by SELECT ... FOR UPDATE SKIP LOCKED in a transaction,
+// so the other instances skip them instead of sending twice. The transaction is held
+// while delivering, so the batch should be small.
+func (r *Postgres) ProcessDueReminders(ctx context.Context, param domain.DueReminderParam, handle func(ctx context.Context, reminder domain.DueReminder) error) (int, error) {
+
+	ctx, end := r.instrument(ctx, "ProcessDueReminders")
+	defer end()
+
+	fireAt := "COALESCE(r.remind_at, t.due_at + r.offset_seconds * interval '1 second')"
+
+	columns := make([]string, 0, len(repoFieldReminder.fields())+6)
+	for _, field := range repoFieldReminder.fields() {
+		columns = append(columns, "r."+field)
+	}
+	columns = append(columns,
+		"t.name AS task_name",
+		"t.status AS task_status",
+		"t.due_at AS task_due_at",
+		"t.rrule AS task_rrule",
+		"t.timezone AS task_timezone",
+		fireAt+" AS fire_at",
+	)
+
+	where := squirrel.And{
+		squirrel.Expr("r.sent_at IS NULL"),
+		squirrel.Expr("t.status = FALSE"),
+		squirrel.Lt{"r.attempts": param.MaxAttempts},
+		squirrel.Or{
+			squirrel.Expr("r.retry_at IS NULL"),
+			squirrel.LtOrEq{"r.retry_at": param.Now},
+		},
+		squirrel.Expr(fireAt+" <= ?", param.Now),
+	}
+
+	query, args, err := r.stmtBuilder.Select(columns...).
+		From(repoTableReminder + " r").
+		Join(repoTableTask + " t ON t.id = r.task_id").
+		Where(where).
+		OrderBy("fire_at").
+		Limit(uint64(param.Limit)).
+		Suffix("FOR UPDATE OF r SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var rows []repoDueReminder
+
+	if err = tx.SelectContext(ctx, &rows, query, args...); err != nil {
+		return 0, dbError(err)
+	}
+
+	for i := range rows {
+		var updates map[string]any
+
+		handleErr := handle(ctx, rows[i].toDueReminder())
+		if handleErr == nil {
+			updates = map[string]any{
+				repoFieldReminder.SentAt:    param.Now,
+				repoFieldReminder.LastError: "",
+				repoFieldReminder.RetryAt:   nil,
+			}
+		} else {
+			updates = map[string]any{
+				repoFieldReminder.Attempts:  squirrel.Expr(repoFieldReminder.Attempts + " + 1"),
+				repoFieldReminder.LastError: handleErr.Error(),
+				repoFieldReminder.RetryAt:   param.Now.Add(param.RetryDelay << rows[i].Attempts),
+			}
+		}
+
+		query, args, err := r.stmtBuilder.Update(repoTableReminder).
+			Where(squirrel.Eq{repoFieldReminder.ID: rows[i].ID}).
+			SetMap(updates).
+			ToSql()
+		if err != nil {
+			return 0, dbError(err)
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, dbError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, dbError(err)
+	}
+
+	return len(rows), nil
+}
@@ -0,0 +1,110 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestReminderRepo_ProcessDueReminders .
+func TestReminderRepo_ProcessDueReminders(t *testing.T) {
+
+	repo := NewRepository(getTestDBConn())
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	task, err := repo.CreateTask(ctx, domain.Task{Name: "繳房租", DueAt: null.TimeFrom(now.Add(time.Hour))})
+	require.NoError(t, err)
+
+	// due: one hour before due date
+	due, err := repo.CreateReminder(ctx, domain.Reminder{TaskID: task.ID, Offset: null.IntFrom(-3600), Channel: domain.ReminderChannelLog})
+	require.NoError(t, err)
+
+	// not due yet
+	_, err = repo.CreateReminder(ctx, domain.Reminder{TaskID: task.ID, RemindAt: null.TimeFrom(now.Add(time.Minute)), Channel: domain.ReminderChannelLog})
+	require.NoError(t, err)
+
+	var handled []int64
+
+	param := domain.DueReminderParam{Now: now, Limit: 10, MaxAttempts: 3, RetryDelay: time.Minute}
+
+	processed, err := repo.ProcessDueReminders(ctx, param, func(_ context.Context, reminder domain.DueReminder) error {
+		handled = append(handled, reminder.Reminder.ID)
+		assert.Equal(t, task.Name, reminder.Task.Name)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []int64{due.ID}, handled)
+
+	// the sent reminder is not picked up again
+	processed, err = repo.ProcessDueReminders(ctx, param, func(_ context.Context, _ domain.DueReminder) error {
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	reminders, err := repo.ListRemindersByTaskID(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, reminders, 2)
+	assert.True(t, reminders[0].SentAt.Valid)
+	assert.False(t, reminders[1].SentAt.Valid)
+
+	other, err := repo.CreateTask(ctx, domain.Task{Name: "繳水費"})
+	require.NoError(t, err)
+
+	_, err = repo.CreateReminder(ctx, domain.Reminder{TaskID: other.ID, RemindAt: null.TimeFrom(now.Add(time.Minute)), Channel: domain.ReminderChannelLog})
+	require.NoError(t, err)
+
+	reminders, err = repo.ListRemindersByTaskIDs(ctx, []int64{other.ID, task.ID})
+	require.NoError(t, err)
+	require.Len(t, reminders, 3)
+	assert.Equal(t, task.ID, reminders[0].TaskID)
+	assert.Equal(t, other.ID, reminders[2].TaskID)
+}
+
+// TestReminderRepo_CopyOffsetReminders .
+func TestReminderRepo_CopyOffsetReminders(t *testing.T) {
+
+	repo := NewRepository(getTestDBConn())
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	task, err := repo.CreateTask(ctx, domain.Task{Name: "繳房租", DueAt: null.TimeFrom(now)})
+	require.NoError(t, err)
+
+	next, err := repo.CreateTask(ctx, domain.Task{Name: "繳房租", DueAt: null.TimeFrom(now.AddDate(0, 1, 0))})
+	require.NoError(t, err)
+
+	_, err = repo.CreateReminder(ctx, domain.Reminder{TaskID: task.ID, Offset: null.IntFrom(-3600), Channel: domain.ReminderChannelWebhook, Recipient: "https://example.com/hook"})
+	require.NoError(t, err)
+
+	_, err = repo.CreateReminder(ctx, domain.Reminder{TaskID: task.ID, RemindAt: null.TimeFrom(now), Channel: domain.ReminderChannelLog})
+	require.NoError(t, err)
+
+	// the sent reminder is copied as not sent
+	_, err = repo.ProcessDueReminders(ctx, domain.DueReminderParam{Now: now, Limit: 10, MaxAttempts: 3, RetryDelay: time.Minute},
+		func(context.Context, domain.DueReminder) error { return nil })
+	require.NoError(t, err)
+
+	err = repo.CopyOffsetReminders(ctx, task.ID, next.ID)
+	require.NoError(t, err)
+
+	// only the reminder relative to the due time is copied
+	reminders, err := repo.ListRemindersByTaskID(ctx, next.ID)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, null.IntFrom(-3600), reminders[0].Offset)
+	assert.Equal(t, domain.ReminderChannelWebhook, reminders[0].Channel)
+	assert.Equal(t, "https://example.com/hook", reminders[0].Recipient)
+	assert.False(t, reminders[0].SentAt.Valid)
+}
@@ -0,0 +1,245 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"net"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// replica check
+const (
+	// ReplicaCheckInterval .
+	ReplicaCheckInterval = 5 * time.Second
+	// replicaCheckTimeout .
+	replicaCheckTimeout = 2 * time.Second
+	// replicaDownDuration is how long a replica is skipped after a connection failure of query,
+	// the health check brings it back earlier.
+	replicaDownDuration = 30 * time.Second
+)
+
+// replica .
+type replica struct {
+	name string
+	db   *sqlx.DB
+
+	// downUntil is the unix nano before which the replica is skipped, 0 if it is healthy
+	downUntil atomic.Int64
+}
+
+// healthy .
+func (rep *replica) healthy(now time.Time) bool {
+	return now.UnixNano() >= rep.downUntil.Load()
+}
+
+// WithReplicas routes the list and get queries to the replicas by round robin, the replicas which
+// fail the health check or the connection are skipped, and the queries go to the primary if no replica is healthy.
+func WithReplicas(replicas ...*sqlx.DB) Option {
+	return func(r *Postgres) {
+		for i := range replicas {
+			r.replicas = append(r.replicas, &replica{
+				name: "replica-" + strconv.Itoa(i),
+				db:   replicas[i],
+			})
+		}
+	}
+}
+
+// pickReplica returns nil if the primary should be read.
+func (r *Postgres) pickReplica(ctx context.Context) *replica {
+
+	if len(r.replicas) == 0 || common.IsPrimaryRead(ctx) {
+		return nil
+	}
+
+	now := r.now()
+	start := r.nextReplica.Add(1)
+
+	for i := range r.replicas {
+		rep := r.replicas[(int(start)+i)%len(r.replicas)]
+		if rep.healthy(now) {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+// read runs query on a replica, it is run again on the primary if the connection to replica fails.
+// The query of transaction is run in the transaction.
+func (r *Postgres) read(ctx context.Context, query func(db dbConn) error) error {
+
+	if r.tx != nil {
+		return query(r.tx)
+	}
+
+	rep := r.pickReplica(ctx)
+	if rep == nil {
+		return query(r.db)
+	}
+
+	err := query(rep.db)
+	if err == nil || ctx.Err() != nil || !isReplicaFailure(err) {
+		return err
+	}
+
+	if isConnFailure(err) {
+		r.markReplicaDown(ctx, rep, err)
+	}
+
+	return query(r.db)
+}
+
+// selectContext .
+func (r *Postgres) selectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.read(ctx, func(db dbConn) error {
+		// the rows scanned before the failure are dropped
+		resetDest(dest)
+		return db.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// getContext .
+func (r *Postgres) getContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.read(ctx, func(db dbConn) error {
+		return db.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// queryxContext only fails over when the query fails, the rows which have been read can not be read again.
+func (r *Postgres) queryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+
+	var rows *sqlx.Rows
+
+	err := r.read(ctx, func(db dbConn) error {
+		var err error
+		rows, err = db.QueryxContext(ctx, query, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// resetDest .
+func resetDest(dest any) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
+
+// markReplicaDown .
+func (r *Postgres) markReplicaDown(ctx context.Context, rep *replica, err error) {
+
+	if rep.healthy(r.now()) {
+		slog.WarnContext(ctx, "postgres replica down, fail over to primary", "replica", rep.name, "err", err)
+	}
+
+	rep.downUntil.Store(r.now().Add(replicaDownDuration).UnixNano())
+}
+
+// CheckReplicas pings every replica, the replica is read again once it responds.
+func (r *Postgres) CheckReplicas(ctx context.Context) {
+
+	for _, rep := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+		err := rep.db.PingContext(checkCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			r.markReplicaDown(ctx, rep, err)
+			continue
+		}
+
+		if !rep.healthy(r.now()) {
+			slog.InfoContext(ctx, "postgres replica up", "replica", rep.name)
+		}
+		rep.downUntil.Store(0)
+	}
+}
+
+// RunReplicaCheck checks the replicas every interval until ctx is done.
+func (r *Postgres) RunReplicaCheck(ctx context.Context, interval time.Duration) {
+
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckReplicas(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replica failures
+const (
+	// the standby cancels the query conflicting with the replayed changes, it is fine on the primary
+	pqCodeSerializationFailure = "40001"
+
+	pqClassConnectionException   = "08"
+	pqClassInsufficientResources = "53"
+	pqCodeAdminShutdown          = "57P01"
+	pqCodeCrashShutdown          = "57P02"
+	pqCodeCannotConnectNow       = "57P03"
+)
+
+// isReplicaFailure reports whether the query may succeed on the primary.
+func isReplicaFailure(err error) bool {
+
+	if isConnFailure(err) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqCodeSerializationFailure
+}
+
+// isConnFailure reports whether the replica can not serve any query.
+func isConnFailure(err error) bool {
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqCodeAdminShutdown, pqCodeCrashShutdown, pqCodeCannotConnectNow:
+			return true
+		}
+
+		switch pqErr.Code.Class() {
+		case pqClassConnectionException, pqClassInsufficientResources:
+			return true
+		}
+	}
+
+	return false
+}
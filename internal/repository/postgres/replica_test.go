@@ -0,0 +1,149 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// openUnreachable opens the handle which is never connected by the tests.
+func openUnreachable(t *testing.T, name string) *sqlx.DB {
+
+	db, err := sqlx.Open("postgres", fmt.Sprintf("host=127.0.0.1 port=1 dbname=%s sslmode=disable", name))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// TestPostgres_read .
+func TestPostgres_read(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		// replicaErr is returned by the query on replica
+		replicaErr  error
+		wantDBs     []string
+		wantErr     bool
+		wantDown    bool
+		replicaDown bool
+	}{
+		{
+			name:    "replica",
+			ctx:     ctx,
+			wantDBs: []string{"replica"},
+		},
+		{
+			name:    "primary hint",
+			ctx:     common.WithPrimaryRead(ctx),
+			wantDBs: []string{"primary"},
+		},
+		{
+			name:        "replica is down",
+			ctx:         ctx,
+			replicaDown: true,
+			wantDBs:     []string{"primary"},
+		},
+		{
+			name:       "connection failure fails over",
+			ctx:        ctx,
+			replicaErr: driver.ErrBadConn,
+			wantDBs:    []string{"replica", "primary"},
+			wantDown:   true,
+		},
+		{
+			name:       "recovery conflict is read from primary",
+			ctx:        ctx,
+			replicaErr: &pq.Error{Code: pqCodeSerializationFailure},
+			wantDBs:    []string{"replica", "primary"},
+		},
+		{
+			name:       "query error is returned",
+			ctx:        ctx,
+			replicaErr: &pq.Error{Code: "42703"},
+			wantDBs:    []string{"replica"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			primary := openUnreachable(t, "primary")
+			replica := openUnreachable(t, "replica")
+
+			r := NewRepository(primary, WithReplicas(replica))
+			if tt.replicaDown {
+				r.replicas[0].downUntil.Store(time.Now().Add(time.Minute).UnixNano())
+			}
+
+			var dbs []string
+
+			err := r.read(tt.ctx, func(db dbConn) error {
+				if db == replica {
+					dbs = append(dbs, "replica")
+					return tt.replicaErr
+				}
+				dbs = append(dbs, "primary")
+				return nil
+			})
+
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantDBs, dbs)
+			assert.Equal(t, tt.wantDown || tt.replicaDown, !r.replicas[0].healthy(time.Now()))
+		})
+	}
+}
+
+// TestPostgres_pickReplica .
+func TestPostgres_pickReplica(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC)
+
+	r := NewRepository(openUnreachable(t, "primary"),
+		WithReplicas(openUnreachable(t, "replica-0"), openUnreachable(t, "replica-1")))
+	r.now = func() time.Time { return now }
+
+	// round robin
+	first := r.pickReplica(context.Background())
+	second := r.pickReplica(context.Background())
+	assert.NotSame(t, first, second)
+
+	// the replica which is down is skipped until the down duration passes
+	r.markReplicaDown(context.Background(), first, driver.ErrBadConn)
+	for i := 0; i < 3; i++ {
+		assert.Same(t, second, r.pickReplica(context.Background()))
+	}
+
+	r.markReplicaDown(context.Background(), second, driver.ErrBadConn)
+	assert.Nil(t, r.pickReplica(context.Background()))
+
+	now = now.Add(replicaDownDuration)
+	assert.NotNil(t, r.pickReplica(context.Background()))
+}
+
+// TestCheckReplicas .
+func TestCheckReplicas(t *testing.T) {
+	t.Parallel()
+
+	r := NewRepository(openUnreachable(t, "primary"), WithReplicas(openUnreachable(t, "replica")))
+
+	r.CheckReplicas(context.Background())
+	assert.Nil(t, r.pickReplica(context.Background()))
+}
@@ -2,19 +2,100 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Postgres struct {
 	db          *sqlx.DB
 	stmtBuilder squirrel.StatementBuilderType
+
+	queryObserver QueryObserver
+
+	replicas    []*replica
+	nextReplica atomic.Uint64
+	now         func() time.Time
+
+	// tx is set for the repository of WithTx
+	tx      *sqlx.Tx
+	txDepth int
+}
+
+// QueryObserver receives the duration of every repository method, e.g. for the metrics.
+type QueryObserver func(method string, duration time.Duration)
+
+// Option .
+type Option func(*Postgres)
+
+// WithQueryObserver .
+func WithQueryObserver(observer QueryObserver) Option {
+	return func(r *Postgres) {
+		r.queryObserver = observer
+	}
 }
 
-// NewRepository .
-func NewRepository(conn *sqlx.DB) *Postgres {
-	return &Postgres{
+// NewRepository writes to the primary conn, the reads go to the replicas of WithReplicas if any,
+// unless the ctx is hinted by common.WithPrimaryRead.
+func NewRepository(conn *sqlx.DB, options ...Option) *Postgres {
+
+	r := &Postgres{
 		db:          conn,
 		stmtBuilder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		now:         time.Now,
 	}
+
+	for _, o := range options {
+		o(r)
+	}
+
+	return r
+}
+
+// tracer .
+var tracer = otel.Tracer("github.com/tingchima/gogolook/internal/repository/postgres")
+
+// instrument starts the span of method, the returned func ends the span and reports
+// the duration to the query observer. The sql queries of method are the children of span.
+func (r *Postgres) instrument(ctx context.Context, method string) (context.Context, func()) {
+
+	start := time.Now()
+
+	// the background queries without parent span, e.g. the metrics scrape, are not traced
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		ctx, span = tracer.Start(ctx, "postgres."+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		)
+	}
+
+	return ctx, func() {
+		span.End()
+
+		if r.queryObserver != nil {
+			r.queryObserver(method, time.Since(start))
+		}
+	}
+}
+
+// likeEscaper escapes the wildcard characters of LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike .
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// nullString stores the empty string as NULL, for the nullable unique columns.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
@@ -0,0 +1,274 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// table name
+const (
+	repoTableTaskTag     = "task_tags"
+	repoTableSubtask     = "subtasks"
+	repoTableTaskHistory = "task_history"
+)
+
+// repoTaskTag .
+type repoTaskTag struct {
+	TaskID int64  `db:"task_id"`
+	Tag    string `db:"tag"`
+}
+
+type repoFieldNameTaskTag struct {
+	TaskID string
+	Tag    string
+}
+
+var repoFieldTaskTag = repoFieldNameTaskTag{
+	TaskID: "task_id",
+	Tag:    "tag",
+}
+
+func (r *repoFieldNameTaskTag) fields() []string {
+	return []string{
+		r.TaskID,
+		r.Tag,
+	}
+}
+
+// repoSubtask .
+type repoSubtask struct {
+	ID        int64        `db:"id"`
+	TaskID    int64        `db:"task_id"`
+	Name      string       `db:"name"`
+	Status    bool         `db:"status"`
+	CreatedAt time.Time    `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+}
+
+// toSubtask convert repo struct to domain struct
+func (row repoSubtask) toSubtask() domain.Subtask {
+
+	return domain.Subtask{
+		ID:        row.ID,
+		TaskID:    row.TaskID,
+		Name:      row.Name,
+		Status:    row.Status,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+}
+
+type repoFieldNameSubtask struct {
+	ID        string
+	TaskID    string
+	Name      string
+	Status    string
+	CreatedAt string
+	UpdatedAt string
+}
+
+var repoFieldSubtask = repoFieldNameSubtask{
+	ID:        "id",
+	TaskID:    "task_id",
+	Name:      "name",
+	Status:    "status",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (r *repoFieldNameSubtask) fields() []string {
+	return []string{
+		r.ID,
+		r.TaskID,
+		r.Name,
+		r.Status,
+		r.CreatedAt,
+		r.UpdatedAt,
+	}
+}
+
+// repoTaskHistory .
+type repoTaskHistory struct {
+	ID        int64        `db:"id"`
+	TaskID    int64        `db:"task_id"`
+	Action    string       `db:"action"`
+	Name      string       `db:"name"`
+	Status    bool         `db:"status"`
+	DueAt     sql.NullTime `db:"due_at"`
+	Priority  int          `db:"priority"`
+	ChangedAt time.Time    `db:"changed_at"`
+}
+
+// toTaskHistory convert repo struct to domain struct
+func (row repoTaskHistory) toTaskHistory() domain.TaskHistory {
+
+	return domain.TaskHistory{
+		ID:        row.ID,
+		TaskID:    row.TaskID,
+		Action:    domain.TaskHistoryAction(row.Action),
+		Name:      row.Name,
+		Status:    row.Status,
+		DueAt:     null.NewTime(row.DueAt.Time, row.DueAt.Valid),
+		Priority:  row.Priority,
+		ChangedAt: row.ChangedAt,
+	}
+}
+
+type repoFieldNameTaskHistory struct {
+	ID        string
+	TaskID    string
+	Action    string
+	Name      string
+	Status    string
+	DueAt     string
+	Priority  string
+	ChangedAt string
+}
+
+var repoFieldTaskHistory = repoFieldNameTaskHistory{
+	ID:        "id",
+	TaskID:    "task_id",
+	Action:    "action",
+	Name:      "name",
+	Status:    "status",
+	DueAt:     "due_at",
+	Priority:  "priority",
+	ChangedAt: "changed_at",
+}
+
+func (r *repoFieldNameTaskHistory) fields() []string {
+	return []string{
+		r.ID,
+		r.TaskID,
+		r.Action,
+		r.Name,
+		r.Status,
+		r.DueAt,
+		r.Priority,
+		r.ChangedAt,
+	}
+}
+
+// 列出多個任務的標籤, 依任務ID與標籤排序
+func (r *Postgres) ListTagsByTaskIDs(ctx context.Context, taskIDs []int64) ([]domain.TaskTag, error) {
+
+	ctx, end := r.instrument(ctx, "ListTagsByTaskIDs")
+	defer end()
+
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldTaskTag.TaskID: taskIDs},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTaskTag.fields()...).
+		From(repoTableTaskTag).
+		Where(where).
+		OrderBy(repoFieldTaskTag.TaskID, repoFieldTaskTag.Tag).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoTaskTag
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	tags := make([]domain.TaskTag, len(rows))
+
+	for i := range rows {
+		tags[i] = domain.TaskTag{TaskID: rows[i].TaskID, Tag: rows[i].Tag}
+	}
+
+	return tags, nil
+}
+
+// 列出多個任務的子任務, 依任務ID與子任務ID排序
+func (r *Postgres) ListSubtasksByTaskIDs(ctx context.Context, taskIDs []int64) ([]domain.Subtask, error) {
+
+	ctx, end := r.instrument(ctx, "ListSubtasksByTaskIDs")
+	defer end()
+
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldSubtask.TaskID: taskIDs},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldSubtask.fields()...).
+		From(repoTableSubtask).
+		Where(where).
+		OrderBy(repoFieldSubtask.TaskID, repoFieldSubtask.ID).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoSubtask
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	subtasks := make([]domain.Subtask, len(rows))
+
+	for i := range rows {
+		subtasks[i] = rows[i].toSubtask()
+	}
+
+	return subtasks, nil
+}
+
+// 列出多個任務的異動紀錄, 依任務ID與異動順序排序, 每個任務最多 limit 筆最新的紀錄
+func (r *Postgres) ListTaskHistoryByTaskIDs(ctx context.Context, taskIDs []int64, limit int) ([]domain.TaskHistory, error) {
+
+	ctx, end := r.instrument(ctx, "ListTaskHistoryByTaskIDs")
+	defer end()
+
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	// the latest records of every task are ranked in a subquery, so that a long history is not loaded
+	ranked := r.stmtBuilder.Select(repoFieldTaskHistory.fields()...).
+		Column(fmt.Sprintf("row_number() OVER (PARTITION BY %s ORDER BY %s DESC) AS rank",
+			repoFieldTaskHistory.TaskID, repoFieldTaskHistory.ID)).
+		From(repoTableTaskHistory).
+		Where(squirrel.Eq{repoFieldTaskHistory.TaskID: taskIDs})
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTaskHistory.fields()...).
+		FromSelect(ranked, "h").
+		Where(squirrel.LtOrEq{"rank": limit}).
+		OrderBy(repoFieldTaskHistory.TaskID, repoFieldTaskHistory.ID).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoTaskHistory
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	history := make([]domain.TaskHistory, len(rows))
+
+	for i := range rows {
+		history[i] = rows[i].toTaskHistory()
+	}
+
+	return history, nil
+}
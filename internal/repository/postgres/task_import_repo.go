@@ -0,0 +1,182 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// 逐筆讀取任務, 不會一次載入所有任務
+func (r *Postgres) IterateTasks(ctx context.Context, param domain.TaskParam, fn func(task domain.Task) error) error {
+
+	ctx, end := r.instrument(ctx, "IterateTasks")
+	defer end()
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTask.fields()...).
+		From(repoTableTask).
+		Where(taskParamWheres(param)).
+		OrderBy(repoFieldTask.ID).
+		ToSql()
+	if err != nil {
+		return dbError(err)
+	}
+
+	rows, err := r.queryxContext(ctx, query, args...)
+	if err != nil {
+		return dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row repoTask
+
+		if err = rows.StructScan(&row); err != nil {
+			return dbError(err)
+		}
+
+		if err = fn(row.toTask()); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// 透過外部ID列出任務
+func (r *Postgres) ListTasksByExternalIDs(ctx context.Context, externalIDs []string) ([]domain.Task, error) {
+
+	ctx, end := r.instrument(ctx, "ListTasksByExternalIDs")
+	defer end()
+
+	if len(externalIDs) == 0 {
+		return nil, nil
+	}
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldTask.ExternalID: externalIDs},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTask.fields()...).
+		From(repoTableTask).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var rows []repoTask
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
+	}
+
+	tasks := make([]domain.Task, len(rows))
+
+	for i := range rows {
+		tasks[i] = rows[i].toTask()
+	}
+
+	return tasks, nil
+}
+
+// 依外部ID新增或更新任務, 沒有外部ID的任務一律新增; 全部成功或全部失敗
+func (r *Postgres) UpsertTasks(ctx context.Context, params []domain.Task) ([]domain.TaskUpsertResult, error) {
+
+	ctx, end := r.instrument(ctx, "UpsertTasks")
+	defer end()
+
+	conflictUpdates := []string{
+		repoFieldTask.Name,
+		repoFieldTask.Status,
+		repoFieldTask.DueAt,
+		repoFieldTask.RRule,
+		repoFieldTask.Timezone,
+		repoFieldTask.SeriesStart,
+		repoFieldTask.Priority,
+	}
+	for i := range conflictUpdates {
+		conflictUpdates[i] = fmt.Sprintf("%s = EXCLUDED.%s", conflictUpdates[i], conflictUpdates[i])
+	}
+	conflictUpdates = append(conflictUpdates, fmt.Sprintf("%s = NOW()", repoFieldTask.UpdatedAt))
+
+	// xmax is zero for the inserted row
+	suffix := fmt.Sprintf(
+		"ON CONFLICT (%s) DO UPDATE SET %s returning %s, (xmax = 0) AS inserted",
+		repoFieldTask.ExternalID,
+		strings.Join(conflictUpdates, ", "),
+		strings.Join(repoFieldTask.fields(), ", "),
+	)
+
+	var results []domain.TaskUpsertResult
+
+	// in a savepoint if it is called in WithTx
+	err := r.withTx(ctx, nil, func(txRepo *Postgres) error {
+		var err error
+		results, err = txRepo.upsertTasks(ctx, params, suffix)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// upsertTasks .
+func (r *Postgres) upsertTasks(ctx context.Context, params []domain.Task, suffix string) ([]domain.TaskUpsertResult, error) {
+
+	results := make([]domain.TaskUpsertResult, len(params))
+
+	for i := range params {
+		query, args, err := r.stmtBuilder.Insert(repoTableTask).
+			Columns(
+				repoFieldTask.Name,
+				repoFieldTask.Status,
+				repoFieldTask.DueAt,
+				repoFieldTask.RRule,
+				repoFieldTask.Timezone,
+				repoFieldTask.SeriesStart,
+				repoFieldTask.Priority,
+				repoFieldTask.ExternalID,
+			).
+			Values(
+				params[i].Name,
+				params[i].Status,
+				params[i].DueAt,
+				params[i].RRule,
+				params[i].Timezone,
+				params[i].SeriesStart,
+				params[i].Priority,
+				nullString(params[i].ExternalID),
+			).
+			Suffix(suffix).
+			ToSql()
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		var row struct {
+			repoTask
+			Inserted bool `db:"inserted"`
+		}
+
+		if err = r.conn().GetContext(ctx, &row, query, args...); err != nil {
+			return nil, dbError(err)
+		}
+
+		results[i] = domain.TaskUpsertResult{
+			Task:    row.toTask(),
+			Created: row.Inserted,
+		}
+	}
+
+	return results, nil
+}
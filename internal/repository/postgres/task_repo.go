@@ -12,10 +12,7 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/tingchima/gogolook/internal/domain"
 	"github.com/tingchima/gogolook/internal/domain/common"
-)
-
-var (
-	ErrNotFoundTask = errors.New("task not found")
+	"gopkg.in/guregu/null.v4"
 )
 
 // repoTask .
@@ -25,6 +22,14 @@ type repoTask struct {
 	Status    bool         `db:"status"`
 	CreatedAt time.Time    `db:"created_at"`
 	UpdatedAt sql.NullTime `db:"updated_at"`
+
+	DueAt       sql.NullTime `db:"due_at"`
+	RRule       string       `db:"rrule"`
+	Timezone    string       `db:"timezone"`
+	SeriesStart sql.NullTime `db:"series_start"`
+	Priority    int          `db:"priority"`
+
+	ExternalID sql.NullString `db:"external_id"`
 }
 
 // toTask convert repo struct to domain struct
@@ -36,6 +41,14 @@ func (row repoTask) toTask() domain.Task {
 		Status:    row.Status,
 		CreatedAt: row.CreatedAt,
 		UpdatedAt: row.UpdatedAt.Time,
+
+		DueAt:       null.NewTime(row.DueAt.Time, row.DueAt.Valid),
+		RRule:       row.RRule,
+		Timezone:    row.Timezone,
+		SeriesStart: null.NewTime(row.SeriesStart.Time, row.SeriesStart.Valid),
+		Priority:    row.Priority,
+
+		ExternalID: row.ExternalID.String,
 	}
 }
 
@@ -48,6 +61,14 @@ type repoFieldNameTask struct {
 	Status    string
 	CreatedAt string
 	UpdatedAt string
+
+	DueAt       string
+	RRule       string
+	Timezone    string
+	SeriesStart string
+	Priority    string
+
+	ExternalID string
 }
 
 var repoFieldTask = repoFieldNameTask{
@@ -56,6 +77,14 @@ var repoFieldTask = repoFieldNameTask{
 	Status:    "status",
 	CreatedAt: "created_at",
 	UpdatedAt: "updated_at",
+
+	DueAt:       "due_at",
+	RRule:       "rrule",
+	Timezone:    "timezone",
+	SeriesStart: "series_start",
+	Priority:    "priority",
+
+	ExternalID: "external_id",
 }
 
 func (r *repoFieldNameTask) fields() []string {
@@ -65,28 +94,51 @@ func (r *repoFieldNameTask) fields() []string {
 		r.Status,
 		r.CreatedAt,
 		r.UpdatedAt,
+		r.DueAt,
+		r.RRule,
+		r.Timezone,
+		r.SeriesStart,
+		r.Priority,
+		r.ExternalID,
 	}
 }
 
+// taskParamWheres builds the select tasks condition,
+// it should be kept consistent with domain.TaskParam.Match.
+func taskParamWheres(param domain.TaskParam) squirrel.And {
+
+	wheres := squirrel.And{}
+
+	if param.Status.Valid {
+		wheres = append(wheres, squirrel.Eq{repoFieldTask.Status: param.Status.Bool})
+	}
+
+	if param.Name != "" {
+		wheres = append(wheres, squirrel.ILike{repoFieldTask.Name: "%" + escapeLike(param.Name) + "%"})
+	}
+
+	return wheres
+}
+
 // 列出任務
 func (r *Postgres) ListTasks(ctx context.Context, param domain.TaskParam) ([]domain.Task, error) {
 
-	wheres := squirrel.And{
-		// implement select tasks condition
-	}
+	ctx, end := r.instrument(ctx, "ListTasks")
+	defer end()
 
 	query, args, err := r.stmtBuilder.Select(repoFieldTask.fields()...).
 		From(repoTableTask).
-		Where(wheres).
+		Where(taskParamWheres(param)).
+		OrderBy(repoFieldTask.ID).
 		ToSql()
 	if err != nil {
-		return nil, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return nil, dbError(err)
 	}
 
 	var rows []repoTask
 
-	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
-		return nil, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return nil, dbError(err)
 	}
 
 	tasks := make([]domain.Task, len(rows))
@@ -98,31 +150,118 @@ func (r *Postgres) ListTasks(ctx context.Context, param domain.TaskParam) ([]dom
 	return tasks, nil
 }
 
+// 透過ID取得任務
+func (r *Postgres) GetTaskByID(ctx context.Context, id int64) (*domain.Task, error) {
+
+	ctx, end := r.instrument(ctx, "GetTaskByID")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldTask.ID: id},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTask.fields()...).
+		From(repoTableTask).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var row repoTask
+
+	err = r.getContext(ctx, &row, query, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = domain.ErrNotFoundTask
+			return nil, common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+		}
+		return nil, dbError(err)
+	}
+
+	task := row.toTask()
+
+	return &task, nil
+}
+
+// 透過ID取得任務並鎖定至交易結束
+//
+// The row is locked by SELECT ... FOR UPDATE on the primary, it should be called by the txRepo
+// of WithTx, otherwise the lock is released right after the statement.
+func (r *Postgres) LockTaskByID(ctx context.Context, id int64) (*domain.Task, error) {
+
+	ctx, end := r.instrument(ctx, "LockTaskByID")
+	defer end()
+
+	where := squirrel.And{
+		squirrel.Eq{repoFieldTask.ID: id},
+	}
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTask.fields()...).
+		From(repoTableTask).
+		Where(where).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var row repoTask
+
+	err = r.conn().GetContext(ctx, &row, query, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = domain.ErrNotFoundTask
+			return nil, common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
+		}
+		return nil, dbError(err)
+	}
+
+	task := row.toTask()
+
+	return &task, nil
+}
+
 // 建立任務
 func (r *Postgres) CreateTask(ctx context.Context, param domain.Task) (*domain.Task, error) {
 
+	ctx, end := r.instrument(ctx, "CreateTask")
+	defer end()
+
 	insertBuilder := r.stmtBuilder.Insert(repoTableTask).Columns(
 		repoFieldTask.Name,
 		repoFieldTask.Status,
+		repoFieldTask.DueAt,
+		repoFieldTask.RRule,
+		repoFieldTask.Timezone,
+		repoFieldTask.SeriesStart,
+		repoFieldTask.Priority,
+		repoFieldTask.ExternalID,
 	)
 
 	insertBuilder = insertBuilder.Values(
 		param.Name,
 		param.Status,
+		param.DueAt,
+		param.RRule,
+		param.Timezone,
+		param.SeriesStart,
+		param.Priority,
+		nullString(param.ExternalID),
 	)
 
 	query, args, err := insertBuilder.
 		Suffix(fmt.Sprintf("returning %s", strings.Join(repoFieldTask.fields(), ", "))).
 		ToSql()
 	if err != nil {
-		return nil, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return nil, dbError(err)
 	}
 
 	var row repoTask
 
-	err = r.db.GetContext(ctx, &row, query, args...)
+	err = r.conn().GetContext(ctx, &row, query, args...)
 	if err != nil {
-		return nil, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return nil, dbError(err)
 	}
 
 	task := row.toTask()
@@ -133,14 +272,23 @@ func (r *Postgres) CreateTask(ctx context.Context, param domain.Task) (*domain.T
 // 修改任務
 func (r *Postgres) UpdateTask(ctx context.Context, param domain.Task) (*domain.Task, error) {
 
+	ctx, end := r.instrument(ctx, "UpdateTask")
+	defer end()
+
 	where := squirrel.And{
 		squirrel.Eq{repoFieldTask.ID: param.ID},
 	}
 
 	updates := map[string]any{
-		repoFieldTask.Name:      param.Name,
-		repoFieldTask.Status:    param.Status,
-		repoFieldTask.UpdatedAt: time.Now().UTC(),
+		repoFieldTask.Name:        param.Name,
+		repoFieldTask.Status:      param.Status,
+		repoFieldTask.UpdatedAt:   time.Now().UTC(),
+		repoFieldTask.DueAt:       param.DueAt,
+		repoFieldTask.RRule:       param.RRule,
+		repoFieldTask.Timezone:    param.Timezone,
+		repoFieldTask.SeriesStart: param.SeriesStart,
+		repoFieldTask.Priority:    param.Priority,
+		repoFieldTask.ExternalID:  nullString(param.ExternalID),
 	}
 
 	query, args, err := r.stmtBuilder.Update(repoTableTask).
@@ -149,18 +297,18 @@ func (r *Postgres) UpdateTask(ctx context.Context, param domain.Task) (*domain.T
 		Suffix(fmt.Sprintf("returning %s", strings.Join(repoFieldTask.fields(), ", "))).
 		ToSql()
 	if err != nil {
-		return nil, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return nil, dbError(err)
 	}
 
 	var row repoTask
 
-	err = r.db.GetContext(ctx, &row, query, args...)
+	err = r.conn().GetContext(ctx, &row, query, args...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			err = ErrNotFoundTask
+			err = domain.ErrNotFoundTask
 			return nil, common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
 		}
-		return nil, common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return nil, dbError(err)
 	}
 
 	task := row.toTask()
@@ -171,29 +319,68 @@ func (r *Postgres) UpdateTask(ctx context.Context, param domain.Task) (*domain.T
 // 透過ID刪除任務
 func (r *Postgres) DeleteTaskByID(ctx context.Context, id int64) error {
 
+	ctx, end := r.instrument(ctx, "DeleteTaskByID")
+	defer end()
+
 	where := squirrel.And{
 		squirrel.Eq{repoFieldTask.ID: id},
 	}
 
 	query, args, err := r.stmtBuilder.Delete(repoTableTask).Where(where).ToSql()
 	if err != nil {
-		return common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return dbError(err)
 	}
 
-	result, err := r.db.ExecContext(ctx, query, args...)
+	result, err := r.conn().ExecContext(ctx, query, args...)
 	if err != nil {
-		return common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return dbError(err)
 	}
 
 	affects, err := result.RowsAffected()
 	if err != nil {
-		return common.NewError(common.ErrCodeInternalProcess, err, common.WithMsg(err.Error()))
+		return dbError(err)
 	}
 
 	if affects == 0 {
-		err := ErrNotFoundTask
+		err := domain.ErrNotFoundTask
 		return common.NewError(common.ErrCodeResourceNotFound, err, common.WithMsg(err.Error()))
 	}
 
 	return nil
 }
+
+// 統計未完成與已完成的任務數量
+func (r *Postgres) CountTasks(ctx context.Context) (domain.TaskCount, error) {
+
+	ctx, end := r.instrument(ctx, "CountTasks")
+	defer end()
+
+	var count domain.TaskCount
+
+	query, args, err := r.stmtBuilder.Select(repoFieldTask.Status, "COUNT(*) AS count").
+		From(repoTableTask).
+		GroupBy(repoFieldTask.Status).
+		ToSql()
+	if err != nil {
+		return count, dbError(err)
+	}
+
+	var rows []struct {
+		Status bool  `db:"status"`
+		Count  int64 `db:"count"`
+	}
+
+	if err = r.selectContext(ctx, &rows, query, args...); err != nil {
+		return count, dbError(err)
+	}
+
+	for i := range rows {
+		if rows[i].Status {
+			count.Completed = rows[i].Count
+		} else {
+			count.Open = rows[i].Count
+		}
+	}
+
+	return count, nil
+}
@@ -3,12 +3,17 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/go-faker/faker/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/application/task"
 	"github.com/tingchima/gogolook/internal/domain"
+	"github.com/tingchima/gogolook/internal/domain/common"
 	"github.com/tingchima/gogolook/testdata"
 )
 
@@ -26,6 +31,25 @@ func TestTaskRepo_ListTasks(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestTaskRepo_GetTaskByID .
+func TestTaskRepo_GetTaskByID(t *testing.T) {
+
+	conn := getTestDBConn()
+
+	err := setupTestData(conn, testdata.Path(testdata.TestDataTasks))
+	require.NoError(t, err)
+
+	repo := NewRepository(conn)
+
+	got, err := repo.GetTaskByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.ID)
+
+	_, err = repo.GetTaskByID(context.Background(), 0)
+	require.Error(t, err)
+	assert.True(t, common.IsErrCode(err, common.ErrCodeResourceNotFound))
+}
+
 // TestTaskRepo_CreateTask .
 func TestTaskRepo_CreateTask(t *testing.T) {
 
@@ -100,3 +124,152 @@ func TestTaskRepo_DeleteTask(t *testing.T) {
 	err = repo.DeleteTaskByID(context.Background(), createdTask.ID)
 	require.NoError(t, err)
 }
+
+// TestTaskRepo_CountTasks .
+func TestTaskRepo_CountTasks(t *testing.T) {
+
+	conn := getTestDBConn()
+
+	err := setupTestData(conn, testdata.Path(testdata.TestDataTasks))
+	require.NoError(t, err)
+
+	var observed []string
+
+	repo := NewRepository(conn, WithQueryObserver(func(method string, _ time.Duration) {
+		observed = append(observed, method)
+	}))
+
+	tasks, err := repo.ListTasks(context.Background(), domain.TaskParam{})
+	require.NoError(t, err)
+
+	got, err := repo.CountTasks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(tasks)), got.Open+got.Completed)
+
+	assert.Equal(t, []string{"ListTasks", "CountTasks"}, observed)
+}
+
+// TestTaskRepo_WithTx .
+func TestTaskRepo_WithTx(t *testing.T) {
+
+	ctx := context.Background()
+
+	repo := NewRepository(getTestDBConn())
+
+	errRollback := errors.New("rollback")
+
+	var committedID, rolledBackID, savepointID int64
+
+	err := repo.WithTx(ctx, func(txRepo task.Repository) error {
+
+		created, err := txRepo.CreateTask(ctx, domain.Task{Name: "committed"})
+		if err != nil {
+			return err
+		}
+		committedID = created.ID
+
+		// the transaction reads its own writes
+		_, err = txRepo.GetTaskByID(ctx, committedID)
+		if err != nil {
+			return err
+		}
+
+		// only the savepoint is rolled back
+		err = txRepo.WithTx(ctx, func(nestedRepo task.Repository) error {
+			created, err := nestedRepo.CreateTask(ctx, domain.Task{Name: "savepoint"})
+			if err != nil {
+				return err
+			}
+			savepointID = created.ID
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = repo.WithTx(ctx, func(txRepo task.Repository) error {
+		created, err := txRepo.CreateTask(ctx, domain.Task{Name: "rolled back"})
+		if err != nil {
+			return err
+		}
+		rolledBackID = created.ID
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+
+	_, err = repo.GetTaskByID(ctx, committedID)
+	require.NoError(t, err)
+
+	for _, id := range []int64{savepointID, rolledBackID} {
+		_, err = repo.GetTaskByID(ctx, id)
+		assert.True(t, common.IsErrCode(err, common.ErrCodeResourceNotFound))
+	}
+}
+
+// TestTaskRepo_LockTaskByID .
+func TestTaskRepo_LockTaskByID(t *testing.T) {
+
+	ctx := context.Background()
+
+	repo := NewRepository(getTestDBConn())
+
+	created, err := repo.CreateTask(ctx, domain.Task{Name: "locked"})
+	require.NoError(t, err)
+
+	locked := make(chan struct{})
+	done := make(chan *domain.Task)
+
+	go func() {
+		<-locked
+
+		var got *domain.Task
+
+		// blocked until the first transaction commits, then reads its write
+		_ = repo.WithTx(ctx, func(txRepo task.Repository) error {
+			var err error
+			got, err = txRepo.LockTaskByID(ctx, created.ID)
+			return err
+		})
+
+		done <- got
+	}()
+
+	err = repo.WithTx(ctx, func(txRepo task.Repository) error {
+		lockedTask, err := txRepo.LockTaskByID(ctx, created.ID)
+		if err != nil {
+			return err
+		}
+		close(locked)
+
+		time.Sleep(50 * time.Millisecond)
+
+		lockedTask.Status = true
+		_, err = txRepo.UpdateTask(ctx, *lockedTask)
+		return err
+	})
+	require.NoError(t, err)
+
+	got := <-done
+	require.NotNil(t, got)
+	assert.True(t, got.Status)
+}
+
+// TestTaskRepo_WithTx_Isolation .
+func TestTaskRepo_WithTx_Isolation(t *testing.T) {
+
+	ctx := context.Background()
+
+	repo := NewRepository(getTestDBConn())
+
+	err := repo.WithTx(ctx, func(txRepo task.Repository) error {
+		var level string
+		if err := txRepo.(*Postgres).conn().GetContext(ctx, &level, "SHOW transaction_isolation"); err != nil {
+			return err
+		}
+		assert.Equal(t, "serializable", level)
+		return nil
+	}, task.WithIsolation(sql.LevelSerializable))
+	require.NoError(t, err)
+}
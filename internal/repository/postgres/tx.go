@@ -0,0 +1,173 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tingchima/gogolook/internal/application/task"
+)
+
+// transaction retry
+const (
+	// maxTxAttempts .
+	maxTxAttempts = 3
+	// txRetryDelay is multiplied by the attempt.
+	txRetryDelay = 20 * time.Millisecond
+)
+
+// retryable transaction errors
+const (
+	pqCodeDeadlockDetected = "40P01"
+)
+
+// dbConn is satisfied by *sqlx.DB and *sqlx.Tx.
+type dbConn interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// conn returns the transaction if the repository is bound to one, otherwise the primary.
+func (r *Postgres) conn() dbConn {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// WithTx runs fn in a transaction, it is committed if fn returns nil, otherwise rolled back.
+//
+// The WithTx of txRepo runs fn in a savepoint, which is rolled back alone if fn fails, the options
+// of outer transaction are kept. The outermost transaction is run again on the serialization
+// failure or deadlock, so fn should not have side effects other than txRepo.
+func (r *Postgres) WithTx(ctx context.Context, fn func(txRepo task.Repository) error, opts ...task.TxOption) error {
+
+	var options task.TxOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return r.withTx(ctx, &sql.TxOptions{Isolation: options.Isolation}, func(txRepo *Postgres) error {
+		return fn(txRepo)
+	})
+}
+
+// withTx runs fn in a transaction of opts, or in a savepoint if r is bound to a transaction.
+func (r *Postgres) withTx(ctx context.Context, opts *sql.TxOptions, fn func(txRepo *Postgres) error) error {
+
+	if r.tx != nil {
+		return r.withSavepoint(ctx, fn)
+	}
+
+	return retryTx(ctx, func() error {
+		return r.runTx(ctx, opts, fn)
+	})
+}
+
+// retryTx runs the transaction again on the retryable errors, at most maxTxAttempts times.
+func retryTx(ctx context.Context, run func() error) error {
+
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= maxTxAttempts || !isRetryableTxErr(err) {
+			return err
+		}
+
+		slog.DebugContext(ctx, "retry postgres transaction", "attempt", attempt, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txRetryDelay * time.Duration(attempt)):
+		}
+	}
+}
+
+// runTx .
+func (r *Postgres) runTx(ctx context.Context, opts *sql.TxOptions, fn func(txRepo *Postgres) error) error {
+
+	tx, err := r.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return dbError(err)
+	}
+
+	committed := false
+	defer func() {
+		// also on panic
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(r.bind(tx, 0)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return dbError(err)
+	}
+	committed = true
+
+	return nil
+}
+
+// withSavepoint .
+func (r *Postgres) withSavepoint(ctx context.Context, fn func(txRepo *Postgres) error) error {
+
+	txRepo := r.bind(r.tx, r.txDepth+1)
+	savepoint := "sp_" + strconv.Itoa(txRepo.txDepth)
+
+	if _, err := r.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return dbError(err)
+	}
+
+	released := false
+	defer func() {
+		// also on panic, the outer transaction can still be used
+		if !released {
+			_, _ = r.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+savepoint)
+		}
+	}()
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if _, err := r.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return dbError(err)
+	}
+	released = true
+
+	return nil
+}
+
+// bind returns the repository of transaction tx, depth is the number of savepoints.
+func (r *Postgres) bind(tx *sqlx.Tx, depth int) *Postgres {
+	return &Postgres{
+		db:            r.db,
+		stmtBuilder:   r.stmtBuilder,
+		queryObserver: r.queryObserver,
+		now:           r.now,
+		tx:            tx,
+		txDepth:       depth,
+	}
+}
+
+// isRetryableTxErr reports whether the transaction may succeed if it is run again.
+func isRetryableTxErr(err error) bool {
+
+	// common.Error unwraps to the driver error
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == pqCodeSerializationFailure || pqErr.Code == pqCodeDeadlockDetected
+}
@@ -0,0 +1,70 @@
+// Package postgres provides
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/tingchima/gogolook/internal/domain/common"
+)
+
+// TestIsRetryableTxErr .
+func TestIsRetryableTxErr(t *testing.T) {
+	t.Parallel()
+
+	serialization := &pq.Error{Code: pqCodeSerializationFailure}
+
+	assert.True(t, isRetryableTxErr(serialization))
+	assert.True(t, isRetryableTxErr(&pq.Error{Code: pqCodeDeadlockDetected}))
+	assert.True(t, isRetryableTxErr(common.NewError(common.ErrCodeInternalProcess, serialization)))
+	assert.False(t, isRetryableTxErr(&pq.Error{Code: "23505"}))
+	assert.False(t, isRetryableTxErr(driver.ErrBadConn))
+}
+
+// TestRetryTx .
+func TestRetryTx(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		errs     []error
+		wantRuns int
+		wantErr  bool
+	}{
+		{
+			name:     "serialization failure is retried",
+			errs:     []error{dbError(&pq.Error{Code: pqCodeSerializationFailure}), nil},
+			wantRuns: 2,
+		},
+		{
+			name:     "deadlock is retried at most max attempts",
+			errs:     []error{&pq.Error{Code: pqCodeDeadlockDetected}, &pq.Error{Code: pqCodeDeadlockDetected}, &pq.Error{Code: pqCodeDeadlockDetected}, nil},
+			wantRuns: maxTxAttempts,
+			wantErr:  true,
+		},
+		{
+			name:     "other error is not retried",
+			errs:     []error{dbError(&pq.Error{Code: pqCodeUniqueViolation}), nil},
+			wantRuns: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+
+			err := retryTx(context.Background(), func() error {
+				err := tt.errs[runs]
+				runs++
+				return err
+			})
+
+			assert.Equal(t, tt.wantRuns, runs)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Package taskio provides the encoders and decoders of task import/export formats.
+package taskio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// csv columns
+const (
+	csvColumnID         = "id"
+	csvColumnExternalID = "external_id"
+	csvColumnName       = "name"
+	csvColumnStatus     = "status"
+	csvColumnDueAt      = "due_at"
+	csvColumnRRule      = "rrule"
+	csvColumnTimezone   = "timezone"
+	csvColumnPriority   = "priority"
+	csvColumnCreatedAt  = "created_at"
+	csvColumnUpdatedAt  = "updated_at"
+)
+
+var csvHeader = []string{
+	csvColumnID,
+	csvColumnExternalID,
+	csvColumnName,
+	csvColumnStatus,
+	csvColumnDueAt,
+	csvColumnRRule,
+	csvColumnTimezone,
+	csvColumnPriority,
+	csvColumnCreatedAt,
+	csvColumnUpdatedAt,
+}
+
+// csvFlushRows is the number of rows buffered before flushing to the underlying writer.
+const csvFlushRows = 100
+
+// csvEncoder .
+type csvEncoder struct {
+	w       *csv.Writer
+	rows    int
+	started bool
+}
+
+// newCSVEncoder .
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+// Encode .
+func (e *csvEncoder) Encode(task domain.Task) error {
+
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	err := e.w.Write([]string{
+		strconv.FormatInt(task.ID, 10),
+		task.ExternalID,
+		task.Name,
+		formatStatus(task.Status),
+		formatTime(task.DueAt),
+		task.RRule,
+		task.Timezone,
+		strconv.Itoa(task.Priority),
+		task.CreatedAt.Format(time.RFC3339),
+		formatUpdatedAt(task.UpdatedAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%csvFlushRows == 0 {
+		e.w.Flush()
+	}
+
+	return e.w.Error()
+}
+
+// Close .
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// writeHeader .
+func (e *csvEncoder) writeHeader() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	return e.w.Write(csvHeader)
+}
+
+// formatUpdatedAt leaves the zero time, which means never updated, empty.
+func formatUpdatedAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// csvError wraps the malformed csv in ErrInvalidFile, the read errors of r are returned as is.
+func csvError(err error) error {
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: line %d: %w", ErrInvalidFile, parseErr.Line, parseErr.Err)
+	}
+
+	return err
+}
+
+// decodeCSV reads the header first, the columns are matched by name and the unknown columns are ignored.
+func decodeCSV(r io.Reader, maxRows int) ([]domain.TaskImportRow, []RowError, error) {
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("%w: the csv header is missing", ErrInvalidFile)
+		}
+		return nil, nil, csvError(err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i := range header {
+		// strip the BOM written by spreadsheets
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+		columns[name] = i
+	}
+
+	if _, ok := columns[csvColumnName]; !ok {
+		return nil, nil, fmt.Errorf("%w: the csv header should contain the %q column", ErrInvalidFile, csvColumnName)
+	}
+
+	var (
+		rows    []domain.TaskImportRow
+		rowErrs []RowError
+	)
+
+	for rowNum := 1; ; rowNum++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if rowNum > maxRows {
+			return nil, nil, fmt.Errorf("%w, at most %d rows", ErrTooManyRows, maxRows)
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				rowErrs = append(rowErrs, RowError{Row: rowNum, Message: "wrong number of fields"})
+				continue
+			}
+			return nil, nil, csvError(err)
+		}
+
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+
+		task := domain.Task{
+			ExternalID: strings.TrimSpace(value(csvColumnExternalID)),
+			Name:       value(csvColumnName),
+			RRule:      strings.TrimSpace(value(csvColumnRRule)),
+			Timezone:   strings.TrimSpace(value(csvColumnTimezone)),
+		}
+
+		valid := true
+
+		task.Status, err = parseStatus(value(csvColumnStatus))
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Field: csvColumnStatus, Message: err.Error()})
+			valid = false
+		}
+
+		task.DueAt, err = parseTime(value(csvColumnDueAt))
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Field: csvColumnDueAt, Message: err.Error()})
+			valid = false
+		}
+
+		task.Priority, err = parsePriority(value(csvColumnPriority))
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Field: csvColumnPriority, Message: err.Error()})
+			valid = false
+		}
+
+		if valid {
+			rows = append(rows, domain.TaskImportRow{Row: rowNum, Task: task})
+		}
+	}
+
+	return rows, rowErrs, nil
+}
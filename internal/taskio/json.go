@@ -0,0 +1,248 @@
+// Package taskio provides the encoders and decoders of task import/export formats.
+package taskio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// jsonRecord is the task object of json and ndjson.
+type jsonRecord struct {
+	ID         int64      `json:"id,omitempty"`
+	ExternalID string     `json:"external_id,omitempty"`
+	Name       string     `json:"name"`
+	Status     jsonStatus `json:"status"`
+	DueAt      null.Time  `json:"due_at"`
+	RRule      string     `json:"rrule,omitempty"`
+	Timezone   string     `json:"timezone,omitempty"`
+	Priority   int        `json:"priority,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// jsonStatus accepts both boolean and 0/1 as README.
+type jsonStatus bool
+
+// UnmarshalJSON .
+func (s *jsonStatus) UnmarshalJSON(data []byte) error {
+	status, err := parseStatus(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*s = jsonStatus(status)
+	return nil
+}
+
+// newJSONRecord .
+func newJSONRecord(task domain.Task) jsonRecord {
+	record := jsonRecord{
+		ID:         task.ID,
+		ExternalID: task.ExternalID,
+		Name:       task.Name,
+		Status:     jsonStatus(task.Status),
+		DueAt:      task.DueAt,
+		RRule:      task.RRule,
+		Timezone:   task.Timezone,
+		Priority:   task.Priority,
+	}
+	if !task.CreatedAt.IsZero() {
+		record.CreatedAt = &task.CreatedAt
+	}
+	if !task.UpdatedAt.IsZero() {
+		record.UpdatedAt = &task.UpdatedAt
+	}
+	return record
+}
+
+// toTask .
+func (r jsonRecord) toTask() domain.Task {
+	return domain.Task{
+		ExternalID: strings.TrimSpace(r.ExternalID),
+		Name:       r.Name,
+		Status:     bool(r.Status),
+		DueAt:      r.DueAt,
+		RRule:      strings.TrimSpace(r.RRule),
+		Timezone:   strings.TrimSpace(r.Timezone),
+		Priority:   r.Priority,
+	}
+}
+
+// jsonEncoder writes a json array element by element.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+// newJSONEncoder .
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	return &jsonEncoder{w: w}
+}
+
+// Encode .
+func (e *jsonEncoder) Encode(task domain.Task) error {
+
+	data, err := json.Marshal(newJSONRecord(task))
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n"
+	if e.count == 0 {
+		prefix = "[\n"
+	}
+	e.count++
+
+	if _, err = io.WriteString(e.w, prefix); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+// Close .
+func (e *jsonEncoder) Close() error {
+	suffix := "\n]\n"
+	if e.count == 0 {
+		suffix = "[]\n"
+	}
+	_, err := io.WriteString(e.w, suffix)
+	return err
+}
+
+// ndjsonEncoder writes a json object per line.
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+// newNDJSONEncoder .
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}
+}
+
+// Encode .
+func (e *ndjsonEncoder) Encode(task domain.Task) error {
+	return e.enc.Encode(newJSONRecord(task))
+}
+
+// Close .
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// decodeJSONRecord decodes a row, the type errors are reported as RowError.
+func decodeJSONRecord(rowNum int, data []byte) (domain.TaskImportRow, *RowError) {
+
+	var record jsonRecord
+
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		rowErr := RowError{Row: rowNum, Message: err.Error()}
+
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			rowErr.Field = typeErr.Field
+			rowErr.Message = fmt.Sprintf("should be %s", typeErr.Type)
+		}
+
+		return domain.TaskImportRow{}, &rowErr
+	}
+
+	return domain.TaskImportRow{Row: rowNum, Task: record.toTask()}, nil
+}
+
+// decodeJSON reads a json array of task objects.
+func decodeJSON(r io.Reader, maxRows int) ([]domain.TaskImportRow, []RowError, error) {
+
+	dec := json.NewDecoder(r)
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid json: %w", ErrInvalidFile, err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, nil, fmt.Errorf("%w: invalid json: should be an array of tasks", ErrInvalidFile)
+	}
+
+	var (
+		rows    []domain.TaskImportRow
+		rowErrs []RowError
+	)
+
+	for rowNum := 1; dec.More(); rowNum++ {
+		if rowNum > maxRows {
+			return nil, nil, fmt.Errorf("%w, at most %d rows", ErrTooManyRows, maxRows)
+		}
+
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid json: %w", ErrInvalidFile, err)
+		}
+
+		row, rowErr := decodeJSONRecord(rowNum, raw)
+		if rowErr != nil {
+			rowErrs = append(rowErrs, *rowErr)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if _, err = dec.Token(); err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid json: %w", ErrInvalidFile, err)
+	}
+
+	return rows, rowErrs, nil
+}
+
+// ndjsonMaxLineSize .
+const ndjsonMaxLineSize = 1 << 20
+
+// decodeNDJSON reads a task object per line, the blank lines are skipped.
+func decodeNDJSON(r io.Reader, maxRows int) ([]domain.TaskImportRow, []RowError, error) {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), ndjsonMaxLineSize)
+
+	var (
+		rows    []domain.TaskImportRow
+		rowErrs []RowError
+		rowNum  int
+	)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		rowNum++
+		if rowNum > maxRows {
+			return nil, nil, fmt.Errorf("%w, at most %d rows", ErrTooManyRows, maxRows)
+		}
+
+		row, rowErr := decodeJSONRecord(rowNum, line)
+		if rowErr != nil {
+			rowErrs = append(rowErrs, *rowErr)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, nil, fmt.Errorf("%w: the line is too long, at most %d bytes", ErrInvalidFile, ndjsonMaxLineSize)
+		}
+		return nil, nil, err
+	}
+
+	return rows, rowErrs, nil
+}
@@ -0,0 +1,83 @@
+// Package taskio provides the encoders and decoders of task import/export formats.
+package taskio
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/tingchima/gogolook/internal/domain"
+)
+
+// markdownItemPattern matches the task list items, e.g. "- [ ] item", "* [x] item" and "1. [ ] item".
+var markdownItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\](?:\s+(.*))?$`)
+
+// markdownEncoder writes a task list item per task, the item text is the same as todo.txt,
+// e.g. "- [x] (A) pay rent +home due:2024-01-01".
+type markdownEncoder struct {
+	w *bufio.Writer
+}
+
+// newMarkdownEncoder .
+func newMarkdownEncoder(w io.Writer) *markdownEncoder {
+	return &markdownEncoder{w: bufio.NewWriter(w)}
+}
+
+// Encode .
+func (e *markdownEncoder) Encode(task domain.Task) error {
+
+	parts := []string{"- [ ]"}
+	if task.Status {
+		parts[0] = "- [x]"
+	}
+
+	if priority := formatTodoPriority(task.Priority); priority != "" {
+		parts = append(parts, "("+priority+")")
+	}
+
+	parts = append(parts, formatTodoText(task, false))
+
+	_, err := e.w.WriteString(strings.Join(parts, " ") + "\n")
+	return err
+}
+
+// Close .
+func (e *markdownEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// parseMarkdownLine reports whether the line is a task list item.
+func parseMarkdownLine(line string) (domain.Task, []RowError, bool) {
+
+	matches := markdownItemPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return domain.Task{}, nil, false
+	}
+
+	task := domain.Task{Status: matches[1] != " "}
+
+	var rowErrs []RowError
+
+	words := strings.Fields(matches[2])
+	if len(words) > 0 {
+		if priority := todoPriorityPattern.FindStringSubmatch(words[0]); priority != nil {
+			var err error
+			task.Priority, err = parseTodoPriority(priority[1])
+			if err != nil {
+				rowErrs = append(rowErrs, RowError{Field: todoTagPriority, Message: err.Error()})
+			}
+			words = words[1:]
+		}
+	}
+
+	rowErrs = append(rowErrs, parseTodoText(words, &task)...)
+
+	return task, rowErrs, true
+}
+
+// decodeMarkdown reads the task list items, the other lines such as headings are skipped
+// and the rows are the line numbers.
+func decodeMarkdown(r io.Reader, maxRows int) ([]domain.TaskImportRow, []RowError, error) {
+	return decodeTextLines(r, maxRows, parseMarkdownLine)
+}
@@ -0,0 +1,185 @@
+// Package taskio provides the encoders and decoders of task import/export formats.
+package taskio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// Format .
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+	// FormatTodoTxt is todo.txt, see https://github.com/todotxt/todo.txt
+	FormatTodoTxt Format = "todotxt"
+	// FormatMarkdown is the Markdown task list, e.g. "- [ ] item"
+	FormatMarkdown Format = "markdown"
+)
+
+// ErrUnsupportedFormat .
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// ParseFormat accepts the format names and the file extensions.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSON, FormatNDJSON, FormatTodoTxt, FormatMarkdown:
+		return f, nil
+	case "txt", "todo.txt":
+		return FormatTodoTxt, nil
+	case "md":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, s)
+}
+
+// ContentType .
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatTodoTxt:
+		return "text/plain; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Extension is the file extension without dot.
+func (f Format) Extension() string {
+	switch f {
+	case FormatTodoTxt:
+		return "txt"
+	case FormatMarkdown:
+		return "md"
+	}
+	return string(f)
+}
+
+// Encoder writes tasks one by one, Close should be called after the last task.
+type Encoder interface {
+	Encode(task domain.Task) error
+	Close() error
+}
+
+// NewEncoder .
+func NewEncoder(format Format, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatJSON:
+		return newJSONEncoder(w), nil
+	case FormatNDJSON:
+		return newNDJSONEncoder(w), nil
+	case FormatTodoTxt:
+		return newTodoTxtEncoder(w), nil
+	case FormatMarkdown:
+		return newMarkdownEncoder(w), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// RowError is the error of a row which can not be decoded,
+// the other rows are still decoded.
+type RowError struct {
+	// 資料列序號, 從 1 開始
+	Row     int
+	Field   string
+	Message string
+}
+
+// Error .
+func (e RowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("row %d: %s: %s", e.Row, e.Field, e.Message)
+}
+
+// ErrTooManyRows .
+var ErrTooManyRows = errors.New("too many rows")
+
+// ErrInvalidFile is returned when the whole input is malformed, the message only describes the input.
+var ErrInvalidFile = errors.New("invalid file")
+
+// Decode reads all rows of r, at most maxRows rows are accepted.
+// The rows which can not be decoded are reported in rowErrs,
+// err is returned when the whole input is malformed.
+func Decode(format Format, r io.Reader, maxRows int) (rows []domain.TaskImportRow, rowErrs []RowError, err error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r, maxRows)
+	case FormatJSON:
+		return decodeJSON(r, maxRows)
+	case FormatNDJSON:
+		return decodeNDJSON(r, maxRows)
+	case FormatTodoTxt:
+		return decodeTodoTxt(r, maxRows)
+	case FormatMarkdown:
+		return decodeMarkdown(r, maxRows)
+	}
+	return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// parseStatus accepts 0/1 as README and true/false.
+func parseStatus(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0", "false":
+		return false, nil
+	case "1", "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid status %q, should be 0, 1, true or false", s)
+}
+
+// parseTime accepts RFC 3339 or empty.
+func parseTime(s string) (null.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return null.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return null.Time{}, fmt.Errorf("invalid time %q, should be RFC 3339", s)
+	}
+	return null.TimeFrom(t), nil
+}
+
+// parsePriority accepts an integer or empty.
+func parsePriority(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	priority, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority %q, should be an integer", s)
+	}
+	return priority, nil
+}
+
+// formatTime .
+func formatTime(t null.Time) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+// formatStatus .
+func formatStatus(status bool) string {
+	return strconv.FormatBool(status)
+}
@@ -0,0 +1,385 @@
+// Package taskio provides the encoders and decoders of task import/export formats.
+package taskio
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestRoundTrip .
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tasks := []domain.Task{
+		{
+			ID:         1,
+			ExternalID: "ext-1",
+			Name:       "pay rent, \"monthly\"",
+			Status:     false,
+			DueAt:      null.TimeFrom(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)),
+			RRule:      "FREQ=MONTHLY;BYMONTHDAY=1",
+			Timezone:   "Asia/Taipei",
+			CreatedAt:  time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        2,
+			Name:      "多行\n任務",
+			Status:    true,
+			CreatedAt: time.Date(2023, 12, 2, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2023, 12, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, format := range []Format{FormatCSV, FormatJSON, FormatNDJSON} {
+		format := format
+
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			enc, err := NewEncoder(format, &buf)
+			require.NoError(t, err)
+
+			for i := range tasks {
+				require.NoError(t, enc.Encode(tasks[i]))
+			}
+			require.NoError(t, enc.Close())
+
+			rows, rowErrs, err := Decode(format, &buf, 10)
+			require.NoError(t, err)
+			require.Empty(t, rowErrs)
+			require.Len(t, rows, len(tasks))
+
+			for i := range rows {
+				assert.Equal(t, i+1, rows[i].Row)
+				assert.Equal(t, tasks[i].ExternalID, rows[i].Task.ExternalID)
+				assert.Equal(t, tasks[i].Name, rows[i].Task.Name)
+				assert.Equal(t, tasks[i].Status, rows[i].Task.Status)
+				assert.Equal(t, tasks[i].DueAt.Valid, rows[i].Task.DueAt.Valid)
+				assert.True(t, tasks[i].DueAt.Time.Equal(rows[i].Task.DueAt.Time))
+				assert.Equal(t, tasks[i].RRule, rows[i].Task.RRule)
+				assert.Equal(t, tasks[i].Timezone, rows[i].Task.Timezone)
+			}
+		})
+	}
+}
+
+// TestRoundTrip_Text .
+func TestRoundTrip_Text(t *testing.T) {
+	t.Parallel()
+
+	tasks := []domain.Task{
+		{
+			ID:         1,
+			ExternalID: "ext-1",
+			Name:       "pay rent +home @bank http://bank.example",
+			DueAt:      null.TimeFrom(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)),
+			RRule:      "FREQ=MONTHLY;BYMONTHDAY=1",
+			Timezone:   "Asia/Taipei",
+			Priority:   1,
+			CreatedAt:  time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        2,
+			Name:      "x-ray 2024-01-01 @clinic",
+			Status:    true,
+			DueAt:     null.TimeFrom(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+			Priority:  9,
+			CreatedAt: time.Date(2023, 12, 2, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2023, 12, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:       3,
+			Name:     "任務 +專案",
+			DueAt:    null.TimeFrom(time.Date(2024, 1, 2, 16, 0, 0, 0, time.UTC)),
+			Timezone: "Asia/Taipei",
+		},
+	}
+
+	for _, format := range []Format{FormatTodoTxt, FormatMarkdown} {
+		format := format
+
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			enc, err := NewEncoder(format, &buf)
+			require.NoError(t, err)
+
+			for i := range tasks {
+				require.NoError(t, enc.Encode(tasks[i]))
+			}
+			require.NoError(t, enc.Close())
+
+			rows, rowErrs, err := Decode(format, &buf, 10)
+			require.NoError(t, err)
+			require.Empty(t, rowErrs)
+			require.Len(t, rows, len(tasks))
+
+			for i := range rows {
+				assert.Equal(t, i+1, rows[i].Row)
+				assert.Equal(t, tasks[i].ExternalID, rows[i].Task.ExternalID)
+				assert.Equal(t, tasks[i].Name, rows[i].Task.Name)
+				assert.Equal(t, tasks[i].Status, rows[i].Task.Status)
+				assert.Equal(t, tasks[i].Priority, rows[i].Task.Priority)
+				assert.Equal(t, tasks[i].DueAt.Valid, rows[i].Task.DueAt.Valid)
+				assert.True(t, tasks[i].DueAt.Time.Equal(rows[i].Task.DueAt.Time))
+				assert.Equal(t, tasks[i].RRule, rows[i].Task.RRule)
+				assert.Equal(t, tasks[i].Timezone, rows[i].Task.Timezone)
+			}
+		})
+	}
+}
+
+// todoName is the task name generated from the fragments, which collide with the todo.txt syntax.
+type todoName string
+
+// todoNameFragments .
+var todoNameFragments = []string{
+	"a", "任務", " ", "  ", "\t", "\n", "\r\n", "\u00a0", "\u3000", "\\", `\s`, `\u0041`, ":",
+	"x", "(A)", "(z)", "2024-01-01", "due:2024-01-01", "pri:A", "rrule:FREQ=DAILY", "tz:UTC", "uid:1",
+	"due:", "+home", "@phone", "- [ ]",
+}
+
+// Generate .
+func (todoName) Generate(rand *rand.Rand, size int) reflect.Value {
+
+	var b strings.Builder
+	for n := rand.Intn(size) + 1; n > 0; n-- {
+		b.WriteString(todoNameFragments[rand.Intn(len(todoNameFragments))])
+	}
+
+	return reflect.ValueOf(todoName(b.String()))
+}
+
+// TestRoundTrip_TextName .
+func TestRoundTrip_TextName(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []Format{FormatTodoTxt, FormatMarkdown} {
+		format := format
+
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+
+			roundTrip := func(name todoName, status bool, priority uint8, created bool) bool {
+				task := domain.Task{
+					Name:       string(name),
+					Status:     status,
+					Priority:   int(priority % 10),
+					ExternalID: string(name),
+				}
+				if created {
+					task.CreatedAt = time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+				}
+
+				var buf bytes.Buffer
+
+				enc, err := NewEncoder(format, &buf)
+				require.NoError(t, err)
+				require.NoError(t, enc.Encode(task))
+				require.NoError(t, enc.Close())
+
+				rows, rowErrs, err := Decode(format, &buf, 10)
+				if err != nil || len(rowErrs) > 0 || len(rows) != 1 {
+					return false
+				}
+
+				return rows[0].Task.Name == task.Name &&
+					rows[0].Task.ExternalID == task.ExternalID &&
+					rows[0].Task.Status == task.Status &&
+					rows[0].Task.Priority == task.Priority
+			}
+
+			assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 2000}))
+		})
+	}
+}
+
+// TestEncode_Text .
+func TestEncode_Text(t *testing.T) {
+	t.Parallel()
+
+	tasks := []domain.Task{
+		{
+			Name:      "call mom +family @phone",
+			Priority:  1,
+			DueAt:     null.TimeFrom(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)),
+			CreatedAt: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:      "file taxes\nbefore April",
+			Status:    true,
+			Priority:  2,
+			CreatedAt: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format:   FormatTodoTxt,
+			expected: "(A) 2024-01-01 call mom +family @phone due:2024-01-05\nx 2024-01-03 2024-01-01 file taxes\\nbefore April pri:B\n",
+		},
+		{
+			format:   FormatMarkdown,
+			expected: "- [ ] (A) call mom +family @phone due:2024-01-05\n- [x] (B) file taxes\\nbefore April\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		enc, err := NewEncoder(tt.format, &buf)
+		require.NoError(t, err)
+
+		for i := range tasks {
+			require.NoError(t, enc.Encode(tasks[i]))
+		}
+		require.NoError(t, enc.Close())
+
+		assert.Equal(t, tt.expected, buf.String(), tt.format)
+	}
+}
+
+// TestEncode_Empty .
+func TestEncode_Empty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{format: FormatCSV, expected: strings.Join(csvHeader, ",") + "\n"},
+		{format: FormatJSON, expected: "[]\n"},
+		{format: FormatNDJSON, expected: ""},
+		{format: FormatTodoTxt, expected: ""},
+		{format: FormatMarkdown, expected: ""},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		enc, err := NewEncoder(tt.format, &buf)
+		require.NoError(t, err)
+		require.NoError(t, enc.Close())
+
+		assert.Equal(t, tt.expected, buf.String(), tt.format)
+	}
+}
+
+// TestDecode .
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		format          Format
+		input           string
+		maxRows         int
+		wantErr         error
+		expectedRows    int
+		expectedRowErrs []string
+	}{
+		{
+			name:         "csv with BOM and partial columns",
+			format:       FormatCSV,
+			input:        "\ufeffName,Status\nfirst,1\nsecond,0\n",
+			maxRows:      10,
+			expectedRows: 2,
+		},
+		{
+			name:            "csv invalid fields",
+			format:          FormatCSV,
+			input:           "name,status,due_at\nfirst,yes,\nsecond,1,tomorrow\nthird\n",
+			maxRows:         10,
+			expectedRowErrs: []string{"row 1: status: invalid status \"yes\", should be 0, 1, true or false", "row 2: due_at: invalid time \"tomorrow\", should be RFC 3339", "row 3: wrong number of fields"},
+		},
+		{
+			name:    "csv too many rows",
+			format:  FormatCSV,
+			input:   "name\na\nb\nc\n",
+			maxRows: 2,
+			wantErr: ErrTooManyRows,
+		},
+		{
+			name:            "json invalid type",
+			format:          FormatJSON,
+			input:           `[{"name":"first","status":1},{"name":2}]`,
+			maxRows:         10,
+			expectedRows:    1,
+			expectedRowErrs: []string{"row 2: name: should be string"},
+		},
+		{
+			name:         "ndjson skips blank lines",
+			format:       FormatNDJSON,
+			input:        "{\"name\":\"first\",\"status\":true}\n\n{\"name\":\"second\"}\n",
+			maxRows:      10,
+			expectedRows: 2,
+		},
+		{
+			name:            "todotxt priorities and invalid due",
+			format:          FormatTodoTxt,
+			input:           "(Z) 2024-01-01 someday\n\nx (A) done\ndue:tomorrow pay\n",
+			maxRows:         10,
+			expectedRows:    2,
+			expectedRowErrs: []string{"row 4: due: invalid due \"tomorrow\", should be YYYY-MM-DD or RFC 3339"},
+		},
+		{
+			name:         "markdown skips other lines",
+			format:       FormatMarkdown,
+			input:        "# Tasks\n\n- [ ] first\n  * [X] nested\n1. [ ] numbered\n- not a task\n- [] not a task\n",
+			maxRows:      10,
+			expectedRows: 3,
+		},
+		{
+			name:    "markdown too many rows",
+			format:  FormatMarkdown,
+			input:   "# a\n- [ ] a\n- [ ] b\n",
+			maxRows: 1,
+			wantErr: ErrTooManyRows,
+		},
+		{
+			name:    "ndjson too many rows",
+			format:  FormatNDJSON,
+			input:   "{\"name\":\"a\"}\n{\"name\":\"b\"}\n",
+			maxRows: 1,
+			wantErr: ErrTooManyRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrs, err := Decode(tt.format, strings.NewReader(tt.input), tt.maxRows)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, rows, tt.expectedRows)
+			require.Len(t, rowErrs, len(tt.expectedRowErrs))
+
+			for i := range rowErrs {
+				assert.Equal(t, tt.expectedRowErrs[i], rowErrs[i].Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+// Package taskio provides the encoders and decoders of task import/export formats.
+package taskio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tingchima/gogolook/internal/domain"
+	"gopkg.in/guregu/null.v4"
+)
+
+// todo.txt (https://github.com/todotxt/todo.txt) tags of the task fields,
+// the +project and @context tags are kept in the task name.
+const (
+	todoTagDue        = "due"
+	todoTagRRule      = "rrule"
+	todoTagTimezone   = "tz"
+	todoTagExternalID = "uid"
+	// the priority of completed task, which is removed from the head of line
+	todoTagPriority = "pri"
+)
+
+// todoDateLayout .
+const todoDateLayout = "2006-01-02"
+
+// maxTextLineSize is the maximum size of a line of the text formats.
+const maxTextLineSize = 1 << 20
+
+var todoPriorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+// todoTags are the tags of the task fields, the words of name with the same keys are escaped.
+var todoTags = map[string]bool{
+	todoTagDue:        true,
+	todoTagRRule:      true,
+	todoTagTimezone:   true,
+	todoTagExternalID: true,
+	todoTagPriority:   true,
+}
+
+// writeTodoEscaped writes the rune, the backslash and the whitespaces are escaped as
+// "\\", "\s", "\t", "\n", "\r" and "\uXXXX".
+func writeTodoEscaped(b *strings.Builder, r rune) {
+	switch {
+	case r == '\\':
+		b.WriteString(`\\`)
+	case r == ' ':
+		b.WriteString(`\s`)
+	case r == '\t':
+		b.WriteString(`\t`)
+	case r == '\n':
+		b.WriteString(`\n`)
+	case r == '\r':
+		b.WriteString(`\r`)
+	case unicode.IsSpace(r):
+		fmt.Fprintf(b, `\u%04x`, r)
+	default:
+		b.WriteRune(r)
+	}
+}
+
+// escapeTodoValue escapes the tag value, which is a single word.
+func escapeTodoValue(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		writeTodoEscaped(&b, r)
+	}
+	return b.String()
+}
+
+// escapeTodoName splits the name into words, which are parsed back to the same name:
+// only the single spaces between words are kept, the other whitespaces are escaped,
+// the words like tags are escaped as "due\:..." and the first word like "x", the priority
+// or the date is escaped as "\x", "\(A)" or "\2024-01-01".
+func escapeTodoName(name string) []string {
+
+	var b strings.Builder
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == ' ' && i > 0 && i < len(runes)-1 && !unicode.IsSpace(runes[i-1]) && !unicode.IsSpace(runes[i+1]) {
+			b.WriteRune(r)
+			continue
+		}
+		writeTodoEscaped(&b, r)
+	}
+
+	words := strings.Fields(b.String())
+
+	for i, word := range words {
+		if key, _, ok := strings.Cut(word, ":"); ok && todoTags[key] {
+			words[i] = key + `\:` + word[len(key)+1:]
+		}
+	}
+
+	if len(words) > 0 && (words[0] == "x" || todoPriorityPattern.MatchString(words[0]) || isTodoDate(words[0])) {
+		words[0] = `\` + words[0]
+	}
+
+	return words
+}
+
+// unescapeTodoText reverses the escapes, a backslash before the other runes is removed,
+// e.g. "\:" and "\x", and the trailing backslash is kept.
+func unescapeTodoText(s string) string {
+
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i == len(runes)-1 {
+			b.WriteRune(runes[i])
+			continue
+		}
+
+		i++
+		switch runes[i] {
+		case 's':
+			b.WriteRune(' ')
+		case 't':
+			b.WriteRune('\t')
+		case 'n':
+			b.WriteRune('\n')
+		case 'r':
+			b.WriteRune('\r')
+		case 'u':
+			if i+4 < len(runes) {
+				if code, err := strconv.ParseUint(string(runes[i+1:i+5]), 16, 32); err == nil {
+					b.WriteRune(rune(code))
+					i += 4
+					continue
+				}
+			}
+			b.WriteRune('u')
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+
+	return b.String()
+}
+
+// formatTodoPriority maps the priority 1 to 9 to A to I, the undefined priority is empty.
+func formatTodoPriority(priority int) string {
+	if priority < domain.TaskPriorityHighest || priority > domain.TaskPriorityLowest {
+		return ""
+	}
+	return string(rune('A' + priority - domain.TaskPriorityHighest))
+}
+
+// parseTodoPriority maps A to I to the priority 1 to 9, J to Z are the lowest priority.
+func parseTodoPriority(s string) (int, error) {
+	if len(s) != 1 || s[0] < 'A' || s[0] > 'Z' {
+		return 0, fmt.Errorf("invalid priority %q, should be A to Z", s)
+	}
+	priority := int(s[0]-'A') + domain.TaskPriorityHighest
+	if priority > domain.TaskPriorityLowest {
+		priority = domain.TaskPriorityLowest
+	}
+	return priority, nil
+}
+
+// formatTodoDue writes the date only if the due time is the midnight of the task timezone.
+func formatTodoDue(task domain.Task) string {
+
+	loc := time.UTC
+	if task.Timezone != "" {
+		if l, err := time.LoadLocation(task.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	t := task.DueAt.Time.In(loc)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format(todoDateLayout)
+	}
+
+	return task.DueAt.Time.Format(time.RFC3339)
+}
+
+// parseTodoDue accepts a date, which is the midnight of the task timezone, or RFC 3339.
+func parseTodoDue(s string, timezone string) (null.Time, error) {
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return null.TimeFrom(t), nil
+	}
+
+	loc := time.UTC
+	if timezone != "" {
+		l, err := time.LoadLocation(timezone)
+		if err != nil {
+			return null.Time{}, fmt.Errorf("invalid timezone %q", timezone)
+		}
+		loc = l
+	}
+
+	t, err := time.ParseInLocation(todoDateLayout, s, loc)
+	if err != nil {
+		return null.Time{}, fmt.Errorf("invalid due %q, should be YYYY-MM-DD or RFC 3339", s)
+	}
+
+	return null.TimeFrom(t), nil
+}
+
+// formatTodoText writes the name and the tags of task fields, the name and the tag values are escaped
+// so that they are parsed back as is.
+func formatTodoText(task domain.Task, withPriorityTag bool) string {
+
+	parts := escapeTodoName(task.Name)
+
+	if task.DueAt.Valid {
+		parts = append(parts, todoTagDue+":"+formatTodoDue(task))
+	}
+	if task.RRule != "" {
+		parts = append(parts, todoTagRRule+":"+escapeTodoValue(task.RRule))
+	}
+	if task.Timezone != "" {
+		parts = append(parts, todoTagTimezone+":"+escapeTodoValue(task.Timezone))
+	}
+	if task.ExternalID != "" {
+		parts = append(parts, todoTagExternalID+":"+escapeTodoValue(task.ExternalID))
+	}
+	if priority := formatTodoPriority(task.Priority); priority != "" && withPriorityTag {
+		parts = append(parts, todoTagPriority+":"+priority)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// parseTodoText reads the name and the tags of task fields, the unknown tags are kept in the name
+// and the escapes are reversed.
+func parseTodoText(words []string, task *domain.Task) []RowError {
+
+	var (
+		name    []string
+		due     string
+		rowErrs []RowError
+	)
+
+	for _, word := range words {
+		key, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			name = append(name, word)
+			continue
+		}
+
+		switch key {
+		case todoTagDue:
+			due = value
+		case todoTagRRule:
+			task.RRule = unescapeTodoText(value)
+		case todoTagTimezone:
+			task.Timezone = unescapeTodoText(value)
+		case todoTagExternalID:
+			task.ExternalID = unescapeTodoText(value)
+		case todoTagPriority:
+			priority, err := parseTodoPriority(value)
+			if err != nil {
+				rowErrs = append(rowErrs, RowError{Field: todoTagPriority, Message: err.Error()})
+				continue
+			}
+			task.Priority = priority
+		default:
+			name = append(name, word)
+		}
+	}
+
+	task.Name = unescapeTodoText(strings.Join(name, " "))
+
+	if due != "" {
+		var err error
+		task.DueAt, err = parseTodoDue(due, task.Timezone)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Field: todoTagDue, Message: err.Error()})
+		}
+	}
+
+	return rowErrs
+}
+
+// todoTxtEncoder writes a task per line, the completed task is "x <completion date> <creation date> ..."
+// and keeps the priority as the pri tag.
+type todoTxtEncoder struct {
+	w *bufio.Writer
+}
+
+// newTodoTxtEncoder .
+func newTodoTxtEncoder(w io.Writer) *todoTxtEncoder {
+	return &todoTxtEncoder{w: bufio.NewWriter(w)}
+}
+
+// Encode .
+func (e *todoTxtEncoder) Encode(task domain.Task) error {
+
+	var parts []string
+
+	if task.Status {
+		parts = append(parts, "x")
+
+		// the creation date is only allowed after the completion date
+		completedAt := task.UpdatedAt
+		if completedAt.IsZero() {
+			completedAt = task.CreatedAt
+		}
+		if !completedAt.IsZero() {
+			parts = append(parts, completedAt.UTC().Format(todoDateLayout))
+		}
+	} else if priority := formatTodoPriority(task.Priority); priority != "" {
+		parts = append(parts, "("+priority+")")
+	}
+
+	if !task.CreatedAt.IsZero() {
+		parts = append(parts, task.CreatedAt.UTC().Format(todoDateLayout))
+	}
+
+	parts = append(parts, formatTodoText(task, task.Status))
+
+	_, err := e.w.WriteString(strings.Join(parts, " ") + "\n")
+	return err
+}
+
+// Close .
+func (e *todoTxtEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// isTodoDate .
+func isTodoDate(s string) bool {
+	_, err := time.Parse(todoDateLayout, s)
+	return err == nil
+}
+
+// parseTodoTxtLine .
+func parseTodoTxtLine(line string) (domain.Task, []RowError) {
+
+	var task domain.Task
+
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		task.Status = true
+		words = words[1:]
+
+		// completion date and creation date
+		for i := 0; i < 2 && len(words) > 0 && isTodoDate(words[0]); i++ {
+			words = words[1:]
+		}
+	}
+
+	var rowErrs []RowError
+
+	if len(words) > 0 {
+		if matches := todoPriorityPattern.FindStringSubmatch(words[0]); matches != nil {
+			priority, err := parseTodoPriority(matches[1])
+			if err != nil {
+				rowErrs = append(rowErrs, RowError{Field: todoTagPriority, Message: err.Error()})
+			}
+			task.Priority = priority
+			words = words[1:]
+		}
+	}
+
+	// creation date, the created time is not imported
+	if !task.Status && len(words) > 0 && isTodoDate(words[0]) {
+		words = words[1:]
+	}
+
+	rowErrs = append(rowErrs, parseTodoText(words, &task)...)
+
+	return task, rowErrs
+}
+
+// decodeTodoTxt reads a task per line, the blank lines are skipped and the rows are the line numbers.
+func decodeTodoTxt(r io.Reader, maxRows int) ([]domain.TaskImportRow, []RowError, error) {
+	return decodeTextLines(r, maxRows, func(line string) (domain.Task, []RowError, bool) {
+		if strings.TrimSpace(line) == "" {
+			return domain.Task{}, nil, false
+		}
+		task, rowErrs := parseTodoTxtLine(line)
+		return task, rowErrs, true
+	})
+}
+
+// decodeTextLines decodes the lines by parse, which reports whether the line is a task.
+func decodeTextLines(r io.Reader, maxRows int, parse func(line string) (domain.Task, []RowError, bool)) ([]domain.TaskImportRow, []RowError, error) {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxTextLineSize)
+
+	var (
+		rows    []domain.TaskImportRow
+		rowErrs []RowError
+		count   int
+	)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		task, errs, ok := parse(line)
+		if !ok {
+			continue
+		}
+
+		count++
+		if count > maxRows {
+			return nil, nil, fmt.Errorf("%w, at most %d rows", ErrTooManyRows, maxRows)
+		}
+
+		if len(errs) > 0 {
+			for i := range errs {
+				errs[i].Row = lineNum
+			}
+			rowErrs = append(rowErrs, errs...)
+			continue
+		}
+
+		rows = append(rows, domain.TaskImportRow{Row: lineNum, Task: task})
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, nil, fmt.Errorf("%w: the line is too long, at most %d bytes", ErrInvalidFile, maxTextLineSize)
+		}
+		return nil, nil, err
+	}
+
+	return rows, rowErrs, nil
+}
@@ -0,0 +1,6 @@
+-- TASKS
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS rrule,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS series_start;
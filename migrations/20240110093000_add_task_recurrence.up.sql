@@ -0,0 +1,14 @@
+-- TASKS
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS due_at timestamptz DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS rrule VARCHAR (255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR (64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS series_start timestamptz DEFAULT NULL;
+
+COMMENT ON COLUMN tasks.due_at IS '到期時間';
+
+COMMENT ON COLUMN tasks.rrule IS '重複規則 (RFC 5545 RRULE)';
+
+COMMENT ON COLUMN tasks.timezone IS '重複規則的時區 (IANA)';
+
+COMMENT ON COLUMN tasks.series_start IS '重複系列的起始時間 (DTSTART)';
@@ -0,0 +1,2 @@
+-- REMINDERS
+DROP TABLE IF EXISTS reminders;
@@ -0,0 +1,28 @@
+-- REMINDERS
+CREATE TABLE IF NOT EXISTS reminders(
+    id serial NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    remind_at timestamptz DEFAULT NULL,
+    offset_seconds INTEGER DEFAULT NULL,
+    channel VARCHAR (32) NOT NULL,
+    recipient VARCHAR (255) NOT NULL DEFAULT '',
+    sent_at timestamptz DEFAULT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    retry_at timestamptz DEFAULT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CHECK ((remind_at IS NULL) <> (offset_seconds IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS reminders_pending_idx ON reminders (task_id) WHERE sent_at IS NULL;
+
+COMMENT ON COLUMN reminders.remind_at IS '提醒時間 (絕對時間)';
+
+COMMENT ON COLUMN reminders.offset_seconds IS '相對任務到期時間的秒數, 負值為到期前';
+
+COMMENT ON COLUMN reminders.channel IS '通知管道: log, webhook, smtp';
+
+COMMENT ON COLUMN reminders.recipient IS '通知對象: webhook url 或 email';
+
+COMMENT ON COLUMN reminders.retry_at IS '送出失敗後的下次重試時間';
@@ -0,0 +1,5 @@
+-- TASKS
+DROP INDEX IF EXISTS tasks_external_id_key;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,7 @@
+-- TASKS
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS external_id VARCHAR (255) DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_external_id_key ON tasks (external_id);
+
+COMMENT ON COLUMN tasks.external_id IS '外部系統的任務ID, 匯入時依此更新既有任務';
@@ -0,0 +1,3 @@
+-- TASKS
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS priority;
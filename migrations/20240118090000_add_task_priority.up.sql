@@ -0,0 +1,5 @@
+-- TASKS
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 9);
+
+COMMENT ON COLUMN tasks.priority IS '優先順序 (RFC 5545 PRIORITY), 0 為未指定, 1 最高, 9 最低';
@@ -0,0 +1,2 @@
+-- CALENDAR FEEDS
+DROP TABLE IF EXISTS calendar_feeds;
@@ -0,0 +1,23 @@
+-- CALENDAR FEEDS
+CREATE TABLE IF NOT EXISTS calendar_feeds(
+    id serial NOT NULL,
+    user_name VARCHAR (255) NOT NULL,
+    name VARCHAR (255) NOT NULL DEFAULT '',
+    token_hash CHAR (64) NOT NULL,
+    filter_status BOOLEAN DEFAULT NULL,
+    filter_name VARCHAR (255) NOT NULL DEFAULT '',
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_feeds_token_hash_key ON calendar_feeds (token_hash);
+
+CREATE INDEX IF NOT EXISTS calendar_feeds_user_name_idx ON calendar_feeds (user_name);
+
+COMMENT ON COLUMN calendar_feeds.user_name IS '使用者';
+
+COMMENT ON COLUMN calendar_feeds.token_hash IS '訂閱token的 SHA-256 (hex), 不儲存token本身';
+
+COMMENT ON COLUMN calendar_feeds.filter_status IS '任務狀態篩選, NULL 為不篩選';
+
+COMMENT ON COLUMN calendar_feeds.filter_name IS '任務名稱關鍵字篩選';
@@ -0,0 +1,2 @@
+-- RATE LIMIT BUCKETS
+DROP TABLE IF EXISTS rate_limit_buckets;
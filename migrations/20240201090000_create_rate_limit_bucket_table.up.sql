@@ -0,0 +1,16 @@
+-- RATE LIMIT BUCKETS
+CREATE TABLE IF NOT EXISTS rate_limit_buckets(
+    key VARCHAR (512) NOT NULL,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(key)
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+
+COMMENT ON COLUMN rate_limit_buckets.key IS '規則與請求者, 例如 POST /task||key:1a2b3c4d5e6f';
+
+COMMENT ON COLUMN rate_limit_buckets.tokens IS '剩餘的 token, 於 updated_at 時計算';
+
+COMMENT ON COLUMN rate_limit_buckets.allowed IS '最近一次請求是否允許';
@@ -0,0 +1,2 @@
+-- IDEMPOTENCY KEYS
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,25 @@
+-- IDEMPOTENCY KEYS
+CREATE TABLE IF NOT EXISTS idempotency_keys(
+    key VARCHAR (512) NOT NULL,
+    fingerprint CHAR (64) NOT NULL,
+    lock_token CHAR (32) NOT NULL DEFAULT '',
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    response_status INTEGER,
+    response_content_type VARCHAR (255),
+    response_body BYTEA,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY(key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+COMMENT ON COLUMN idempotency_keys.key IS '請求者與 Idempotency-Key, 例如 key:1a2b3c4d5e6f|7c9e6679';
+
+COMMENT ON COLUMN idempotency_keys.fingerprint IS '請求方法, 路徑與內容的 SHA-256 (hex)';
+
+COMMENT ON COLUMN idempotency_keys.lock_token IS '取得 key 時產生的隨機值, 只有同一次取得的請求可以完成或釋放 key';
+
+COMMENT ON COLUMN idempotency_keys.completed IS '處理中為 FALSE, 處理中的 key 於 expires_at 後視為中斷';
+
+COMMENT ON COLUMN idempotency_keys.expires_at IS '到期後可重新使用此 key';
@@ -0,0 +1,2 @@
+-- NOTIFICATION IDS
+DROP SEQUENCE IF EXISTS notification_id_seq;
@@ -0,0 +1,4 @@
+-- NOTIFICATION IDS
+CREATE SEQUENCE IF NOT EXISTS notification_id_seq;
+
+COMMENT ON SEQUENCE notification_id_seq IS 'NOTIFY 的通知編號, 同一頻道依送達順序遞增, 各實例共用';
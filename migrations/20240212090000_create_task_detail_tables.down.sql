@@ -0,0 +1,11 @@
+-- TASK HISTORY
+DROP TRIGGER IF EXISTS tasks_history_update ON tasks;
+DROP TRIGGER IF EXISTS tasks_history_insert ON tasks;
+DROP FUNCTION IF EXISTS record_task_history();
+DROP TABLE IF EXISTS task_history;
+
+-- SUBTASKS
+DROP TABLE IF EXISTS subtasks;
+
+-- TASK TAGS
+DROP TABLE IF EXISTS task_tags;
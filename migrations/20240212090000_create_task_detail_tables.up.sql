@@ -0,0 +1,70 @@
+-- TASK TAGS
+CREATE TABLE IF NOT EXISTS task_tags(
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag VARCHAR (64) NOT NULL,
+    PRIMARY KEY(task_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS task_tags_tag_idx ON task_tags (tag);
+
+COMMENT ON COLUMN task_tags.tag IS '標籤, 同一任務不重複';
+
+-- SUBTASKS
+CREATE TABLE IF NOT EXISTS subtasks(
+    id serial NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    name VARCHAR (255) NOT NULL,
+    status BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT NULL,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS subtasks_task_id_idx ON subtasks (task_id);
+
+COMMENT ON COLUMN subtasks.name IS '子任務名稱';
+
+COMMENT ON COLUMN subtasks.status IS '子任務狀態';
+
+-- TASK HISTORY
+CREATE TABLE IF NOT EXISTS task_history(
+    id bigserial NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    action VARCHAR (16) NOT NULL,
+    name VARCHAR (255) NOT NULL,
+    status BOOLEAN NOT NULL,
+    due_at timestamptz DEFAULT NULL,
+    priority SMALLINT NOT NULL,
+    changed_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS task_history_task_id_idx ON task_history (task_id, id);
+
+COMMENT ON COLUMN task_history.action IS '異動類型: created, updated';
+
+COMMENT ON COLUMN task_history.name IS '異動後的任務名稱';
+
+COMMENT ON COLUMN task_history.status IS '異動後的任務狀態';
+
+-- the history is recorded by trigger, so that every write path of tasks is covered in the same transaction
+CREATE OR REPLACE FUNCTION record_task_history() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_history (task_id, action, name, status, due_at, priority)
+    VALUES (NEW.id, CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END, NEW.name, COALESCE(NEW.status, FALSE), NEW.due_at, NEW.priority);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_history_insert ON tasks;
+
+CREATE TRIGGER tasks_history_insert AFTER INSERT ON tasks
+    FOR EACH ROW EXECUTE FUNCTION record_task_history();
+
+DROP TRIGGER IF EXISTS tasks_history_update ON tasks;
+
+-- the update without the change of the recorded columns, e.g. the import of the same task, is not recorded
+CREATE TRIGGER tasks_history_update AFTER UPDATE ON tasks
+    FOR EACH ROW
+    WHEN ((OLD.name, OLD.status, OLD.due_at, OLD.priority) IS DISTINCT FROM (NEW.name, NEW.status, NEW.due_at, NEW.priority))
+    EXECUTE FUNCTION record_task_history();
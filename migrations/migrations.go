@@ -0,0 +1,9 @@
+// Package migrations provides the sql migrations embedded in the binary.
+package migrations
+
+import "embed"
+
+// FS contains the up and down migrations of golang-migrate.
+//
+//go:embed *.sql
+var FS embed.FS
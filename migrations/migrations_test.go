@@ -0,0 +1,42 @@
+// Package migrations provides the sql migrations embedded in the binary.
+package migrations
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFS checks that every embedded migration can be rolled back.
+func TestFS(t *testing.T) {
+
+	src, err := iofs.New(FS, ".")
+	require.NoError(t, err)
+	defer src.Close()
+
+	var count int
+
+	version, err := src.First()
+	for err == nil {
+		count++
+
+		up, _, upErr := src.ReadUp(version)
+		require.NoError(t, upErr, version)
+		_ = up.Close()
+
+		down, _, downErr := src.ReadDown(version)
+		require.NoError(t, downErr, version)
+		_ = down.Close()
+
+		version, err = src.Next(version)
+	}
+	assert.True(t, errors.Is(err, os.ErrNotExist), err)
+
+	entries, err := FS.ReadDir(".")
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), count*2)
+}